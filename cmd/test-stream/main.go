@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/dtls/v3"
+	"github.com/rmrfslashbin/hue-mcp/pkg/stream"
+)
+
+const (
+	identity        = "test-application-id"
+	clientKey       = "0123456789abcdef0123456789abcdef"
+	configurationID = "1a8d99cc-967b-44f2-9202-43f976c0fa6b"
+	rate            = 50
+	duration        = 2 * time.Second
+)
+
+// standIn is a local DTLS-PSK server that behaves like the bridge's
+// entertainment endpoint and records the frames it receives
+type standIn struct {
+	listener net.Listener
+	frames   chan stream.Frame
+	errs     chan error
+}
+
+func newStandIn() (*standIn, error) {
+	psk, _ := hex.DecodeString(clientKey)
+
+	listener, err := dtls.Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, &dtls.Config{
+		PSK: func(hint []byte) ([]byte, error) {
+			if string(hint) != identity {
+				return nil, fmt.Errorf("unknown PSK identity %q", hint)
+			}
+			return psk, nil
+		},
+		PSKIdentityHint: []byte("hue-stand-in"),
+		CipherSuites:    []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_GCM_SHA256},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &standIn{listener: listener, frames: make(chan stream.Frame, 1000), errs: make(chan error, 10)}
+	go s.serve()
+	return s, nil
+}
+
+func (s *standIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.read(conn)
+	}
+}
+
+func (s *standIn) read(conn net.Conn) {
+	defer conn.Close()
+
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		frame, err := stream.ParseFrame(buf[:n])
+		if err != nil {
+			s.errs <- err
+			continue
+		}
+		s.frames <- frame
+	}
+}
+
+func main() {
+	fmt.Println("HueStream DTLS stand-in bridge test")
+	fmt.Println("===================================")
+
+	bridge, err := newStandIn()
+	if err != nil {
+		fail("starting stand-in bridge: %v", err)
+	}
+	defer bridge.listener.Close()
+
+	source, err := stream.Effect("rainbow", stream.EffectOptions{Period: time.Second})
+	if err != nil {
+		fail("building effect: %v", err)
+	}
+
+	session, err := stream.Dial(context.Background(), stream.Config{
+		Addr:            bridge.listener.Addr().String(),
+		Identity:        identity,
+		ClientKey:       clientKey,
+		ConfigurationID: configurationID,
+		Channels:        []uint8{0, 1, 2},
+		Rate:            rate,
+		Duration:        duration,
+		Source:          source,
+	})
+	if err != nil {
+		fail("dialing stand-in bridge: %v", err)
+	}
+	fmt.Printf("✓ DTLS-PSK handshake completed\n")
+
+	// The engine must run the cleanup exactly once when the stream ends
+	var cleanups atomic.Int32
+	engine := stream.NewEngine()
+	err = engine.Add(stream.Active{BridgeID: "test", Name: "Test Area", Effect: "rainbow", Status: stream.Status{ConfigurationID: configurationID}}, session, func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	})
+	if err != nil {
+		fail("registering stream: %v", err)
+	}
+
+	<-session.Done()
+	if err := session.Err(); err != nil {
+		fail("stream failed: %v", err)
+	}
+
+	// Let the last packets arrive
+	time.Sleep(200 * time.Millisecond)
+
+	var received []stream.Frame
+drain:
+	for {
+		select {
+		case frame := <-bridge.frames:
+			received = append(received, frame)
+		case err := <-bridge.errs:
+			fail("stand-in bridge: %v", err)
+		default:
+			break drain
+		}
+	}
+
+	expected := int(duration.Seconds() * rate)
+	fmt.Printf("  frames sent: %d, received: %d, expected about %d\n", session.Status().Frames, len(received), expected)
+	if len(received) < expected*8/10 || len(received) > expected*11/10 {
+		fail("received %d frames, expected about %d", len(received), expected)
+	}
+	fmt.Printf("✓ Frame rate within 20%% of %d Hz\n", rate)
+
+	for i, frame := range received {
+		if frame.ConfigurationID != configurationID {
+			fail("frame %d has configuration ID %q", i, frame.ConfigurationID)
+		}
+		if len(frame.Channels) != 3 {
+			fail("frame %d has %d channels", i, len(frame.Channels))
+		}
+		if i > 0 && frame.Sequence != received[i-1].Sequence+1 {
+			fail("frame %d has sequence %d after %d", i, frame.Sequence, received[i-1].Sequence)
+		}
+	}
+	fmt.Println("✓ Frames decode as HueStream v2 with consecutive sequence numbers")
+
+	first, last := received[0].Channels[0], received[len(received)/4].Channels[0]
+	if first == last {
+		fail("rainbow colors did not change")
+	}
+	fmt.Println("✓ Effect colors change over time")
+
+	time.Sleep(100 * time.Millisecond)
+	if n := cleanups.Load(); n != 1 {
+		fail("cleanup ran %d times, expected once", n)
+	}
+	if len(engine.List()) != 0 {
+		fail("engine still lists the finished stream")
+	}
+	if err := engine.StopAll(context.Background()); err != nil {
+		fail("stopping all streams: %v", err)
+	}
+	fmt.Println("✓ Cleanup ran once when the stream ended")
+
+	// A stream without a duration runs until it is stopped
+	session, err = stream.Dial(context.Background(), stream.Config{
+		Addr:            bridge.listener.Addr().String(),
+		Identity:        identity,
+		ClientKey:       clientKey,
+		ConfigurationID: configurationID,
+		Channels:        []uint8{0},
+		Rate:            stream.MinRate,
+		Source:          source,
+	})
+	if err != nil {
+		fail("dialing stand-in bridge again: %v", err)
+	}
+	err = engine.Add(stream.Active{BridgeID: "test", Name: "Test Area", Effect: "rainbow", Status: stream.Status{ConfigurationID: configurationID}}, session, func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	})
+	if err != nil {
+		fail("registering second stream: %v", err)
+	}
+	if !engine.Running("test", configurationID) {
+		fail("engine does not list the new stream")
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	stopped, err := engine.Stop(context.Background(), "test", configurationID)
+	if err != nil {
+		fail("stopping stream: %v", err)
+	}
+	if stopped.Running || stopped.Frames == 0 {
+		fail("stopped stream reports running=%v after %d frames", stopped.Running, stopped.Frames)
+	}
+	if n := cleanups.Load(); n != 2 {
+		fail("cleanup ran %d times in total, expected twice", n)
+	}
+	if _, err := engine.Stop(context.Background(), "test", configurationID); err == nil {
+		fail("stopping a stopped stream succeeded")
+	}
+	fmt.Printf("✓ Stop ended a running stream after %d frames and ran its cleanup\n", stopped.Frames)
+
+	fmt.Println()
+	fmt.Println("PASS")
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf("✗ "+format+"\n", args...)
+	fmt.Println()
+	fmt.Println("FAIL")
+	os.Exit(1)
+}
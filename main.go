@@ -4,11 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/animation"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
 	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/events"
+	"github.com/rmrfslashbin/hue-mcp/pkg/firmware"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/show"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-mcp/pkg/stream"
 	"github.com/rmrfslashbin/hue-mcp/pkg/tools"
 )
 
@@ -27,12 +35,28 @@ func main() {
 	// Initialize bridge manager
 	bridgeManager := bridge.NewManager(cfg)
 
+	// Load the software update history before bridges start streaming events
+	updateHistory, err := firmware.NewHistory(config.UpdateHistoryPath())
+	if err != nil {
+		log.Fatalf("Failed to load software update history: %v", err)
+	}
+	bridgeManager.SetUpdateHistory(updateHistory)
+
 	// Initialize all bridges (non-fatal if none configured)
 	ctx := context.Background()
 	if err := bridgeManager.InitializeBridges(ctx); err != nil {
 		log.Printf("Warning: %v (server will start but tools will not work until bridges are configured)", err)
 	}
 
+	// Load light state snapshots used by restore and undo
+	snapshots, err := snapshot.NewStore(config.SnapshotPath())
+	if err != nil {
+		log.Fatalf("Failed to load snapshots: %v", err)
+	}
+
+	// Name/alias resolution shared by the tools and the confirmation rules
+	res := resolver.New(bridgeManager, cfg)
+
 	// Create MCP server
 	mcpServer := server.NewMCPServer(
 		serverName,
@@ -40,10 +64,20 @@ func main() {
 		server.WithToolCapabilities(true),
 		server.WithResourceCapabilities(true, false),
 		server.WithPromptCapabilities(true),
+		server.WithElicitation(),
+		server.WithToolHandlerMiddleware(tools.DryRunMiddleware(cfg)),
+		server.WithToolHandlerMiddleware(tools.ConfirmMiddleware(cfg, res)),
+		server.WithToolHandlerMiddleware(tools.SnapshotMiddleware(snapshots, bridgeManager)),
 	)
 
+	// Entertainment streams, animations and light shows are tracked so
+	// they can be stopped on shutdown
+	streams := stream.NewEngine()
+	animations := animation.NewEngine()
+	shows := show.NewEngine()
+
 	// Register tools
-	tools.RegisterAllTools(mcpServer, bridgeManager, cfg)
+	tools.RegisterAllTools(mcpServer, bridgeManager, cfg, res, snapshots, streams, animations, shows)
 
 	// Register resources
 	registerResources(mcpServer, bridgeManager)
@@ -51,9 +85,32 @@ func main() {
 	// Register prompts
 	registerPrompts(mcpServer)
 
+	// Forward button presses and dial turns to clients as they happen
+	bridgeManager.Events().Subscribe(func(event events.ButtonEvent) {
+		mcpServer.SendNotificationToAllClients("notifications/hue/button_event", map[string]any{
+			"event": event,
+		})
+	})
+
 	// Start stdio server for Claude Desktop
-	if err := server.ServeStdio(mcpServer); err != nil {
-		log.Fatalf("Server error: %v", err)
+	serveErr := server.ServeStdio(mcpServer)
+
+	// Hand streamed, animated and show lights back and restore them before
+	// exiting
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := streams.StopAll(shutdownCtx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if _, err := animations.StopAll(shutdownCtx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if _, err := shows.StopAll(shutdownCtx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	if serveErr != nil {
+		log.Fatalf("Server error: %v", serveErr)
 	}
 }
 
@@ -128,6 +185,75 @@ func registerResources(s *server.MCPServer, bm *bridge.Manager) {
 		},
 	)
 
+	// Zones resource
+	s.AddResource(
+		mcp.Resource{
+			URI:         "bridges://zones",
+			Name:        "Zones",
+			Description: "All zones across all bridges",
+			MIMEType:    "application/json",
+		},
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			zones, err := bm.GetZones()
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      "bridges://zones",
+					MIMEType: "application/json",
+					Text:     zones,
+				},
+			}, nil
+		},
+	)
+
+	// Sensors resource
+	s.AddResource(
+		mcp.Resource{
+			URI:         "bridges://sensors",
+			Name:        "Sensors",
+			Description: "Motion, temperature and light level sensors across all bridges with their current readings",
+			MIMEType:    "application/json",
+		},
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			sensors, err := bm.GetSensors()
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      "bridges://sensors",
+					MIMEType: "application/json",
+					Text:     sensors,
+				},
+			}, nil
+		},
+	)
+
+	// Software updates resource
+	s.AddResource(
+		mcp.Resource{
+			URI:         "bridges://software_updates",
+			Name:        "Software Updates",
+			Description: "Firmware update state of the bridges and every device, with installed versions",
+			MIMEType:    "application/json",
+		},
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			updates, err := bm.GetSoftwareUpdates()
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      "bridges://software_updates",
+					MIMEType: "application/json",
+					Text:     updates,
+				},
+			}, nil
+		},
+	)
+
 	// Scenes resource
 	s.AddResource(
 		mcp.Resource{
@@ -303,7 +429,7 @@ Consider time of day, room function, and energy efficiency.`, activity)
 1. Suggest light settings (brightness, color, temperature)
 2. Recommend which lights to include
 3. Propose mood and atmosphere
-4. Create the scene configuration
+4. Create the scene with the create_scene tool, either from per-light actions or by capturing the lights' current state
 
 Ask me questions if you need more details about the desired lighting.`
 
@@ -0,0 +1,307 @@
+package animation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Share is the part of a bridge's light command rate an animation may use,
+// leaving the rest for other commands sent while it runs
+const Share = 0.8
+
+// Light is one light an animation plays on
+type Light struct {
+	BridgeID string `json:"bridge_id"`
+	ID       string `json:"light_id"`
+	Name     string `json:"name,omitempty"`
+}
+
+// SendFunc sends one light update, going through the bridge's scheduler
+type SendFunc func(ctx context.Context, light Light, update resources.LightUpdate) error
+
+// Config describes an animation
+type Config struct {
+	// Pattern is one of Patterns
+	Pattern string
+
+	// Lights are played on in order, which matters for chase and color_loop
+	Lights []Light
+
+	// Options tune the pattern; zero values use the pattern's defaults
+	Options Options
+
+	// Rates are the light command rates of the bridges, by bridge ID
+	Rates map[string]float64
+
+	// Cycles stops the animation after this many cycles; zero does not
+	Cycles int
+
+	// Duration stops the animation after this long; zero does not
+	Duration time.Duration
+
+	// Send delivers each light update
+	Send SendFunc
+}
+
+// Status is a snapshot of an animation's progress
+type Status struct {
+	ID         string     `json:"id"`
+	Pattern    string     `json:"pattern"`
+	Lights     []Light    `json:"lights"`
+	IntervalMS int64      `json:"step_interval_ms"`
+	PeriodMS   int64      `json:"period_ms"`
+	Started    time.Time  `json:"started"`
+	Ends       *time.Time `json:"ends,omitempty"`
+	Cycles     int        `json:"cycles,omitempty"`
+	Steps      int        `json:"steps"`
+	Updates    int        `json:"updates"`
+	Errors     int        `json:"errors,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Running    bool       `json:"running"`
+}
+
+// Animation is a running animation
+type Animation struct {
+	id       string
+	cfg      Config
+	pattern  pattern
+	interval time.Duration
+	started  time.Time
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu        sync.Mutex
+	steps     int
+	updates   int
+	errors    int
+	lastError error
+}
+
+// Plan checks an animation and returns the step interval it would run at
+// and the updates of its first step, without sending anything
+func Plan(cfg Config) (time.Duration, []resources.LightUpdate, error) {
+	p, cfg, interval, err := prepare(cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	states := p.state(0, len(cfg.Lights), cfg.Options, rand.New(rand.NewSource(1)))
+	updates := make([]resources.LightUpdate, len(states))
+	for i, s := range states {
+		updates[i] = Update(s, transition(p, interval))
+	}
+
+	return interval, updates, nil
+}
+
+// prepare validates a config, fills in defaults and works out the step
+// interval
+func prepare(cfg Config) (pattern, Config, time.Duration, error) {
+	if len(cfg.Lights) == 0 {
+		return pattern{}, cfg, 0, errors.New("an animation needs at least one light")
+	}
+	if cfg.Send == nil {
+		return pattern{}, cfg, 0, errors.New("an animation needs a send function")
+	}
+
+	p, opts, err := lookup(cfg.Pattern, cfg.Options)
+	if err != nil {
+		return pattern{}, cfg, 0, err
+	}
+	cfg.Options = opts
+
+	return p, cfg, stepInterval(p, cfg), nil
+}
+
+// stepInterval is the pattern's own step length, stretched so that one
+// step's updates fit within each bridge's share of its rate limit
+func stepInterval(p pattern, cfg Config) time.Duration {
+	interval := cfg.Options.Period / time.Duration(max(1, p.steps(len(cfg.Lights), cfg.Options)))
+
+	perBridge := make(map[string]int)
+	for _, light := range cfg.Lights {
+		perBridge[light.BridgeID]++
+	}
+	for bridgeID, n := range perBridge {
+		rate := cfg.Rates[bridgeID]
+		if rate <= 0 {
+			rate = 10
+		}
+		interval = max(interval, time.Duration(float64(n)/(rate*Share)*float64(time.Second)))
+	}
+
+	// The bridge counts transitions in 100ms; round up so the rate holds
+	step := 100 * time.Millisecond
+	return (interval + step - 1).Truncate(step)
+}
+
+// transition is how long lights take to reach each step's state
+func transition(p pattern, interval time.Duration) time.Duration {
+	if !p.fade {
+		return 0
+	}
+	return interval
+}
+
+// Update converts a pattern state to a light update
+func Update(s State, transition time.Duration) resources.LightUpdate {
+	ms := int(transition.Milliseconds())
+	update := resources.LightUpdate{Dynamics: &resources.Dynamics{Duration: &ms}}
+
+	if s.Off {
+		update.On = &resources.OnState{On: false}
+		return update
+	}
+
+	xy, _ := s.Color.XY()
+	update.On = &resources.OnState{On: true}
+	update.Dimming = &resources.Dimming{Brightness: s.Brightness}
+	update.Color = &resources.Color{XY: xy}
+	return update
+}
+
+// start begins playing an animation
+func start(id string, cfg Config) (*Animation, error) {
+	p, cfg, interval, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &Animation{
+		id:       id,
+		cfg:      cfg,
+		pattern:  p,
+		interval: interval,
+		started:  time.Now(),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go a.run(ctx)
+
+	return a, nil
+}
+
+// Stop stops the animation and waits for it to finish sending. It is safe
+// to call more than once.
+func (a *Animation) Stop() {
+	a.cancel()
+	<-a.done
+}
+
+// Done is closed once the animation has stopped
+func (a *Animation) Done() <-chan struct{} {
+	return a.done
+}
+
+// Status returns the animation's progress
+func (a *Animation) Status() Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := Status{
+		ID:         a.id,
+		Pattern:    a.cfg.Pattern,
+		Lights:     a.cfg.Lights,
+		IntervalMS: a.interval.Milliseconds(),
+		PeriodMS:   a.cfg.Options.Period.Milliseconds(),
+		Started:    a.started,
+		Cycles:     a.cfg.Cycles,
+		Steps:      a.steps,
+		Updates:    a.updates,
+		Errors:     a.errors,
+	}
+	if a.cfg.Duration > 0 {
+		ends := a.started.Add(a.cfg.Duration)
+		status.Ends = &ends
+	}
+	if a.lastError != nil {
+		status.LastError = a.lastError.Error()
+	}
+	select {
+	case <-a.done:
+	default:
+		status.Running = true
+	}
+
+	return status
+}
+
+// run plays one step every interval until the animation is stopped or
+// reaches its cycles or duration
+func (a *Animation) run(ctx context.Context) {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	n := len(a.cfg.Lights)
+	steps := a.pattern.steps(n, a.cfg.Options)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	last := make([]*State, n)
+
+	for step := 0; ; step++ {
+		if a.cfg.Cycles > 0 && step >= a.cfg.Cycles*steps {
+			return
+		}
+		if a.cfg.Duration > 0 && time.Since(a.started) >= a.cfg.Duration {
+			return
+		}
+
+		a.step(ctx, a.pattern.state(step, n, a.cfg.Options, rng), last)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// step sends the updates of the lights whose state changed, one sender per
+// bridge so a slow bridge does not hold up the others
+func (a *Animation) step(ctx context.Context, states []State, last []*State) {
+	byBridge := make(map[string][]int)
+	for i, s := range states {
+		if last[i] != nil && *last[i] == s {
+			continue
+		}
+		byBridge[a.cfg.Lights[i].BridgeID] = append(byBridge[a.cfg.Lights[i].BridgeID], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, indexes := range byBridge {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			for _, i := range indexes {
+				err := a.cfg.Send(ctx, a.cfg.Lights[i], Update(states[i], transition(a.pattern, a.interval)))
+				if ctx.Err() != nil {
+					return
+				}
+
+				a.mu.Lock()
+				if err != nil {
+					a.errors++
+					a.lastError = fmt.Errorf("%s: %w", a.cfg.Lights[i].Name, err)
+				} else {
+					a.updates++
+					s := states[i]
+					last[i] = &s
+				}
+				a.mu.Unlock()
+			}
+		}(indexes)
+	}
+	wg.Wait()
+
+	a.mu.Lock()
+	a.steps++
+	a.mu.Unlock()
+}
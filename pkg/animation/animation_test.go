@@ -0,0 +1,270 @@
+package animation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// sent is one update the fake bridge received
+type sent struct {
+	at     time.Time
+	light  string
+	update resources.LightUpdate
+}
+
+// fakeBridge records the updates sent through a real scheduler
+type fakeBridge struct {
+	scheduler *scheduler.Scheduler
+	mu        sync.Mutex
+	updates   []sent
+}
+
+func newFakeBridge(lightRate float64) *fakeBridge {
+	return &fakeBridge{scheduler: scheduler.New(scheduler.Config{LightRate: lightRate})}
+}
+
+func (b *fakeBridge) send(ctx context.Context, light Light, update resources.LightUpdate) error {
+	return b.scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.updates = append(b.updates, sent{at: time.Now(), light: light.ID, update: update})
+		return nil
+	})
+}
+
+func (b *fakeBridge) received() []sent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]sent(nil), b.updates...)
+}
+
+// lights returns n lights on one bridge
+func lights(bridgeID string, n int) []Light {
+	list := make([]Light, n)
+	for i := range list {
+		list[i] = Light{BridgeID: bridgeID, ID: fmt.Sprintf("%s-light-%d", bridgeID, i), Name: fmt.Sprintf("Light %d", i)}
+	}
+	return list
+}
+
+func TestStepInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		lights  []Light
+		options Options
+		rates   map[string]float64
+		want    time.Duration
+	}{
+		{
+			name:    "pattern pace within the rate",
+			pattern: "color_loop",
+			lights:  lights("a", 2),
+			options: Options{Colors: []color.RGB{{R: 1}, {G: 1}, {B: 1}}, Period: 3 * time.Second},
+			rates:   map[string]float64{"a": 10},
+			want:    time.Second,
+		},
+		{
+			name:    "chase stretched to the bridge rate",
+			pattern: "chase",
+			lights:  lights("a", 8),
+			options: Options{Period: time.Second},
+			rates:   map[string]float64{"a": 10},
+			want:    time.Second,
+		},
+		{
+			name:    "slowest bridge sets the pace",
+			pattern: "chase",
+			lights:  append(lights("a", 4), lights("b", 4)...),
+			options: Options{Period: 2 * time.Second},
+			rates:   map[string]float64{"a": 10, "b": 5},
+			want:    time.Second,
+		},
+		{
+			name:    "unknown rate assumes ten a second",
+			pattern: "twinkle",
+			lights:  lights("a", 16),
+			options: Options{Period: time.Second},
+			want:    2 * time.Second,
+		},
+		{
+			name:    "rounded up to whole transitions",
+			pattern: "breathe",
+			lights:  lights("a", 1),
+			options: Options{Period: 1050 * time.Millisecond},
+			rates:   map[string]float64{"a": 10},
+			want:    600 * time.Millisecond,
+		},
+		{
+			name:    "flash kept to its minimum period",
+			pattern: "flash",
+			lights:  lights("a", 1),
+			options: Options{Period: 50 * time.Millisecond},
+			rates:   map[string]float64{"a": 10},
+			want:    300 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, opts, err := lookup(tt.pattern, tt.options)
+			if err != nil {
+				t.Fatalf("lookup: %v", err)
+			}
+			got := stepInterval(p, Config{Lights: tt.lights, Options: opts, Rates: tt.rates})
+			if got != tt.want {
+				t.Errorf("stepInterval = %s, want %s", got, tt.want)
+			}
+			if tt.pattern == "flash" && 2*got < MinFlashPeriod {
+				t.Errorf("flash cycle of %s is faster than %s", 2*got, MinFlashPeriod)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	send := newFakeBridge(10).send
+
+	_, first, err := Plan(Config{Pattern: "chase", Lights: lights("a", 8), Send: send})
+	if err != nil {
+		t.Fatalf("planning chase: %v", err)
+	}
+	if len(first) != 8 || first[0].Dimming.Brightness != 100 || first[1].Dimming.Brightness >= 100 {
+		t.Error("first chase step does not light only the first light")
+	}
+
+	_, first, err = Plan(Config{Pattern: "flash", Lights: lights("a", 1), Send: send})
+	if err != nil {
+		t.Fatalf("planning flash: %v", err)
+	}
+	if *first[0].Dynamics.Duration != 0 {
+		t.Error("flash fades instead of switching")
+	}
+
+	invalid := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "unknown pattern", cfg: Config{Pattern: "strobe", Lights: lights("a", 1), Send: send}},
+		{name: "no lights", cfg: Config{Pattern: "chase", Send: send}},
+		{name: "no send function", cfg: Config{Pattern: "chase", Lights: lights("a", 1)}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Plan(tt.cfg); err == nil {
+				t.Error("Plan accepted an invalid animation")
+			}
+		})
+	}
+}
+
+func TestEngineRunsCycles(t *testing.T) {
+	engine := NewEngine()
+	var cleanups atomic.Int32
+	cleanup := func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	}
+
+	bridge := newFakeBridge(80)
+	cfg := Config{
+		Pattern:  "chase",
+		Lights:   lights("a", 8),
+		Options:  Options{Period: time.Second},
+		Rates:    map[string]float64{"a": bridge.scheduler.LightRate()},
+		Cycles:   2,
+		Duration: time.Minute,
+		Send:     bridge.send,
+	}
+	interval, _, err := Plan(cfg)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	status, err := engine.Start(cfg, cleanup)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := engine.Start(cfg, cleanup); err == nil {
+		t.Error("a second animation started on the same lights")
+	}
+
+	deadline := time.Now().Add(time.Duration(2*8+2) * interval)
+	for len(engine.List()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(engine.List()) != 0 {
+		t.Fatalf("chase %s did not end after its cycles", status.ID)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := cleanups.Load(); n != 1 {
+		t.Errorf("cleanup ran %d times, want once", n)
+	}
+
+	updates := bridge.received()
+	// Step 0 sends every light; each later step changes two
+	if want := 8 + (2*8-1)*2; len(updates) != want {
+		t.Errorf("sent %d updates, want %d (only changed lights)", len(updates), want)
+	}
+	for i := range updates {
+		window := 0
+		for j := i; j < len(updates) && updates[j].at.Sub(updates[i].at) < time.Second; j++ {
+			window++
+		}
+		if window > int(bridge.scheduler.LightRate())+2 {
+			t.Fatalf("%d updates sent within one second", window)
+		}
+	}
+}
+
+func TestEngineStop(t *testing.T) {
+	engine := NewEngine()
+	var cleanups atomic.Int32
+	cleanup := func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	}
+
+	bridge := newFakeBridge(10)
+	cfg := Config{
+		Pattern: "flash",
+		Lights:  lights("a", 1),
+		Rates:   map[string]float64{"a": bridge.scheduler.LightRate()},
+		Send:    bridge.send,
+	}
+	interval, _, err := Plan(cfg)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	status, err := engine.Start(cfg, cleanup)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(3 * interval)
+
+	stopped, err := engine.Stop(context.Background(), status.ID)
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if stopped.Running || stopped.Steps < 2 {
+		t.Errorf("stopped flash reports running=%v after %d steps", stopped.Running, stopped.Steps)
+	}
+	if n := cleanups.Load(); n != 1 {
+		t.Errorf("cleanup ran %d times, want once", n)
+	}
+	if _, err := engine.Stop(context.Background(), status.ID); err == nil {
+		t.Error("stopping a stopped animation succeeded")
+	}
+	if updates := bridge.received(); len(updates) < 2 || updates[1].update.On.On {
+		t.Error("flash did not switch the light off on its second step")
+	}
+}
@@ -0,0 +1,177 @@
+package animation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cleanupTimeout bounds the cleanup of an animation that ended on its own
+const cleanupTimeout = 30 * time.Second
+
+// running is an animation registered with the engine
+type running struct {
+	animation *Animation
+	cleanup   func(context.Context) error
+	once      sync.Once
+	err       error
+}
+
+// Engine keeps track of running animations so they can be listed and
+// stopped, keeps two animations off the same light, and cleans up every
+// animation when it ends or the server shuts down
+type Engine struct {
+	mu         sync.Mutex
+	animations map[string]*running
+	lastID     int64
+}
+
+// NewEngine creates an engine with no animations
+func NewEngine() *Engine {
+	return &Engine{animations: make(map[string]*running)}
+}
+
+// Start plays an animation. cleanup runs exactly once when the animation
+// ends, whether it is stopped or reaches its cycles or duration, and should
+// restore the lights.
+func (e *Engine) Start(cfg Config, cleanup func(context.Context) error) (Status, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, light := range cfg.Lights {
+		if id := e.animating(light.BridgeID, light.ID); id != "" {
+			return Status{}, fmt.Errorf("light %s is already playing animation %s; stop it first", lightLabel(light), id)
+		}
+	}
+
+	id := e.nextID(time.Now())
+	a, err := start(id, cfg)
+	if err != nil {
+		return Status{}, err
+	}
+
+	r := &running{animation: a, cleanup: cleanup}
+	e.animations[id] = r
+
+	// Clean up animations that end on their own
+	go func() {
+		<-a.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		e.finish(ctx, id, r)
+	}()
+
+	return a.Status(), nil
+}
+
+// Animating returns the ID of the animation playing on a light, or ""
+func (e *Engine) Animating(bridgeID, lightID string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.animating(bridgeID, lightID)
+}
+
+// animating is Animating with the lock held
+func (e *Engine) animating(bridgeID, lightID string) string {
+	for id, r := range e.animations {
+		for _, light := range r.animation.cfg.Lights {
+			if light.BridgeID == bridgeID && light.ID == lightID {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// List returns the running animations, oldest first
+func (e *Engine) List() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := make([]Status, 0, len(e.animations))
+	for _, r := range e.animations {
+		list = append(list, r.animation.Status())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.Before(list[j].Started)
+	})
+
+	return list
+}
+
+// Stop stops an animation and waits for its cleanup
+func (e *Engine) Stop(ctx context.Context, id string) (Status, error) {
+	e.mu.Lock()
+	r, ok := e.animations[id]
+	e.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("no animation %q is running", id)
+	}
+
+	r.animation.Stop()
+	err := e.finish(ctx, id, r)
+	return r.animation.Status(), err
+}
+
+// StopAll stops every animation and waits for their cleanup
+func (e *Engine) StopAll(ctx context.Context) ([]Status, error) {
+	e.mu.Lock()
+	animations := make(map[string]*running, len(e.animations))
+	for id, r := range e.animations {
+		animations[id] = r
+	}
+	e.mu.Unlock()
+
+	var stopped []Status
+	var errs []error
+	for id, r := range animations {
+		r.animation.Stop()
+		if err := e.finish(ctx, id, r); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+		}
+		stopped = append(stopped, r.animation.Status())
+	}
+
+	if len(errs) > 0 {
+		return stopped, fmt.Errorf("cleaning up animations: %v", errs)
+	}
+	return stopped, nil
+}
+
+// finish runs an animation's cleanup once and forgets the animation
+func (e *Engine) finish(ctx context.Context, id string, r *running) error {
+	r.once.Do(func() {
+		if r.cleanup != nil {
+			r.err = r.cleanup(ctx)
+		}
+
+		e.mu.Lock()
+		if e.animations[id] == r {
+			delete(e.animations, id)
+		}
+		e.mu.Unlock()
+	})
+	return r.err
+}
+
+// nextID returns a unique, time-ordered animation ID. The caller must hold
+// the lock.
+func (e *Engine) nextID(t time.Time) string {
+	id := t.UnixMilli()
+	if id <= e.lastID {
+		id = e.lastID + 1
+	}
+	e.lastID = id
+	return "anim-" + strconv.FormatInt(id, 36)
+}
+
+// lightLabel names a light for messages
+func lightLabel(light Light) string {
+	if light.Name != "" {
+		return fmt.Sprintf("%q", light.Name)
+	}
+	return light.ID
+}
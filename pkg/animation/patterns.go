@@ -0,0 +1,180 @@
+// Package animation plays named animations on sets of lights with ordinary
+// light commands, paced to stay within the bridges' rate limits
+package animation
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+)
+
+// Patterns are the animations that can be played
+var Patterns = []string{"color_loop", "breathe", "chase", "flash", "twinkle"}
+
+// MinFlashPeriod is the shortest on/off cycle of the flash pattern. Two
+// flashes a second stays below the three per second photosensitivity
+// guidelines treat as hazardous.
+const MinFlashPeriod = 500 * time.Millisecond
+
+// Options tune a pattern
+type Options struct {
+	// Colors the pattern uses; patterns that need one color use the first
+	Colors []color.RGB
+
+	// Period is the length of one cycle
+	Period time.Duration
+
+	// Brightness is the peak brightness from 1 to 100
+	Brightness float64
+}
+
+// State is what a pattern wants one light to show at a step
+type State struct {
+	Off        bool      `json:"off,omitempty"`
+	Color      color.RGB `json:"color"`
+	Brightness float64   `json:"brightness"`
+}
+
+// pattern describes how an animation evolves
+type pattern struct {
+	// steps returns how many steps make up one cycle for n lights
+	steps func(n int, opts Options) int
+
+	// fade reports whether lights fade into each step rather than switch
+	fade bool
+
+	// state returns the states of n lights at a step
+	state func(step, n int, opts Options, rng *rand.Rand) []State
+}
+
+// patterns maps each pattern name to its behavior
+var patterns = map[string]pattern{
+	// color_loop fades every light through the colors, each light offset so
+	// the colors travel across the set
+	"color_loop": {
+		steps: func(n int, opts Options) int { return len(opts.Colors) },
+		fade:  true,
+		state: func(step, n int, opts Options, rng *rand.Rand) []State {
+			states := make([]State, n)
+			for i := range states {
+				states[i] = State{Color: opts.Colors[(step+i)%len(opts.Colors)], Brightness: opts.Brightness}
+			}
+			return states
+		},
+	},
+
+	// breathe fades all lights between full and low brightness together
+	"breathe": {
+		steps: func(n int, opts Options) int { return 2 },
+		fade:  true,
+		state: func(step, n int, opts Options, rng *rand.Rand) []State {
+			brightness := opts.Brightness
+			if step%2 == 1 {
+				brightness = dim(opts.Brightness)
+			}
+			c := opts.Colors[(step/2)%len(opts.Colors)]
+			return fill(n, State{Color: c, Brightness: brightness})
+		},
+	},
+
+	// chase lights one light at a time, moving along the set in order
+	"chase": {
+		steps: func(n int, opts Options) int { return n },
+		fade:  true,
+		state: func(step, n int, opts Options, rng *rand.Rand) []State {
+			c := opts.Colors[(step/n)%len(opts.Colors)]
+			states := fill(n, State{Color: c, Brightness: dim(opts.Brightness)})
+			states[step%n].Brightness = opts.Brightness
+			return states
+		},
+	},
+
+	// flash switches all lights on and off together, no faster than
+	// MinFlashPeriod allows
+	"flash": {
+		steps: func(n int, opts Options) int { return 2 },
+		fade:  false,
+		state: func(step, n int, opts Options, rng *rand.Rand) []State {
+			if step%2 == 1 {
+				return fill(n, State{Off: true})
+			}
+			c := opts.Colors[(step/2)%len(opts.Colors)]
+			return fill(n, State{Color: c, Brightness: opts.Brightness})
+		},
+	},
+
+	// twinkle gives a random third of the lights a random color and
+	// brightness each step
+	"twinkle": {
+		steps: func(n int, opts Options) int { return 1 },
+		fade:  true,
+		state: func(step, n int, opts Options, rng *rand.Rand) []State {
+			states := make([]State, n)
+			for i := range states {
+				states[i] = State{Color: opts.Colors[0], Brightness: dim(opts.Brightness)}
+				if rng.Intn(3) == 0 {
+					states[i].Color = opts.Colors[rng.Intn(len(opts.Colors))]
+					states[i].Brightness = dim(opts.Brightness) + rng.Float64()*(opts.Brightness-dim(opts.Brightness))
+				}
+			}
+			return states
+		},
+	},
+}
+
+// defaultColors are used when a pattern is started without colors
+var defaultColors = map[string][]color.RGB{
+	"color_loop": color.Palettes["rainbow"],
+	"breathe":    {color.Named["warm_white"]},
+	"chase":      {color.Named["white"]},
+	"flash":      {color.Named["white"]},
+	"twinkle":    {color.Named["warm_white"], color.Named["amber"], color.Named["white"]},
+}
+
+// defaultPeriods are used when a pattern is started without a period
+var defaultPeriods = map[string]time.Duration{
+	"color_loop": 30 * time.Second,
+	"breathe":    8 * time.Second,
+	"chase":      4 * time.Second,
+	"flash":      2 * time.Second,
+	"twinkle":    2 * time.Second,
+}
+
+// lookup returns a pattern and its options with the defaults filled in
+func lookup(name string, opts Options) (pattern, Options, error) {
+	p, ok := patterns[name]
+	if !ok {
+		return pattern{}, opts, fmt.Errorf("unknown animation %q (valid: %v)", name, Patterns)
+	}
+
+	if len(opts.Colors) == 0 {
+		opts.Colors = defaultColors[name]
+	}
+	if opts.Period <= 0 {
+		opts.Period = defaultPeriods[name]
+	}
+	if name == "flash" && opts.Period < MinFlashPeriod {
+		opts.Period = MinFlashPeriod
+	}
+	if opts.Brightness <= 0 || opts.Brightness > 100 {
+		opts.Brightness = 100
+	}
+
+	return p, opts, nil
+}
+
+// dim is the low brightness of patterns that move between bright and dim
+func dim(brightness float64) float64 {
+	return max(1, brightness*0.1)
+}
+
+// fill returns n copies of a state
+func fill(n int, s State) []State {
+	states := make([]State, n)
+	for i := range states {
+		states[i] = s
+	}
+	return states
+}
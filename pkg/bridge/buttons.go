@@ -0,0 +1,111 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+
+	cache "github.com/rmrfslashbin/hue-cache"
+	"github.com/rmrfslashbin/hue-mcp/pkg/events"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Events returns the buffer of recent button events across all bridges
+func (m *Manager) Events() *events.Buffer {
+	return m.events
+}
+
+// recordButtonEvent adds button presses and rotary turns from an SSE change
+// event to the event buffer
+func (m *Manager) recordButtonEvent(br *Bridge, change cache.ChangeEvent) {
+	if change.Type != "update" || len(change.Resource) == 0 {
+		return
+	}
+
+	event := events.ButtonEvent{
+		Time:         change.Time,
+		BridgeID:     br.ID,
+		ResourceID:   change.ResourceID,
+		ResourceType: change.ResourceType,
+	}
+
+	switch change.ResourceType {
+	case "button":
+		var button resources.Button
+		if err := json.Unmarshal(change.Resource, &button); err != nil {
+			return
+		}
+		switch {
+		case button.Button.ButtonReport != nil:
+			event.Event = button.Button.ButtonReport.Event
+			event.Time = button.Button.ButtonReport.Updated
+		case button.Button.LastEvent != "":
+			event.Event = button.Button.LastEvent
+		default:
+			return
+		}
+		event.DeviceID = button.Owner.RID
+		event.Button = button.Metadata.ControlID
+
+	case "relative_rotary":
+		var rotary resources.RelativeRotary
+		if err := json.Unmarshal(change.Resource, &rotary); err != nil {
+			return
+		}
+		switch {
+		case rotary.RelativeRotary.RotaryReport != nil:
+			report := rotary.RelativeRotary.RotaryReport
+			event.Event = report.Action
+			event.Direction = report.Rotation.Direction
+			event.Steps = report.Rotation.Steps
+			event.Time = report.Updated
+		case rotary.RelativeRotary.LastEvent != nil:
+			last := rotary.RelativeRotary.LastEvent
+			event.Event = last.Action
+			event.Direction = last.Rotation.Direction
+			event.Steps = last.Rotation.Steps
+		default:
+			return
+		}
+		event.DeviceID = rotary.Owner.RID
+
+	default:
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = change.Time
+	}
+
+	// SSE updates usually carry only the changed fields, so whatever an
+	// event leaves out is filled in from the cache. The connectivity and
+	// software update recorders do the same.
+	ctx := context.Background()
+	if event.DeviceID == "" || (event.ResourceType == "button" && event.Button == 0) {
+		m.fillButtonOwner(ctx, br, &event)
+	}
+	if device, err := br.CachedClient.Devices().Get(ctx, event.DeviceID); err == nil {
+		event.Device = device.Metadata.Name
+	}
+
+	m.events.Add(event)
+}
+
+// fillButtonOwner looks up the device and button number of an event
+func (m *Manager) fillButtonOwner(ctx context.Context, br *Bridge, event *events.ButtonEvent) {
+	switch event.ResourceType {
+	case "button":
+		button, err := br.CachedClient.Buttons().Get(ctx, event.ResourceID)
+		if err != nil {
+			return
+		}
+		event.DeviceID = button.Owner.RID
+		event.Button = button.Metadata.ControlID
+
+	case "relative_rotary":
+		rotary, err := br.CachedClient.RelativeRotaries().Get(ctx, event.ResourceID)
+		if err != nil {
+			return
+		}
+		event.DeviceID = rotary.Owner.RID
+	}
+}
@@ -0,0 +1,216 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	cache "github.com/rmrfslashbin/hue-cache"
+	"github.com/rmrfslashbin/hue-mcp/pkg/firmware"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// SoftwareUpdate is the software update state of a device or bridge
+type SoftwareUpdate struct {
+	BridgeID   string   `json:"bridge_id"`
+	BridgeName string   `json:"bridge_name"`
+	ID         string   `json:"id"`
+	DeviceID   string   `json:"device_id"`
+	Name       string   `json:"name"`
+	Product    string   `json:"product,omitempty"`
+	IsBridge   bool     `json:"is_bridge,omitempty"`
+	Version    string   `json:"version,omitempty"`
+	State      string   `json:"state"`
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// Pending reports whether an update is waiting or being installed
+func (u SoftwareUpdate) Pending() bool {
+	return u.State != firmware.StateNoUpdate
+}
+
+// updateTracker remembers the last software update state of every device
+// and the history that transitions are written to
+type updateTracker struct {
+	mu      sync.Mutex
+	history *firmware.History
+	states  map[string]string
+}
+
+// SetUpdateHistory sets where software update events are recorded
+func (m *Manager) SetUpdateHistory(history *firmware.History) {
+	m.updates.mu.Lock()
+	defer m.updates.mu.Unlock()
+	m.updates.history = history
+}
+
+// UpdateHistory returns the software update history, or nil when none was set
+func (m *Manager) UpdateHistory() *firmware.History {
+	m.updates.mu.Lock()
+	defer m.updates.mu.Unlock()
+	return m.updates.history
+}
+
+// SoftwareUpdates returns the software update state of every device on the
+// bridge, read from the cache
+func (b *Bridge) SoftwareUpdates(ctx context.Context) ([]SoftwareUpdate, error) {
+	swus, err := b.CachedClient.DeviceSoftwareUpdates().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing software updates: %w", err)
+	}
+
+	devices := make(map[string]resources.Device)
+	if list, err := b.CachedClient.Devices().List(ctx); err == nil {
+		for _, device := range list {
+			devices[device.ID] = device
+		}
+	}
+
+	updates := make([]SoftwareUpdate, 0, len(swus))
+	for _, swu := range swus {
+		update := SoftwareUpdate{
+			BridgeID:   b.ID,
+			BridgeName: b.Name,
+			ID:         swu.ID,
+			DeviceID:   swu.Owner.RID,
+			State:      swu.State,
+			Problems:   swu.Problems,
+		}
+		if device, ok := devices[swu.Owner.RID]; ok {
+			update.Name = device.Metadata.Name
+			update.Product = device.ProductData.ProductName
+			update.Version = device.ProductData.SoftwareVersion
+			update.IsBridge = device.ProductData.ProductArchetype == "bridge_v2"
+		}
+		updates = append(updates, update)
+	}
+
+	// The bridge first, then devices by name
+	sort.Slice(updates, func(i, j int) bool {
+		if updates[i].IsBridge != updates[j].IsBridge {
+			return updates[i].IsBridge
+		}
+		return updates[i].Name < updates[j].Name
+	})
+
+	return updates, nil
+}
+
+// GetSoftwareUpdates returns the software update state of all devices across
+// all bridges as JSON
+func (m *Manager) GetSoftwareUpdates() (string, error) {
+	bridges := m.ListBridges()
+	ctx := context.Background()
+
+	var allUpdates []SoftwareUpdate
+
+	for _, bridge := range bridges {
+		if !bridge.Connected {
+			continue
+		}
+
+		updates, err := bridge.SoftwareUpdates(ctx)
+		if err != nil {
+			continue
+		}
+
+		allUpdates = append(allUpdates, updates...)
+	}
+
+	data, err := json.MarshalIndent(allUpdates, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling software updates: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// RecordSoftwareUpdate adds an entry to the update history, if there is one
+func (m *Manager) RecordSoftwareUpdate(entry firmware.Entry) {
+	history := m.UpdateHistory()
+	if history == nil {
+		return
+	}
+	if err := history.Add(entry); err != nil {
+		fmt.Printf("Warning: Failed to record software update: %v\n", err)
+	}
+}
+
+// recordSoftwareUpdate writes software update state changes from an SSE
+// change event to the update history
+func (m *Manager) recordSoftwareUpdate(br *Bridge, change cache.ChangeEvent) {
+	if change.ResourceType != "device_software_update" || change.Type != "update" || len(change.Resource) == 0 {
+		return
+	}
+
+	var swu resources.DeviceSoftwareUpdate
+	if err := json.Unmarshal(change.Resource, &swu); err != nil || swu.State == "" {
+		return
+	}
+
+	key := br.ID + "/" + change.ResourceID
+	m.updates.mu.Lock()
+	previous := m.updates.states[key]
+	m.updates.states[key] = swu.State
+	m.updates.mu.Unlock()
+
+	if previous == swu.State {
+		return
+	}
+
+	var event string
+	switch {
+	case swu.State == firmware.StateInstalling:
+		event = "installing"
+	case previous == firmware.StateInstalling && swu.State == firmware.StateNoUpdate:
+		event = "installed"
+	case previous == firmware.StateInstalling:
+		event = "failed"
+	case swu.State == firmware.StateReadyToInstall:
+		event = "available"
+	default:
+		return
+	}
+
+	ctx := context.Background()
+	if swu.Owner.RID == "" {
+		if cached, err := br.CachedClient.DeviceSoftwareUpdates().Get(ctx, change.ResourceID); err == nil {
+			swu.Owner = cached.Owner
+			if len(swu.Problems) == 0 {
+				swu.Problems = cached.Problems
+			}
+		}
+	}
+
+	entry := firmware.Entry{
+		Time:     change.Time,
+		BridgeID: br.ID,
+		DeviceID: swu.Owner.RID,
+		Event:    event,
+		State:    swu.State,
+		Problems: swu.Problems,
+	}
+	if device, err := br.CachedClient.Devices().Get(ctx, swu.Owner.RID); err == nil {
+		entry.Device = device.Metadata.Name
+		entry.Version = device.ProductData.SoftwareVersion
+	}
+
+	m.RecordSoftwareUpdate(entry)
+}
+
+// seedSoftwareUpdates remembers the current update state of every device on
+// a bridge, so an install already in progress is recorded when it finishes
+func (m *Manager) seedSoftwareUpdates(ctx context.Context, br *Bridge) {
+	swus, err := br.CachedClient.DeviceSoftwareUpdates().List(ctx)
+	if err != nil {
+		return
+	}
+
+	m.updates.mu.Lock()
+	defer m.updates.mu.Unlock()
+	for _, swu := range swus {
+		m.updates.states[br.ID+"/"+swu.ID] = swu.State
+	}
+}
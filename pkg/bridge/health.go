@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+
+	cache "github.com/rmrfslashbin/hue-cache"
+	"github.com/rmrfslashbin/hue-mcp/pkg/health"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Health returns the connectivity tracker for all bridges
+func (m *Manager) Health() *health.Tracker {
+	return m.health
+}
+
+// recordConnectivity feeds zigbee connectivity changes from an SSE change
+// event to the health tracker
+func (m *Manager) recordConnectivity(br *Bridge, change cache.ChangeEvent) {
+	if change.ResourceType != "zigbee_connectivity" || change.Type != "update" || len(change.Resource) == 0 {
+		return
+	}
+
+	var zc resources.ZigbeeConnectivity
+	if err := json.Unmarshal(change.Resource, &zc); err != nil || zc.Status == "" {
+		return
+	}
+
+	if zc.Owner.RID == "" {
+		cached, err := br.CachedClient.ZigbeeConnectivity().Get(context.Background(), change.ResourceID)
+		if err != nil {
+			return
+		}
+		zc.Owner = cached.Owner
+	}
+
+	m.health.Record(br.ID, zc.Owner.RID, zc.Status, change.Time)
+}
+
+// seedConnectivity records the current connectivity of every device on a
+// bridge so later changes are measured against it
+func (m *Manager) seedConnectivity(ctx context.Context, br *Bridge) {
+	for deviceID, status := range br.Connectivity(ctx) {
+		m.health.Record(br.ID, deviceID, status, br.LastSeen)
+	}
+}
+
+// Connectivity maps the devices on a bridge to their zigbee connectivity
+// status. Devices without a zigbee connection are left out, and the map is
+// empty if the bridge cannot be asked.
+func (b *Bridge) Connectivity(ctx context.Context) map[string]string {
+	statuses := make(map[string]string)
+
+	connectivity, err := b.CachedClient.ZigbeeConnectivity().List(ctx)
+	if err != nil {
+		return statuses
+	}
+
+	for _, zc := range connectivity {
+		statuses[zc.Owner.RID] = zc.Status
+	}
+
+	return statuses
+}
+
+// UnreachableDevices returns the devices whose zigbee connection is not
+// healthy
+func (b *Bridge) UnreachableDevices(ctx context.Context) map[string]bool {
+	unreachable := make(map[string]bool)
+	for deviceID, status := range b.Connectivity(ctx) {
+		if status != "connected" {
+			unreachable[deviceID] = true
+		}
+	}
+	return unreachable
+}
+
+// UnreachableLights returns the lights whose device is not connected
+func (b *Bridge) UnreachableLights(ctx context.Context) map[string]bool {
+	unreachable := make(map[string]bool)
+
+	devices := b.UnreachableDevices(ctx)
+	if len(devices) == 0 {
+		return unreachable
+	}
+
+	lights, err := b.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return unreachable
+	}
+
+	for _, light := range lights {
+		if devices[light.Owner.RID] {
+			unreachable[light.ID] = true
+		}
+	}
+
+	return unreachable
+}
@@ -0,0 +1,194 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	cache "github.com/rmrfslashbin/hue-cache"
+)
+
+// IndexEntry records which bridge a resource lives on
+type IndexEntry struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	BridgeID string `json:"bridge_id"`
+}
+
+// Index maps resource IDs and names to bridges across all bridges.
+// It is built from the caches and kept current by SSE change events.
+type Index struct {
+	mu         sync.RWMutex
+	byID       map[string]IndexEntry
+	generation uint64
+}
+
+// newIndex creates an empty index
+func newIndex() *Index {
+	return &Index{
+		byID: make(map[string]IndexEntry),
+	}
+}
+
+// Lookup returns the entry for a resource ID
+func (i *Index) Lookup(id string) (IndexEntry, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.byID[id]
+	return entry, ok
+}
+
+// LookupName returns all entries whose name matches case-insensitively
+func (i *Index) LookupName(name string) []IndexEntry {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var entries []IndexEntry
+	for _, entry := range i.byID {
+		if entry.Name != "" && strings.EqualFold(entry.Name, name) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Generation returns a counter that changes whenever resources are added,
+// removed, renamed or change members. Consumers use it to invalidate
+// derived indexes.
+func (i *Index) Generation() uint64 {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.generation
+}
+
+// replaceBridge replaces all entries for a bridge
+func (i *Index) replaceBridge(bridgeID string, entries []IndexEntry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for id, entry := range i.byID {
+		if entry.BridgeID == bridgeID {
+			delete(i.byID, id)
+		}
+	}
+	for _, entry := range entries {
+		i.byID[entry.ID] = entry
+	}
+	i.generation++
+}
+
+// apply updates the index from an SSE change event
+func (i *Index) apply(bridgeID string, event cache.ChangeEvent) {
+	if event.ResourceID == "" {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	switch event.Type {
+	case "delete":
+		if _, ok := i.byID[event.ResourceID]; ok {
+			delete(i.byID, event.ResourceID)
+			i.generation++
+		}
+
+	case "add", "update":
+		var partial struct {
+			Metadata *struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Children json.RawMessage `json:"children"`
+		}
+		if len(event.Resource) > 0 {
+			_ = json.Unmarshal(event.Resource, &partial)
+		}
+
+		entry, known := i.byID[event.ResourceID]
+		if !known {
+			entry = IndexEntry{ID: event.ResourceID, Type: event.ResourceType, BridgeID: bridgeID}
+		}
+
+		// Updates only carry metadata when it changed
+		renamed := partial.Metadata != nil && partial.Metadata.Name != entry.Name
+		if renamed {
+			entry.Name = partial.Metadata.Name
+		}
+
+		// A device moving between rooms changes the rooms' children
+		regrouped := partial.Children != nil
+
+		if !known || renamed || regrouped {
+			i.byID[event.ResourceID] = entry
+			i.generation++
+		}
+	}
+}
+
+// RefreshIndex rebuilds the index entries for one bridge from its cache
+func (m *Manager) RefreshIndex(ctx context.Context, bridgeID string) error {
+	br, err := m.GetBridge(bridgeID)
+	if err != nil {
+		return err
+	}
+
+	m.index.replaceBridge(br.ID, indexEntries(ctx, br))
+	return nil
+}
+
+// indexEntries lists the cached resources of a bridge as index entries. A
+// resource type that cannot be listed is left out rather than hiding every
+// other resource.
+func indexEntries(ctx context.Context, br *Bridge) []IndexEntry {
+	var entries []IndexEntry
+	add := func(id, resourceType, name string) {
+		entries = append(entries, IndexEntry{ID: id, Type: resourceType, Name: name, BridgeID: br.ID})
+	}
+
+	lights, _ := br.CachedClient.Lights().List(ctx)
+	for _, light := range lights {
+		add(light.ID, "light", light.Metadata.Name)
+	}
+
+	rooms, _ := br.CachedClient.Rooms().List(ctx)
+	for _, room := range rooms {
+		add(room.ID, "room", room.Metadata.Name)
+	}
+
+	zones, _ := br.CachedClient.Zones().List(ctx)
+	for _, zone := range zones {
+		add(zone.ID, "zone", zone.Metadata.Name)
+	}
+
+	scenes, _ := br.CachedClient.Scenes().List(ctx)
+	for _, scene := range scenes {
+		add(scene.ID, "scene", scene.Metadata.Name)
+	}
+
+	groupedLights, _ := br.CachedClient.GroupedLights().List(ctx)
+	for _, gl := range groupedLights {
+		add(gl.ID, "grouped_light", "")
+	}
+
+	devices, _ := br.CachedClient.Devices().List(ctx)
+	for _, device := range devices {
+		add(device.ID, "device", device.Metadata.Name)
+	}
+
+	smartScenes, _ := br.CachedClient.SmartScenes().List(ctx)
+	for _, smartScene := range smartScenes {
+		add(smartScene.ID, "smart_scene", smartScene.Metadata.Name)
+	}
+
+	entertainment, _ := br.CachedClient.EntertainmentConfigurations().List(ctx)
+	for _, ec := range entertainment {
+		add(ec.ID, "entertainment_configuration", ec.Metadata.Name)
+	}
+
+	return entries
+}
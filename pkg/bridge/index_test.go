@@ -0,0 +1,40 @@
+package bridge
+
+import (
+	"testing"
+
+	cache "github.com/rmrfslashbin/hue-cache"
+)
+
+func TestIndexApplyGeneration(t *testing.T) {
+	index := newIndex()
+	index.replaceBridge("a", []IndexEntry{
+		{ID: "room-1", Type: "room", Name: "Office", BridgeID: "a"},
+	})
+
+	tests := []struct {
+		name    string
+		event   cache.ChangeEvent
+		changed bool
+	}{
+		{"state update", cache.ChangeEvent{Type: "update", ResourceID: "room-1", ResourceType: "room", Resource: []byte(`{"id":"room-1"}`)}, false},
+		{"same name", cache.ChangeEvent{Type: "update", ResourceID: "room-1", ResourceType: "room", Resource: []byte(`{"metadata":{"name":"Office"}}`)}, false},
+		{"rename", cache.ChangeEvent{Type: "update", ResourceID: "room-1", ResourceType: "room", Resource: []byte(`{"metadata":{"name":"Study"}}`)}, true},
+		{"members change", cache.ChangeEvent{Type: "update", ResourceID: "room-1", ResourceType: "room", Resource: []byte(`{"children":[{"rid":"device-1","rtype":"device"}]}`)}, true},
+		{"add", cache.ChangeEvent{Type: "add", ResourceID: "light-1", ResourceType: "light"}, true},
+		{"delete", cache.ChangeEvent{Type: "delete", ResourceID: "light-1", ResourceType: "light"}, true},
+		{"delete unknown", cache.ChangeEvent{Type: "delete", ResourceID: "light-1", ResourceType: "light"}, false},
+	}
+
+	for _, tt := range tests {
+		before := index.Generation()
+		index.apply("a", tt.event)
+		if changed := index.Generation() != before; changed != tt.changed {
+			t.Errorf("%s: generation changed = %v, want %v", tt.name, changed, tt.changed)
+		}
+	}
+
+	if entry, ok := index.Lookup("room-1"); !ok || entry.Name != "Study" {
+		t.Errorf("Lookup(room-1) = %+v, %v, want renamed entry", entry, ok)
+	}
+}
@@ -2,14 +2,19 @@ package bridge
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	cache "github.com/rmrfslashbin/hue-cache"
 	"github.com/rmrfslashbin/hue-cache/backends"
 	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/events"
+	"github.com/rmrfslashbin/hue-mcp/pkg/health"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
 	"github.com/rmrfslashbin/hue-sdk"
 )
 
@@ -17,7 +22,15 @@ import (
 type Manager struct {
 	config  *config.Config
 	bridges map[string]*Bridge
+	index   *Index
+	events  *events.Buffer
+	health  *health.Tracker
+	updates *updateTracker
 	mu      sync.RWMutex
+
+	// initMu serializes bridge initialization, which runs without holding
+	// mu so SSE callbacks and tools can read the bridges meanwhile
+	initMu sync.Mutex
 }
 
 // Bridge represents a single Hue bridge with its cached client
@@ -25,11 +38,15 @@ type Bridge struct {
 	ID            string
 	Name          string
 	IP            string
+	AppKey        string
+	ClientKey     string
 	SDKClient     *hue.Client
+	HTTPClient    *http.Client
 	CachedClient  *cache.CachedClient
 	Backend       cache.Backend
 	SyncEngine    *cache.SyncEngine
 	Manager       *cache.CacheManager
+	Scheduler     *scheduler.Scheduler
 	Connected     bool
 	LastSeen      time.Time
 	Error         error
@@ -40,15 +57,20 @@ func NewManager(cfg *config.Config) *Manager {
 	return &Manager{
 		config:  cfg,
 		bridges: make(map[string]*Bridge),
+		index:   newIndex(),
+		events:  events.NewBuffer(events.DefaultSize),
+		health:  health.NewTracker(),
+		updates: &updateTracker{states: make(map[string]string)},
 	}
 }
 
 // InitializeBridges initializes all configured bridges
 func (m *Manager) InitializeBridges(ctx context.Context) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.initMu.Lock()
+	defer m.initMu.Unlock()
 
-	for _, bridgeCfg := range m.config.Bridges {
+	initialized := 0
+	for _, bridgeCfg := range m.config.BridgeConfigs() {
 		if !bridgeCfg.Enabled {
 			continue
 		}
@@ -60,10 +82,22 @@ func (m *Manager) InitializeBridges(ctx context.Context) error {
 			continue
 		}
 
+		m.mu.Lock()
 		m.bridges[bridgeCfg.ID] = bridge
+		m.mu.Unlock()
+		initialized++
+
+		// Index the bridge's resources so tools can route by ID or name
+		m.index.replaceBridge(bridgeCfg.ID, indexEntries(ctx, bridge))
+
+		// Baseline connectivity so the health report can spot flapping devices
+		m.seedConnectivity(ctx, bridge)
+
+		// Remember update states so finished installs can be recorded
+		m.seedSoftwareUpdates(ctx, bridge)
 	}
 
-	if len(m.bridges) == 0 {
+	if initialized == 0 {
 		return fmt.Errorf("no bridges successfully initialized")
 	}
 
@@ -72,10 +106,13 @@ func (m *Manager) InitializeBridges(ctx context.Context) error {
 
 // initializeBridge initializes a single bridge
 func (m *Manager) initializeBridge(ctx context.Context, cfg config.BridgeConfig) (*Bridge, error) {
-	// Create SDK client
+	// Create SDK client. Requests the SDK does not cover go through the
+	// same HTTP client so the bridge is trusted the same way everywhere.
+	httpClient := newHTTPClient()
 	sdkClient, err := hue.NewClient(
 		hue.WithBridgeIP(cfg.IP),
 		hue.WithAppKey(cfg.AppKey),
+		hue.WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating SDK client: %w", err)
@@ -125,30 +162,50 @@ func (m *Manager) initializeBridge(ctx context.Context, cfg config.BridgeConfig)
 		}
 	}
 
-	// Create sync engine
-	syncEngine := cache.NewSyncEngine(backend, sdkClient, cache.DefaultSyncConfig())
-	if err := syncEngine.Start(); err != nil {
-		return nil, fmt.Errorf("starting sync engine: %w", err)
-	}
-
 	// Create cached client
 	cachedClient := cache.NewCachedClient(backend, sdkClient, &cache.CachedClientConfig{
 		TTL:        0, // No expiration, rely on SSE
 		EnableSync: true,
 	})
 
-	return &Bridge{
+	// Pace commands within the bridge's rate limits
+	commandScheduler := scheduler.New(scheduler.Config{
+		LightRate:   m.config.Server.RateLimit.LightCommandsPerSecond,
+		GroupRate:   m.config.Server.RateLimit.GroupCommandsPerSecond,
+		MaxInFlight: m.config.Server.RateLimit.MaxConcurrent,
+	})
+
+	br := &Bridge{
 		ID:           cfg.ID,
 		Name:         cfg.Name,
 		IP:           cfg.IP,
+		AppKey:       cfg.AppKey,
+		ClientKey:    cfg.ClientKey,
 		SDKClient:    sdkClient,
+		HTTPClient:   httpClient,
 		CachedClient: cachedClient,
 		Backend:      backend,
-		SyncEngine:   syncEngine,
 		Manager:      cacheManager,
+		Scheduler:    commandScheduler,
 		Connected:    true,
 		LastSeen:     time.Now(),
-	}, nil
+	}
+
+	// Create sync engine. Its callbacks get the bridge directly rather than
+	// looking it up, since the bridge is not registered until it is ready.
+	syncEngine := cache.NewSyncEngine(backend, sdkClient, cache.DefaultSyncConfig())
+	syncEngine.Subscribe(func(event cache.ChangeEvent) {
+		m.index.apply(br.ID, event)
+		m.recordButtonEvent(br, event)
+		m.recordConnectivity(br, event)
+		m.recordSoftwareUpdate(br, event)
+	})
+	if err := syncEngine.Start(); err != nil {
+		return nil, fmt.Errorf("starting sync engine: %w", err)
+	}
+	br.SyncEngine = syncEngine
+
+	return br, nil
 }
 
 // GetBridge returns a bridge by ID
@@ -164,13 +221,21 @@ func (m *Manager) GetBridge(id string) (*Bridge, error) {
 	return bridge, nil
 }
 
-// GetDefaultBridge returns the first enabled bridge
+// GetDefaultBridge returns the configured default bridge, or the first
+// connected bridge in configuration order. It is meant for creating new
+// resources; existing resources are routed with LocateBridge.
 func (m *Manager) GetDefaultBridge() (*Bridge, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for _, bridge := range m.bridges {
-		if bridge.Connected {
+	if id := m.config.DefaultBridgeID(); id != "" {
+		if bridge, ok := m.bridges[id]; ok && bridge.Connected {
+			return bridge, nil
+		}
+	}
+
+	for _, bridgeCfg := range m.config.BridgeConfigs() {
+		if bridge, ok := m.bridges[bridgeCfg.ID]; ok && bridge.Connected {
 			return bridge, nil
 		}
 	}
@@ -178,14 +243,36 @@ func (m *Manager) GetDefaultBridge() (*Bridge, error) {
 	return nil, fmt.Errorf("no connected bridges available")
 }
 
-// ListBridges returns all bridges
+// LocateBridge returns the bridge that owns a resource ID
+func (m *Manager) LocateBridge(resourceID string) (*Bridge, error) {
+	if entry, ok := m.index.Lookup(resourceID); ok {
+		return m.GetBridge(entry.BridgeID)
+	}
+
+	// With a single bridge there is nowhere else the resource could be
+	bridges := m.ListBridges()
+	if len(bridges) == 1 && bridges[0].Connected {
+		return bridges[0], nil
+	}
+
+	return nil, fmt.Errorf("resource %q not found on any bridge (specify bridge_id)", resourceID)
+}
+
+// Index returns the cross-bridge resource index
+func (m *Manager) Index() *Index {
+	return m.index
+}
+
+// ListBridges returns all bridges in configuration order
 func (m *Manager) ListBridges() []*Bridge {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	bridges := make([]*Bridge, 0, len(m.bridges))
-	for _, bridge := range m.bridges {
-		bridges = append(bridges, bridge)
+	for _, bridgeCfg := range m.config.BridgeConfigs() {
+		if bridge, ok := m.bridges[bridgeCfg.ID]; ok {
+			bridges = append(bridges, bridge)
+		}
 	}
 
 	return bridges
@@ -313,6 +400,78 @@ func (m *Manager) GetRooms() (string, error) {
 	return string(data), nil
 }
 
+// GetZones returns all zones across all bridges as JSON
+func (m *Manager) GetZones() (string, error) {
+	bridges := m.ListBridges()
+	ctx := context.Background()
+
+	type zoneInfo struct {
+		BridgeID   string `json:"bridge_id"`
+		BridgeName string `json:"bridge_name"`
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+	}
+
+	var allZones []zoneInfo
+
+	for _, bridge := range bridges {
+		if !bridge.Connected {
+			continue
+		}
+
+		zones, err := bridge.CachedClient.Zones().List(ctx)
+		if err != nil {
+			continue
+		}
+
+		for _, zone := range zones {
+			allZones = append(allZones, zoneInfo{
+				BridgeID:   bridge.ID,
+				BridgeName: bridge.Name,
+				ID:         zone.ID,
+				Name:       zone.Metadata.Name,
+				Type:       zone.Type,
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(allZones, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling zones: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// GetSensors returns all sensors and their readings across all bridges as JSON
+func (m *Manager) GetSensors() (string, error) {
+	bridges := m.ListBridges()
+	ctx := context.Background()
+
+	var allSensors []Sensor
+
+	for _, bridge := range bridges {
+		if !bridge.Connected {
+			continue
+		}
+
+		sensors, err := bridge.Sensors(ctx)
+		if err != nil {
+			continue
+		}
+
+		allSensors = append(allSensors, sensors...)
+	}
+
+	data, err := json.MarshalIndent(allSensors, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling sensors: %w", err)
+	}
+
+	return string(data), nil
+}
+
 // GetScenes returns all scenes across all bridges as JSON
 func (m *Manager) GetScenes() (string, error) {
 	bridges := m.ListBridges()
@@ -380,3 +539,17 @@ func (m *Manager) Close() error {
 
 	return nil
 }
+
+// newHTTPClient returns the HTTP client for a bridge's HTTPS API. Bridges
+// serve a certificate from Signify's own CA that no system root trusts. It
+// has no timeout since the SDK keeps its event stream open on it.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+}
@@ -0,0 +1,204 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Sensor is a sensor device with the readings of the sensor services it
+// provides. A Hue motion sensor has all three.
+type Sensor struct {
+	BridgeID    string              `json:"bridge_id"`
+	BridgeName  string              `json:"bridge_name"`
+	DeviceID    string              `json:"device_id"`
+	Name        string              `json:"name"`
+	Product     string              `json:"product,omitempty"`
+	Room        string              `json:"room,omitempty"`
+	Unreachable bool                `json:"unreachable,omitempty"`
+	Battery     *int                `json:"battery,omitempty"`
+	Motion      *MotionReading      `json:"motion,omitempty"`
+	Temperature *TemperatureReading `json:"temperature,omitempty"`
+	LightLevel  *LightLevelReading  `json:"light_level,omitempty"`
+}
+
+// MotionReading is the state of a motion service
+type MotionReading struct {
+	ID          string     `json:"id"`
+	Enabled     bool       `json:"enabled"`
+	Valid       bool       `json:"valid"`
+	Motion      bool       `json:"motion"`
+	Sensitivity *int       `json:"sensitivity,omitempty"`
+	Changed     *time.Time `json:"last_changed,omitempty"`
+}
+
+// TemperatureReading is the state of a temperature service
+type TemperatureReading struct {
+	ID         string     `json:"id"`
+	Enabled    bool       `json:"enabled"`
+	Valid      bool       `json:"valid"`
+	Celsius    float64    `json:"celsius"`
+	Fahrenheit float64    `json:"fahrenheit"`
+	Display    string     `json:"display"`
+	Changed    *time.Time `json:"last_changed,omitempty"`
+}
+
+// LightLevelReading is the state of a light level service
+type LightLevelReading struct {
+	ID      string     `json:"id"`
+	Enabled bool       `json:"enabled"`
+	Valid   bool       `json:"valid"`
+	Raw     int        `json:"raw"`
+	Lux     float64    `json:"lux"`
+	Display string     `json:"display"`
+	Changed *time.Time `json:"last_changed,omitempty"`
+}
+
+// ServiceIDs returns the IDs of the sensor services on the device
+func (s Sensor) ServiceIDs() map[string]string {
+	ids := make(map[string]string)
+	if s.Motion != nil {
+		ids["motion"] = s.Motion.ID
+	}
+	if s.Temperature != nil {
+		ids["temperature"] = s.Temperature.ID
+	}
+	if s.LightLevel != nil {
+		ids["light_level"] = s.LightLevel.ID
+	}
+	return ids
+}
+
+// LuxFromLightLevel converts a Hue light level, 10000*log10(lux)+1, to lux
+func LuxFromLightLevel(level int) float64 {
+	lux := math.Pow(10, float64(level-1)/10000)
+	return math.Round(lux*10) / 10
+}
+
+// Sensors returns the sensor devices on the bridge with their current
+// readings, read from the cache
+func (b *Bridge) Sensors(ctx context.Context) ([]Sensor, error) {
+	motions, err := b.CachedClient.Motion().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing motion sensors: %w", err)
+	}
+	temperatures, err := b.CachedClient.Temperature().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing temperature sensors: %w", err)
+	}
+	lightLevels, err := b.CachedClient.LightLevel().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing light level sensors: %w", err)
+	}
+
+	byDevice := make(map[string]*Sensor)
+	sensor := func(deviceID string) *Sensor {
+		if s, ok := byDevice[deviceID]; ok {
+			return s
+		}
+		s := &Sensor{BridgeID: b.ID, BridgeName: b.Name, DeviceID: deviceID}
+		byDevice[deviceID] = s
+		return s
+	}
+
+	for _, m := range motions {
+		reading := &MotionReading{
+			ID:      m.ID,
+			Enabled: m.Enabled,
+			Valid:   m.Motion.MotionValid,
+			Motion:  m.Motion.Motion,
+		}
+		if m.Motion.MotionReport != nil {
+			reading.Motion = m.Motion.MotionReport.Motion
+			reading.Changed = &m.Motion.MotionReport.Changed
+		}
+		if m.Sensitivity != nil {
+			reading.Sensitivity = &m.Sensitivity.Sensitivity
+		}
+		sensor(m.Owner.RID).Motion = reading
+	}
+
+	for _, t := range temperatures {
+		celsius := t.Temperature.Temperature
+		var changed *time.Time
+		if t.Temperature.TemperatureReport != nil {
+			celsius = t.Temperature.TemperatureReport.Temperature
+			changed = &t.Temperature.TemperatureReport.Changed
+		}
+		fahrenheit := math.Round((celsius*9/5+32)*10) / 10
+		sensor(t.Owner.RID).Temperature = &TemperatureReading{
+			ID:         t.ID,
+			Enabled:    t.Enabled,
+			Valid:      t.Temperature.TemperatureValid,
+			Celsius:    celsius,
+			Fahrenheit: fahrenheit,
+			Display:    fmt.Sprintf("%.1f °C / %.1f °F", celsius, fahrenheit),
+			Changed:    changed,
+		}
+	}
+
+	for _, l := range lightLevels {
+		raw := l.Light.LightLevel
+		var changed *time.Time
+		if l.Light.LightLevelReport != nil {
+			raw = l.Light.LightLevelReport.LightLevel
+			changed = &l.Light.LightLevelReport.Changed
+		}
+		lux := LuxFromLightLevel(raw)
+		sensor(l.Owner.RID).LightLevel = &LightLevelReading{
+			ID:      l.ID,
+			Enabled: l.Enabled,
+			Valid:   l.Light.LightLevelValid,
+			Raw:     raw,
+			Lux:     lux,
+			Display: fmt.Sprintf("%.1f lux", lux),
+			Changed: changed,
+		}
+	}
+
+	// Names, rooms and health are best effort; readings are still useful
+	// without them
+	if devices, err := b.CachedClient.Devices().List(ctx); err == nil {
+		for _, device := range devices {
+			if s, ok := byDevice[device.ID]; ok {
+				s.Name = device.Metadata.Name
+				s.Product = device.ProductData.ProductName
+			}
+		}
+	}
+	if rooms, err := b.CachedClient.Rooms().List(ctx); err == nil {
+		for _, room := range rooms {
+			for _, child := range room.Children {
+				if s, ok := byDevice[child.RID]; ok && child.RType == "device" {
+					s.Room = room.Metadata.Name
+				}
+			}
+		}
+	}
+
+	for deviceID := range b.UnreachableDevices(ctx) {
+		if s, ok := byDevice[deviceID]; ok {
+			s.Unreachable = true
+		}
+	}
+	if powers, err := b.CachedClient.DevicePower().List(ctx); err == nil {
+		for _, power := range powers {
+			if s, ok := byDevice[power.Owner.RID]; ok {
+				level := power.PowerState.BatteryLevel
+				s.Battery = &level
+			}
+		}
+	}
+
+	sensors := make([]Sensor, 0, len(byDevice))
+	for _, s := range byDevice {
+		sensors = append(sensors, *s)
+	}
+	sort.Slice(sensors, func(i, j int) bool {
+		return sensors[i].Name < sensors[j].Name
+	})
+
+	return sensors, nil
+}
@@ -0,0 +1,157 @@
+// Package color converts between RGB and the CIE XY colors Hue lights use,
+// and names common colors and palettes
+package color
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// RGB is an sRGB color, each component from 0 to 1
+type RGB struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+}
+
+// Named are the colors that can be referred to by name
+var Named = map[string]RGB{
+	"red":        {1, 0, 0},
+	"orange":     {1, 0.5, 0},
+	"amber":      {1, 0.75, 0},
+	"yellow":     {1, 1, 0},
+	"lime":       {0.5, 1, 0},
+	"green":      {0, 1, 0},
+	"teal":       {0, 0.5, 0.5},
+	"cyan":       {0, 1, 1},
+	"sky":        {0.53, 0.81, 0.92},
+	"blue":       {0, 0, 1},
+	"indigo":     {0.29, 0, 0.51},
+	"purple":     {0.5, 0, 0.5},
+	"violet":     {0.56, 0, 1},
+	"magenta":    {1, 0, 1},
+	"pink":       {1, 0.41, 0.71},
+	"white":      {1, 1, 1},
+	"warm_white": {1, 0.84, 0.67},
+	"cool_white": {0.87, 0.93, 1},
+}
+
+// Palettes are named lists of colors for effects that cycle through colors
+var Palettes = map[string][]RGB{
+	"rainbow": {{1, 0, 0}, {1, 0.5, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {0.56, 0, 1}},
+	"sunset":  {{1, 0.37, 0.11}, {1, 0.55, 0.2}, {0.93, 0.29, 0.35}, {0.6, 0.2, 0.5}, {0.35, 0.15, 0.45}},
+	"ocean":   {{0, 0.2, 0.6}, {0, 0.45, 0.75}, {0, 0.65, 0.7}, {0.1, 0.8, 0.8}, {0, 0.35, 0.5}},
+	"fire":    {{1, 0.15, 0}, {1, 0.35, 0}, {1, 0.55, 0.05}, {0.9, 0.25, 0}},
+	"forest":  {{0.13, 0.55, 0.13}, {0.33, 0.42, 0.18}, {0.6, 0.8, 0.2}, {0, 0.39, 0}},
+	"aurora":  {{0, 1, 0.5}, {0, 0.8, 0.8}, {0.4, 0.2, 0.9}, {0.8, 0.2, 0.8}},
+	"candy":   {{1, 0.41, 0.71}, {0.53, 0.81, 0.92}, {1, 0.85, 0.4}, {0.6, 1, 0.6}},
+}
+
+// Parse returns the color with the given name or hex code ("#ff8800",
+// "ff8800" or "#f80")
+func Parse(name string) (RGB, error) {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if c, ok := Named[key]; ok {
+		return c, nil
+	}
+	if c, ok := parseHex(key); ok {
+		return c, nil
+	}
+	return RGB{}, fmt.Errorf("unknown color %q: use a hex code like #ff8800 or one of %s", name, strings.Join(names(Named), ", "))
+}
+
+// parseHex reads a 3 or 6 digit hex color, with or without a leading #
+func parseHex(s string) (RGB, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return RGB{}, false
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return RGB{}, false
+	}
+	return RGB{
+		R: float64(v>>16&0xff) / 255,
+		G: float64(v>>8&0xff) / 255,
+		B: float64(v&0xff) / 255,
+	}, true
+}
+
+// Hex returns the color as a #rrggbb code
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", byte8(c.R), byte8(c.G), byte8(c.B))
+}
+
+// Palette returns the palette with the given name
+func Palette(name string) ([]RGB, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if p, ok := Palettes[key]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown palette %q (valid: %s)", name, strings.Join(names(Palettes), ", "))
+}
+
+// XY converts the color to CIE XY and a brightness from 0 to 100 using the
+// wide gamut conversion Hue recommends. Lights clamp the point to their
+// own gamut.
+func (c RGB) XY() (resources.ColorXY, float64) {
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+
+	x := r*0.664511 + g*0.154324 + b*0.162028
+	y := r*0.283881 + g*0.668433 + b*0.047685
+	z := r*0.000088 + g*0.072310 + b*0.986039
+
+	sum := x + y + z
+	if sum == 0 {
+		// Black has no chromaticity; use the white point at zero brightness
+		return resources.ColorXY{X: 0.3227, Y: 0.329}, 0
+	}
+
+	return resources.ColorXY{X: round4(x / sum), Y: round4(y / sum)}, math.Min(100, math.Round(y*1000)/10)
+}
+
+// gamma applies sRGB gamma correction, the inverse of linear
+func gamma(v float64) float64 {
+	if v > 0.0031308 {
+		return 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return v * 12.92
+}
+
+// linear undoes sRGB gamma correction
+func linear(v float64) float64 {
+	v = math.Max(0, math.Min(1, v))
+	if v > 0.04045 {
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return v / 12.92
+}
+
+// byte8 scales a component from 0-1 to 0-255
+func byte8(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// round4 rounds to four decimals, the precision the bridge reports
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// names returns the sorted keys of a map
+func names[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package color
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		invalid bool
+	}{
+		{name: "#ff8800", want: "#ff8800"},
+		{name: "FF8800", want: "#ff8800"},
+		{name: "#f80", want: "#ff8800"},
+		{name: " #F80 ", want: "#ff8800"},
+		{name: "red", want: "#ff0000"},
+		{name: "Warm White", want: Named["warm_white"].Hex()},
+		{name: "#ff88", invalid: true},
+		{name: "#gg8800", invalid: true},
+		{name: "chartreuse", invalid: true},
+		{name: "", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.name)
+			if tt.invalid {
+				if err == nil {
+					t.Errorf("Parse(%q) = %s, want an error", tt.name, c.Hex())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.name, err)
+			}
+			if c.Hex() != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.name, c.Hex(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOKLabRoundTrip(t *testing.T) {
+	for name, c := range Named {
+		back := c.OKLab().RGB()
+		if math.Abs(back.R-c.R) > 1e-6 || math.Abs(back.G-c.G) > 1e-6 || math.Abs(back.B-c.B) > 1e-6 {
+			t.Errorf("%s round-tripped to %+v", name, back)
+		}
+	}
+
+	if l := Named["white"].OKLab(); math.Abs(l.L-1) > 1e-6 || math.Abs(l.A) > 1e-6 || math.Abs(l.B) > 1e-6 {
+		t.Errorf("white is %+v in OKLab", l)
+	}
+}
+
+func TestMix(t *testing.T) {
+	// Blending is perceptual: the middle of black and white is a mid gray,
+	// lighter than the 50% sRGB average would be in linear light
+	gray := Mix(RGB{}, Named["white"], 0.5)
+	if hex := gray.Hex(); hex[1:3] != hex[3:5] || hex[3:5] != hex[5:7] || gray.R < 0.35 || gray.R > 0.5 {
+		t.Errorf("black to white midpoint is %s", gray.Hex())
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	red, yellow, blue := Named["red"], Named["yellow"], Named["blue"]
+
+	tests := []struct {
+		name   string
+		colors []RGB
+		n      int
+		count  int
+		want   map[int]RGB
+	}{
+		{name: "no points", colors: []RGB{red, blue}, n: 0},
+		{name: "no colors", n: 3},
+		{name: "one color", colors: []RGB{red}, n: 3, count: 3, want: map[int]RGB{0: red, 1: red, 2: red}},
+		{name: "one point", colors: []RGB{red, blue}, n: 1, count: 1, want: map[int]RGB{0: red}},
+		{name: "ends kept", colors: []RGB{red, blue}, n: 2, count: 2, want: map[int]RGB{0: red, 1: blue}},
+		{name: "through the middle color", colors: []RGB{red, yellow, blue}, n: 5, count: 5, want: map[int]RGB{0: red, 2: yellow, 4: blue}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := Interpolate(tt.colors, tt.n)
+			if len(points) != tt.count {
+				t.Fatalf("Interpolate returned %d points, want %d", len(points), tt.count)
+			}
+			for i, want := range tt.want {
+				if points[i].Hex() != want.Hex() {
+					t.Errorf("point %d = %s, want %s", i, points[i].Hex(), want.Hex())
+				}
+			}
+		})
+	}
+}
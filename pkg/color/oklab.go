@@ -0,0 +1,74 @@
+package color
+
+import "math"
+
+// Lab is a color in the OKLab perceptual color space, where equal steps
+// look like equal changes in color
+type Lab struct {
+	L float64 `json:"l"`
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// OKLab converts the color to OKLab
+func (c RGB) OKLab() Lab {
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return Lab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// RGB converts the color back to sRGB, clamped to the displayable range
+func (c Lab) RGB() RGB {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(1, gamma(v))) }
+	return RGB{
+		R: clamp(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		G: clamp(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		B: clamp(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s),
+	}
+}
+
+// Mix returns the color a fraction t of the way from a to b, blended in
+// OKLab
+func Mix(a, b RGB, t float64) RGB {
+	la, lb := a.OKLab(), b.OKLab()
+	return Lab{
+		L: la.L + (lb.L-la.L)*t,
+		A: la.A + (lb.A-la.A)*t,
+		B: la.B + (lb.B-la.B)*t,
+	}.RGB()
+}
+
+// Interpolate spreads colors evenly along a line and samples n evenly
+// spaced points from it, blending in OKLab
+func Interpolate(colors []RGB, n int) []RGB {
+	if n <= 0 || len(colors) == 0 {
+		return nil
+	}
+
+	points := make([]RGB, n)
+	for i := range points {
+		if len(colors) == 1 || n == 1 {
+			points[i] = colors[0]
+			continue
+		}
+
+		pos := float64(i) / float64(n-1) * float64(len(colors)-1)
+		j := min(int(pos), len(colors)-2)
+		points[i] = Mix(colors[j], colors[j+1], pos-float64(j))
+	}
+
+	return points
+}
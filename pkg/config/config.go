@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // Config holds the MCP server configuration
@@ -12,11 +14,22 @@ type Config struct {
 	// Bridges is the list of configured Hue bridges
 	Bridges []BridgeConfig `json:"bridges"`
 
+	// DefaultBridge is the bridge ID used when creating new resources.
+	// If empty, the first enabled bridge is used.
+	DefaultBridge string `json:"default_bridge,omitempty"`
+
 	// Cache configuration
 	Cache CacheConfig `json:"cache"`
 
 	// Server configuration
 	Server ServerConfig `json:"server"`
+
+	// Aliases maps user-defined names to resource IDs, names or "Room/Name" paths
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// mu guards Bridges, DefaultBridge and Aliases, which tools change
+	// while other calls read them, and serializes writes of the file
+	mu sync.RWMutex
 }
 
 // BridgeConfig holds configuration for a single Hue bridge
@@ -33,6 +46,9 @@ type BridgeConfig struct {
 	// AppKey is the API key for authentication
 	AppKey string `json:"app_key,omitempty"`
 
+	// ClientKey is the entertainment streaming key returned with the app key
+	ClientKey string `json:"client_key,omitempty"`
+
 	// Enabled indicates if this bridge should be used
 	Enabled bool `json:"enabled"`
 }
@@ -56,6 +72,54 @@ type CacheConfig struct {
 type ServerConfig struct {
 	// LogLevel is the logging level (debug, info, warn, error)
 	LogLevel string `json:"log_level"`
+
+	// RateLimit limits the commands sent to each bridge
+	RateLimit RateLimitConfig `json:"rate_limit,omitempty"`
+
+	// DryRun makes every mutating tool report what it would send instead
+	// of sending it
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Confirm sets which tool calls need the user's confirmation
+	Confirm ConfirmConfig `json:"confirm,omitempty"`
+}
+
+// ConfirmConfig holds the rules for tool calls that must be confirmed by
+// the user before they run. The zero value confirms remove_bridge and
+// every delete tool.
+type ConfirmConfig struct {
+	// Disabled turns all confirmations off
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Tools always need confirmation. Empty uses remove_bridge and the
+	// delete tools.
+	Tools []string `json:"tools,omitempty"`
+
+	// MaxLights is the number of lights a call may change without
+	// confirmation. Zero means no limit.
+	MaxLights int `json:"max_lights,omitempty"`
+
+	// NightRooms are rooms or zones, such as bedrooms, whose lights need
+	// confirmation to change during night hours
+	NightRooms []string `json:"night_rooms,omitempty"`
+
+	// NightStart and NightEnd are the night hours as HH:MM. They default
+	// to 22:00 and 07:00.
+	NightStart string `json:"night_start,omitempty"`
+	NightEnd   string `json:"night_end,omitempty"`
+}
+
+// RateLimitConfig holds per-bridge command limits. Zero values use the
+// Hue guidance of 10 light commands and 1 group command per second.
+type RateLimitConfig struct {
+	// LightCommandsPerSecond is the rate of single-light commands
+	LightCommandsPerSecond float64 `json:"light_commands_per_second,omitempty"`
+
+	// GroupCommandsPerSecond is the rate of grouped light and scene commands
+	GroupCommandsPerSecond float64 `json:"group_commands_per_second,omitempty"`
+
+	// MaxConcurrent is the number of commands sent to a bridge at once
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
 }
 
 // DefaultConfig returns default configuration
@@ -104,6 +168,14 @@ func Load() (*Config, error) {
 
 // Save saves configuration to file
 func (c *Config) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.save()
+}
+
+// save writes the configuration to file. The caller must hold mu.
+func (c *Config) save() error {
 	configPath := filepath.Join(configDir(), "config.json")
 
 	// Ensure config directory exists
@@ -118,15 +190,52 @@ func (c *Config) Save() error {
 	}
 
 	// Write config file
-	if err := os.WriteFile(configPath, data, 0600); err != nil {
+	if err := WriteFile(configPath, data, 0600); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
 
 	return nil
 }
 
+// WriteFile writes data to a file, creating its directory if needed. The
+// data goes to a temporary file of its own that is then renamed over the
+// target, so a crash never leaves a truncated file and concurrent writes
+// never mix.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, base+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
 // AddBridge adds a new bridge to the configuration
 func (c *Config) AddBridge(bridge BridgeConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	// Check for duplicate ID
 	for _, b := range c.Bridges {
 		if b.ID == bridge.ID {
@@ -135,30 +244,141 @@ func (c *Config) AddBridge(bridge BridgeConfig) error {
 	}
 
 	c.Bridges = append(c.Bridges, bridge)
-	return c.Save()
+	return c.save()
 }
 
 // RemoveBridge removes a bridge from the configuration
 func (c *Config) RemoveBridge(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for i, b := range c.Bridges {
 		if b.ID == id {
 			c.Bridges = append(c.Bridges[:i], c.Bridges[i+1:]...)
-			return c.Save()
+			if c.DefaultBridge == id {
+				c.DefaultBridge = ""
+			}
+			return c.save()
 		}
 	}
 	return fmt.Errorf("bridge with ID %q not found", id)
 }
 
-// GetBridge returns a bridge by ID
+// SetDefaultBridge sets the bridge used when creating new resources
+func (c *Config) SetDefaultBridge(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id != "" {
+		if _, err := c.getBridge(id); err != nil {
+			return err
+		}
+	}
+
+	c.DefaultBridge = id
+	return c.save()
+}
+
+// SetAlias adds or replaces an alias. Aliases are case-insensitive.
+func (c *Config) SetAlias(name, target string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Aliases == nil {
+		c.Aliases = make(map[string]string)
+	}
+
+	for existing := range c.Aliases {
+		if strings.EqualFold(existing, name) {
+			delete(c.Aliases, existing)
+		}
+	}
+
+	c.Aliases[name] = target
+	return c.save()
+}
+
+// RemoveAlias removes an alias
+func (c *Config) RemoveAlias(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := false
+	for existing := range c.Aliases {
+		if strings.EqualFold(existing, name) {
+			delete(c.Aliases, existing)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return fmt.Errorf("alias %q not found", name)
+	}
+	return c.save()
+}
+
+// Alias returns the target of an alias, matched case-insensitively
+func (c *Config) Alias(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for existing, target := range c.Aliases {
+		if strings.EqualFold(existing, name) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// AliasTargets returns a copy of all aliases and their targets
+func (c *Config) AliasTargets() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	aliases := make(map[string]string, len(c.Aliases))
+	for name, target := range c.Aliases {
+		aliases[name] = target
+	}
+	return aliases
+}
+
+// GetBridge returns a copy of a bridge's configuration by ID
 func (c *Config) GetBridge(id string) (*BridgeConfig, error) {
-	for i, b := range c.Bridges {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.getBridge(id)
+}
+
+// getBridge returns a copy of a bridge's configuration. The caller must
+// hold mu.
+func (c *Config) getBridge(id string) (*BridgeConfig, error) {
+	for _, b := range c.Bridges {
 		if b.ID == id {
-			return &c.Bridges[i], nil
+			return &b, nil
 		}
 	}
 	return nil, fmt.Errorf("bridge with ID %q not found", id)
 }
 
+// BridgeConfigs returns a copy of the configured bridges
+func (c *Config) BridgeConfigs() []BridgeConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bridges := make([]BridgeConfig, len(c.Bridges))
+	copy(bridges, c.Bridges)
+	return bridges
+}
+
+// DefaultBridgeID returns the bridge used when creating new resources, or ""
+func (c *Config) DefaultBridgeID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.DefaultBridge
+}
+
 // configDir returns the configuration directory path
 func configDir() string {
 	// Use XDG_CONFIG_HOME if set, otherwise ~/.config
@@ -179,3 +399,18 @@ func configDir() string {
 func ConfigPath() string {
 	return filepath.Join(configDir(), "config.json")
 }
+
+// SnapshotPath returns the full path to the light state snapshot file
+func SnapshotPath() string {
+	return filepath.Join(configDir(), "snapshots.json")
+}
+
+// UpdateHistoryPath returns the full path to the software update history file
+func UpdateHistoryPath() string {
+	return filepath.Join(configDir(), "software_updates.json")
+}
+
+// ShowsDir returns the directory light shows are saved in
+func ShowsDir() string {
+	return filepath.Join(configDir(), "shows")
+}
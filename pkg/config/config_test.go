@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	if err := WriteFile(path, []byte(`{"a":1}`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := WriteFile(path, []byte(`{"a":2}`), 0600); err != nil {
+		t.Fatalf("WriteFile over existing file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != `{"a":2}` {
+		t.Errorf("file holds %s, want the second write", data)
+	}
+
+	if leftover, _ := filepath.Glob(path + ".*.tmp"); len(leftover) > 0 {
+		t.Errorf("temporary files left behind: %v", leftover)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := WriteFile(path, []byte(fmt.Sprintf(`{"writer":%d}`, i)), 0600); err != nil {
+				t.Errorf("WriteFile %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var content map[string]int
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Errorf("file holds mixed writes: %s", data)
+	}
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := DefaultConfig()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := cfg.AddBridge(BridgeConfig{ID: fmt.Sprintf("bridge-%d", i)}); err != nil {
+				t.Errorf("AddBridge: %v", err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			if err := cfg.SetAlias(fmt.Sprintf("alias-%d", i), "Office"); err != nil {
+				t.Errorf("SetAlias: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(ConfigPath())
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var saved Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("parsing saved config: %v", err)
+	}
+	if len(saved.Bridges) != 10 || len(saved.Aliases) != 10 {
+		t.Errorf("saved %d bridges and %d aliases, want 10 of each", len(saved.Bridges), len(saved.Aliases))
+	}
+}
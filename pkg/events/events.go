@@ -0,0 +1,87 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSize is the number of button events kept by a buffer
+const DefaultSize = 500
+
+// ButtonEvent is a press of a switch button or a turn of a rotary dial
+type ButtonEvent struct {
+	Time         time.Time `json:"time"`
+	BridgeID     string    `json:"bridge_id"`
+	DeviceID     string    `json:"device_id"`
+	Device       string    `json:"device,omitempty"`
+	ResourceID   string    `json:"resource_id"`
+	ResourceType string    `json:"resource_type"`
+	Button       int       `json:"button,omitempty"`
+	Event        string    `json:"event"`
+	Direction    string    `json:"direction,omitempty"`
+	Steps        int       `json:"steps,omitempty"`
+}
+
+// Buffer is a fixed-size ring buffer of recent button events
+type Buffer struct {
+	mu        sync.Mutex
+	events    []ButtonEvent
+	next      int
+	full      bool
+	listeners []func(ButtonEvent)
+}
+
+// NewBuffer creates a buffer that keeps the last size events
+func NewBuffer(size int) *Buffer {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Buffer{events: make([]ButtonEvent, size)}
+}
+
+// Add records an event, overwriting the oldest one when the buffer is
+// full, and passes it to every subscriber
+func (b *Buffer) Add(event ButtonEvent) {
+	b.mu.Lock()
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	if b.next == 0 {
+		b.full = true
+	}
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// Since returns the events at or after t, newest first
+func (b *Buffer) Since(t time.Time) []ButtonEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := b.next
+	if b.full {
+		count = len(b.events)
+	}
+
+	var events []ButtonEvent
+	for i := 1; i <= count; i++ {
+		event := b.events[(b.next-i+len(b.events))%len(b.events)]
+		if event.Time.Before(t) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// Subscribe calls fn for every event added from now on
+func (b *Buffer) Subscribe(fn func(ButtonEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, fn)
+}
@@ -0,0 +1,111 @@
+package firmware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+)
+
+// MaxEntries is the number of history entries kept
+const MaxEntries = 500
+
+// Update states reported by the bridge for a device's software
+const (
+	StateNoUpdate       = "no_update"
+	StateUpdatePending  = "update_pending"
+	StateReadyToInstall = "ready_to_install"
+	StateInstalling     = "installing"
+)
+
+// Entry is one event in the software update history
+type Entry struct {
+	Time     time.Time `json:"time"`
+	BridgeID string    `json:"bridge_id"`
+	DeviceID string    `json:"device_id"`
+	Device   string    `json:"device,omitempty"`
+	Event    string    `json:"event"`
+	State    string    `json:"state,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Problems []string  `json:"problems,omitempty"`
+}
+
+// History keeps software update events in memory and persists them to a
+// JSON file
+type History struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+// NewHistory loads the history saved at path. A missing file is an empty
+// history.
+func NewHistory(path string) (*History, error) {
+	h := &History{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading update history: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return nil, fmt.Errorf("parsing update history: %w", err)
+	}
+
+	return h, nil
+}
+
+// Add records an entry, dropping the oldest ones beyond MaxEntries
+func (h *History) Add(entry Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > MaxEntries {
+		h.entries = h.entries[len(h.entries)-MaxEntries:]
+	}
+
+	return h.save()
+}
+
+// List returns the most recent entries, newest first. A limit of zero
+// returns all of them.
+func (h *History) List(limit int) []Entry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if limit <= 0 || limit > len(h.entries) {
+		limit = len(h.entries)
+	}
+
+	list := make([]Entry, 0, limit)
+	for i := len(h.entries) - 1; i >= len(h.entries)-limit; i-- {
+		list = append(list, h.entries[i])
+	}
+
+	return list
+}
+
+// save writes the history to disk
+func (h *History) save() error {
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling update history: %w", err)
+	}
+
+	if err := config.WriteFile(h.path, data, 0600); err != nil {
+		return fmt.Errorf("writing update history: %w", err)
+	}
+
+	return nil
+}
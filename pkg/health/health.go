@@ -0,0 +1,94 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// FlapWindow is how far back connectivity changes count towards flapping
+	FlapWindow = time.Hour
+
+	// FlapThreshold is the number of connectivity changes within FlapWindow
+	// that marks a device as flapping
+	FlapThreshold = 3
+
+	// LowBattery is the battery percentage at or below which a battery is low
+	LowBattery = 20
+
+	// maxTransitions is the number of changes kept per device
+	maxTransitions = 50
+)
+
+// Transition is a change of a device's zigbee connectivity status
+type Transition struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+}
+
+// Tracker records zigbee connectivity changes per device
+type Tracker struct {
+	mu      sync.Mutex
+	devices map[string]*deviceHistory
+}
+
+// deviceHistory is the connectivity history of one device
+type deviceHistory struct {
+	status      string
+	transitions []Transition
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{devices: make(map[string]*deviceHistory)}
+}
+
+// Record notes a device's connectivity status. The first status seen for a
+// device is its baseline; after that, only changes are transitions.
+func (t *Tracker) Record(bridgeID, deviceID, status string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := bridgeID + "/" + deviceID
+	history, ok := t.devices[key]
+	if !ok {
+		t.devices[key] = &deviceHistory{status: status}
+		return
+	}
+	if history.status == status {
+		return
+	}
+
+	history.status = status
+	history.transitions = append(history.transitions, Transition{Time: at, Status: status})
+	if len(history.transitions) > maxTransitions {
+		history.transitions = history.transitions[len(history.transitions)-maxTransitions:]
+	}
+}
+
+// Transitions returns a device's connectivity changes at or after since,
+// oldest first
+func (t *Tracker) Transitions(bridgeID, deviceID string, since time.Time) []Transition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	history, ok := t.devices[bridgeID+"/"+deviceID]
+	if !ok {
+		return nil
+	}
+
+	var transitions []Transition
+	for _, transition := range history.transitions {
+		if !transition.Time.Before(since) {
+			transitions = append(transitions, transition)
+		}
+	}
+
+	return transitions
+}
+
+// Flapping reports whether a device changed connectivity at least
+// FlapThreshold times within window
+func (t *Tracker) Flapping(bridgeID, deviceID string, window time.Duration) bool {
+	return len(t.Transitions(bridgeID, deviceID, time.Now().Add(-window))) >= FlapThreshold
+}
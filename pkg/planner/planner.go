@@ -0,0 +1,428 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Mode selects how aggressively a bulk update is optimized
+type Mode string
+
+const (
+	// ModeNone sends one command per light
+	ModeNone Mode = "none"
+
+	// ModeGroups uses grouped_light commands for states shared by a room or zone
+	ModeGroups Mode = "groups"
+
+	// ModeScenes additionally recalls a temporary scene for rooms or zones
+	// whose lights have many distinct states
+	ModeScenes Mode = "scenes"
+)
+
+// ParseMode returns the mode with the given name
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(name); mode {
+	case ModeNone, ModeGroups, ModeScenes:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown optimize mode %q (use groups, scenes or none)", name)
+	}
+}
+
+// minSceneStates is the number of distinct states in a group before a
+// temporary scene is used instead of individual light commands
+const minSceneStates = 3
+
+// StepKind identifies the command a plan step sends
+type StepKind string
+
+const (
+	StepGroupedLight StepKind = "grouped_light"
+	StepLight        StepKind = "light"
+	StepScene        StepKind = "scene"
+)
+
+// Item is one light's requested update
+type Item struct {
+	LightID  string
+	BridgeID string
+	Update   resources.LightUpdate
+}
+
+// Group is a room, zone or whole-bridge group with its grouped light
+type Group struct {
+	ID             string
+	Type           string
+	Name           string
+	BridgeID       string
+	GroupedLightID string
+	Lights         []string
+}
+
+// Step is one command of a plan
+type Step struct {
+	Kind         StepKind                      `json:"kind"`
+	BridgeID     string                        `json:"bridge_id"`
+	TargetID     string                        `json:"target_id"`
+	TargetType   string                        `json:"target_type,omitempty"`
+	TargetName   string                        `json:"target_name,omitempty"`
+	Lights       []string                      `json:"lights"`
+	GroupUpdate  *resources.GroupedLightUpdate `json:"group_update,omitempty"`
+	LightUpdate  *resources.LightUpdate        `json:"light_update,omitempty"`
+	SceneActions []resources.SceneAction       `json:"scene_actions,omitempty"`
+}
+
+// Plan is an ordered set of commands that applies a bulk update
+type Plan struct {
+	Mode          Mode   `json:"mode"`
+	Steps         []Step `json:"steps"`
+	NaiveCommands int    `json:"naive_commands"`
+}
+
+// Commands returns the number of commands the plan sends to the lights
+func (p Plan) Commands() int {
+	return len(p.Steps)
+}
+
+// LoadGroups returns the rooms, zones and whole-bridge group of a bridge
+// with the lights each of them contains
+func LoadGroups(ctx context.Context, br *bridge.Bridge) ([]Group, error) {
+	lights, err := br.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing lights: %w", err)
+	}
+
+	rooms, err := br.CachedClient.Rooms().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing rooms: %w", err)
+	}
+
+	zones, err := br.CachedClient.Zones().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing zones: %w", err)
+	}
+
+	groupedLights, err := br.CachedClient.GroupedLights().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing grouped lights: %w", err)
+	}
+
+	deviceLights := make(map[string][]string)
+	allLights := make([]string, 0, len(lights))
+	for _, light := range lights {
+		deviceLights[light.Owner.RID] = append(deviceLights[light.Owner.RID], light.ID)
+		allLights = append(allLights, light.ID)
+	}
+
+	groupsByOwner := make(map[string]*Group)
+	for _, room := range rooms {
+		group := &Group{ID: room.ID, Type: "room", Name: room.Metadata.Name, BridgeID: br.ID}
+		// Rooms contain devices, which own the lights
+		for _, child := range room.Children {
+			group.Lights = append(group.Lights, deviceLights[child.RID]...)
+		}
+		groupsByOwner[room.ID] = group
+	}
+
+	for _, zone := range zones {
+		group := &Group{ID: zone.ID, Type: "zone", Name: zone.Metadata.Name, BridgeID: br.ID}
+		// Zones contain lights directly
+		for _, child := range zone.Children {
+			if child.RType == "light" {
+				group.Lights = append(group.Lights, child.RID)
+			}
+		}
+		groupsByOwner[zone.ID] = group
+	}
+
+	var groups []Group
+	for _, gl := range groupedLights {
+		if gl.Owner.RType == "bridge_home" {
+			groups = append(groups, Group{
+				ID:             gl.Owner.RID,
+				Type:           "bridge_home",
+				Name:           "All lights",
+				BridgeID:       br.ID,
+				GroupedLightID: gl.ID,
+				Lights:         allLights,
+			})
+			continue
+		}
+
+		if group, ok := groupsByOwner[gl.Owner.RID]; ok && len(group.Lights) > 0 {
+			group.GroupedLightID = gl.ID
+			groups = append(groups, *group)
+		}
+	}
+
+	return groups, nil
+}
+
+// Build plans the commands for a bulk update. Groups whose lights are all
+// part of the request and mostly share a state get a grouped_light command
+// for the shared part, followed by light commands for the differences. A
+// group is only used if every light that differs sets all of the shared
+// fields itself, so no light keeps a state it did not ask for.
+func Build(items []Item, groups []Group, mode Mode) Plan {
+	plan := Plan{Mode: mode, NaiveCommands: len(items)}
+
+	byLight := make(map[string]Item, len(items))
+	for _, item := range items {
+		byLight[item.LightID] = item
+	}
+
+	covered := make(map[string]bool)
+	residual := make(map[string]*resources.LightUpdate)
+
+	if mode != ModeNone {
+		// Larger groups first, so a whole-home or zone command wins over its rooms
+		sorted := make([]Group, len(groups))
+		copy(sorted, groups)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return len(sorted[i].Lights) > len(sorted[j].Lights)
+		})
+
+		for _, group := range sorted {
+			if !available(group, byLight, covered) {
+				continue
+			}
+
+			if mode == ModeScenes && group.Type != "bridge_home" && distinctStates(group, byLight) >= minSceneStates {
+				plan.Steps = append(plan.Steps, sceneStep(group, byLight))
+				for _, id := range group.Lights {
+					covered[id] = true
+					if rest := sceneResidual(byLight[id].Update); rest != nil {
+						residual[id] = rest
+					}
+				}
+				continue
+			}
+
+			step, overrides, ok := groupStep(group, byLight)
+			if !ok {
+				continue
+			}
+
+			plan.Steps = append(plan.Steps, step)
+			for _, id := range group.Lights {
+				covered[id] = true
+				if update, ok := overrides[id]; ok {
+					residual[id] = update
+				}
+			}
+		}
+	}
+
+	// Light commands run after the group commands they refine
+	for _, item := range items {
+		update := item.Update
+		if covered[item.LightID] {
+			rest, ok := residual[item.LightID]
+			if !ok {
+				continue
+			}
+			update = *rest
+		}
+
+		u := update
+		plan.Steps = append(plan.Steps, Step{
+			Kind:        StepLight,
+			BridgeID:    item.BridgeID,
+			TargetID:    item.LightID,
+			Lights:      []string{item.LightID},
+			LightUpdate: &u,
+		})
+	}
+
+	return plan
+}
+
+// available reports whether every light of a group is requested and not yet covered
+func available(group Group, byLight map[string]Item, covered map[string]bool) bool {
+	if group.GroupedLightID == "" || len(group.Lights) < 2 {
+		return false
+	}
+
+	for _, id := range group.Lights {
+		item, ok := byLight[id]
+		if !ok || covered[id] || item.BridgeID != group.BridgeID {
+			return false
+		}
+	}
+
+	return true
+}
+
+// groupStep builds a grouped_light command for the most common groupable
+// state of a group that every other light of the group fully overrides. It
+// returns the light updates still needed afterwards, and false if no state
+// can be used or using the group would not save commands.
+func groupStep(group Group, byLight map[string]Item) (Step, map[string]*resources.LightUpdate, bool) {
+	counts := make(map[string]int)
+	states := make(map[string]resources.GroupedLightUpdate)
+	for _, id := range group.Lights {
+		state := groupable(byLight[id].Update)
+		key := stateKey(state)
+		counts[key]++
+		states[key] = state
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	empty := stateKey(resources.GroupedLightUpdate{})
+	for _, key := range keys {
+		if key == empty {
+			continue
+		}
+
+		state := states[key]
+		overrides, ok := groupOverrides(group, byLight, key, state)
+		if !ok || 1+len(overrides) >= len(group.Lights) {
+			continue
+		}
+
+		return Step{
+			Kind:        StepGroupedLight,
+			BridgeID:    group.BridgeID,
+			TargetID:    group.GroupedLightID,
+			TargetType:  group.Type,
+			TargetName:  group.Name,
+			Lights:      group.Lights,
+			GroupUpdate: &state,
+		}, overrides, true
+	}
+
+	return Step{}, nil, false
+}
+
+// groupOverrides returns the light updates needed after a grouped_light
+// command with the given state. Lights that asked for the state only need
+// what the group cannot apply; the others get their full update, which must
+// set every field of the state so none of it is left on them. It returns
+// false if a light would keep part of the state it did not ask for.
+func groupOverrides(group Group, byLight map[string]Item, key string, state resources.GroupedLightUpdate) (map[string]*resources.LightUpdate, bool) {
+	overrides := make(map[string]*resources.LightUpdate)
+	for _, id := range group.Lights {
+		update := byLight[id].Update
+		if stateKey(groupable(update)) == key {
+			if rest := withoutGroupable(update); rest != nil {
+				overrides[id] = rest
+			}
+			continue
+		}
+
+		if !replacesState(update, state) {
+			return nil, false
+		}
+		u := update
+		overrides[id] = &u
+	}
+
+	return overrides, true
+}
+
+// replacesState reports whether a light update sets every field of a
+// grouped light state. Either color setting replaces the other.
+func replacesState(update resources.LightUpdate, state resources.GroupedLightUpdate) bool {
+	if state.On != nil && update.On == nil {
+		return false
+	}
+	if state.Dimming != nil && update.Dimming == nil {
+		return false
+	}
+	if (state.Color != nil || state.ColorTemperature != nil) && update.Color == nil && update.ColorTemperature == nil {
+		return false
+	}
+	return true
+}
+
+// sceneStep builds a temporary scene holding each light's state
+func sceneStep(group Group, byLight map[string]Item) Step {
+	actions := make([]resources.SceneAction, 0, len(group.Lights))
+	for _, id := range group.Lights {
+		update := byLight[id].Update
+		actions = append(actions, resources.SceneAction{
+			Target: resources.ResourceIdentifier{RID: id, RType: "light"},
+			Action: resources.SceneActionDetails{
+				On:               update.On,
+				Dimming:          update.Dimming,
+				Color:            update.Color,
+				ColorTemperature: update.ColorTemperature,
+				Gradient:         update.Gradient,
+				Effects:          update.Effects,
+			},
+		})
+	}
+
+	return Step{
+		Kind:         StepScene,
+		BridgeID:     group.BridgeID,
+		TargetID:     group.ID,
+		TargetType:   group.Type,
+		TargetName:   group.Name,
+		Lights:       group.Lights,
+		SceneActions: actions,
+	}
+}
+
+// distinctStates counts the distinct requested states within a group
+func distinctStates(group Group, byLight map[string]Item) int {
+	seen := make(map[string]bool)
+	for _, id := range group.Lights {
+		data, _ := json.Marshal(byLight[id].Update)
+		seen[string(data)] = true
+	}
+	return len(seen)
+}
+
+// groupable returns the part of a light update a grouped light can apply
+func groupable(update resources.LightUpdate) resources.GroupedLightUpdate {
+	return resources.GroupedLightUpdate{
+		On:               update.On,
+		Dimming:          update.Dimming,
+		Color:            update.Color,
+		ColorTemperature: update.ColorTemperature,
+	}
+}
+
+// withoutGroupable returns the part of a light update a grouped light
+// cannot apply, or nil if there is none
+func withoutGroupable(update resources.LightUpdate) *resources.LightUpdate {
+	update.On = nil
+	update.Dimming = nil
+	update.Color = nil
+	update.ColorTemperature = nil
+	if stateKey(update) == stateKey(resources.LightUpdate{}) {
+		return nil
+	}
+	return &update
+}
+
+// sceneResidual returns the part of a light update a scene cannot hold
+func sceneResidual(update resources.LightUpdate) *resources.LightUpdate {
+	if update.TimedEffects == nil && update.Alert == nil {
+		return nil
+	}
+	return &resources.LightUpdate{TimedEffects: update.TimedEffects, Alert: update.Alert}
+}
+
+// stateKey returns a comparable representation of a state
+func stateKey(state interface{}) string {
+	data, _ := json.Marshal(state)
+	return string(data)
+}
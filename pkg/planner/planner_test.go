@@ -0,0 +1,208 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+func on(value bool) *resources.OnState {
+	return &resources.OnState{On: value}
+}
+
+func brightness(value float64) *resources.Dimming {
+	return &resources.Dimming{Brightness: value}
+}
+
+func xy(x, y float64) *resources.Color {
+	return &resources.Color{XY: resources.ColorXY{X: x, Y: y}}
+}
+
+func room(lights ...string) Group {
+	return Group{ID: "room-1", Type: "room", Name: "Office", BridgeID: "a", GroupedLightID: "gl-1", Lights: lights}
+}
+
+func items(updates map[string]resources.LightUpdate, order ...string) []Item {
+	result := make([]Item, 0, len(order))
+	for _, id := range order {
+		result = append(result, Item{LightID: id, BridgeID: "a", Update: updates[id]})
+	}
+	return result
+}
+
+// applied returns the state each light ends up with after a plan's grouped
+// light and light steps, field by field
+func applied(plan Plan) map[string]resources.LightUpdate {
+	states := make(map[string]resources.LightUpdate)
+	for _, step := range plan.Steps {
+		switch step.Kind {
+		case StepGroupedLight:
+			for _, id := range step.Lights {
+				state := states[id]
+				if step.GroupUpdate.On != nil {
+					state.On = step.GroupUpdate.On
+				}
+				if step.GroupUpdate.Dimming != nil {
+					state.Dimming = step.GroupUpdate.Dimming
+				}
+				if step.GroupUpdate.Color != nil {
+					state.Color = step.GroupUpdate.Color
+				}
+				states[id] = state
+			}
+		case StepLight:
+			state := states[step.TargetID]
+			if step.LightUpdate.On != nil {
+				state.On = step.LightUpdate.On
+			}
+			if step.LightUpdate.Dimming != nil {
+				state.Dimming = step.LightUpdate.Dimming
+			}
+			if step.LightUpdate.Color != nil {
+				state.Color = step.LightUpdate.Color
+			}
+			states[step.TargetID] = state
+		}
+	}
+	return states
+}
+
+func countKind(plan Plan, kind StepKind) int {
+	n := 0
+	for _, step := range plan.Steps {
+		if step.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBuildSharedState(t *testing.T) {
+	shared := resources.LightUpdate{On: on(true), Dimming: brightness(50)}
+	updates := map[string]resources.LightUpdate{"l1": shared, "l2": shared, "l3": shared, "l4": shared}
+
+	plan := Build(items(updates, "l1", "l2", "l3", "l4"), []Group{room("l1", "l2", "l3", "l4")}, ModeGroups)
+
+	if plan.Commands() != 1 || plan.Steps[0].Kind != StepGroupedLight {
+		t.Fatalf("plan = %+v, want a single grouped light command", plan.Steps)
+	}
+	if plan.NaiveCommands != 4 {
+		t.Errorf("NaiveCommands = %d, want 4", plan.NaiveCommands)
+	}
+}
+
+func TestBuildPartialOverrideSkipsGroup(t *testing.T) {
+	// The fourth light only asks for a color, so a group command turning
+	// the room on at 50% would leave it on at 50%
+	shared := resources.LightUpdate{On: on(true), Dimming: brightness(50)}
+	updates := map[string]resources.LightUpdate{
+		"l1": shared,
+		"l2": shared,
+		"l3": shared,
+		"l4": {Color: xy(0.6, 0.3)},
+	}
+
+	plan := Build(items(updates, "l1", "l2", "l3", "l4"), []Group{room("l1", "l2", "l3", "l4")}, ModeGroups)
+
+	if n := countKind(plan, StepGroupedLight); n != 0 {
+		t.Fatalf("plan has %d grouped light commands, want none", n)
+	}
+	state := applied(plan)["l4"]
+	if state.On != nil || state.Dimming != nil {
+		t.Errorf("l4 ends up with on=%v dimming=%v, want neither set", state.On, state.Dimming)
+	}
+}
+
+func TestBuildFullOverrideUsesGroup(t *testing.T) {
+	shared := resources.LightUpdate{On: on(true), Dimming: brightness(50)}
+	updates := map[string]resources.LightUpdate{
+		"l1": shared,
+		"l2": shared,
+		"l3": shared,
+		"l4": {On: on(true), Dimming: brightness(10), Color: xy(0.6, 0.3)},
+	}
+
+	plan := Build(items(updates, "l1", "l2", "l3", "l4"), []Group{room("l1", "l2", "l3", "l4")}, ModeGroups)
+
+	if plan.Commands() != 2 || countKind(plan, StepGroupedLight) != 1 {
+		t.Fatalf("plan = %+v, want a grouped light command and one light command", plan.Steps)
+	}
+	for id, want := range updates {
+		got := applied(plan)[id]
+		if got.Dimming == nil || got.Dimming.Brightness != want.Dimming.Brightness {
+			t.Errorf("%s ends up with dimming %v, want %v", id, got.Dimming, want.Dimming)
+		}
+	}
+}
+
+func TestBuildPicksStateEveryLightOverrides(t *testing.T) {
+	// The most common state cannot be used because l4 and l5 do not set a
+	// color, but the next one can
+	red := resources.LightUpdate{On: on(true), Dimming: brightness(50), Color: xy(0.6, 0.3)}
+	white := resources.LightUpdate{On: on(true), Dimming: brightness(50)}
+	updates := map[string]resources.LightUpdate{"l1": red, "l2": red, "l3": red, "l4": white, "l5": white}
+
+	plan := Build(items(updates, "l1", "l2", "l3", "l4", "l5"), []Group{room("l1", "l2", "l3", "l4", "l5")}, ModeGroups)
+
+	if countKind(plan, StepGroupedLight) != 1 || plan.Steps[0].GroupUpdate.Color != nil {
+		t.Fatalf("plan = %+v, want a grouped light command without a color", plan.Steps)
+	}
+	for id, want := range updates {
+		got := applied(plan)[id]
+		if (got.Color == nil) != (want.Color == nil) {
+			t.Errorf("%s ends up with color %v, want %v", id, got.Color, want.Color)
+		}
+	}
+	if plan.Commands() >= plan.NaiveCommands {
+		t.Errorf("plan sends %d commands, want fewer than %d", plan.Commands(), plan.NaiveCommands)
+	}
+}
+
+func TestBuildIncompleteGroup(t *testing.T) {
+	shared := resources.LightUpdate{On: on(false)}
+	updates := map[string]resources.LightUpdate{"l1": shared, "l2": shared}
+
+	// l3 is in the room but not in the request
+	plan := Build(items(updates, "l1", "l2"), []Group{room("l1", "l2", "l3")}, ModeGroups)
+
+	if n := countKind(plan, StepGroupedLight); n != 0 {
+		t.Errorf("plan has %d grouped light commands for a partly requested room, want none", n)
+	}
+}
+
+func TestBuildModeNone(t *testing.T) {
+	shared := resources.LightUpdate{On: on(true)}
+	updates := map[string]resources.LightUpdate{"l1": shared, "l2": shared, "l3": shared}
+
+	plan := Build(items(updates, "l1", "l2", "l3"), []Group{room("l1", "l2", "l3")}, ModeNone)
+
+	if plan.Commands() != 3 || countKind(plan, StepLight) != 3 {
+		t.Errorf("plan = %+v, want three light commands", plan.Steps)
+	}
+}
+
+func TestBuildScenes(t *testing.T) {
+	updates := map[string]resources.LightUpdate{
+		"l1": {On: on(true), Color: xy(0.6, 0.3)},
+		"l2": {On: on(true), Color: xy(0.2, 0.6)},
+		"l3": {On: on(true), Color: xy(0.15, 0.1)},
+	}
+
+	plan := Build(items(updates, "l1", "l2", "l3"), []Group{room("l1", "l2", "l3")}, ModeScenes)
+
+	if plan.Commands() != 1 || plan.Steps[0].Kind != StepScene || len(plan.Steps[0].SceneActions) != 3 {
+		t.Errorf("plan = %+v, want one scene with three actions", plan.Steps)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, name := range []string{"none", "groups", "scenes"} {
+		if mode, err := ParseMode(name); err != nil || string(mode) != name {
+			t.Errorf("ParseMode(%q) = %q, %v", name, mode, err)
+		}
+	}
+
+	if _, err := ParseMode("group"); err == nil {
+		t.Error("ParseMode(group) succeeded, want an error")
+	}
+}
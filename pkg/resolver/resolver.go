@@ -0,0 +1,480 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Kind identifies the type of resource being resolved
+type Kind string
+
+const (
+	KindLight         Kind = "light"
+	KindRoom          Kind = "room"
+	KindZone          Kind = "zone"
+	KindScene         Kind = "scene"
+	KindGroupedLight  Kind = "grouped_light"
+	KindDevice        Kind = "device"
+	KindSmartScene    Kind = "smart_scene"
+	KindEntertainment Kind = "entertainment_configuration"
+)
+
+// minRebuildInterval limits rebuilds triggered by lookups that found nothing
+const minRebuildInterval = 2 * time.Second
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Entry is a resource known to the resolver
+type Entry struct {
+	Kind       Kind           `json:"kind"`
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Group      string         `json:"group,omitempty"`
+	GroupID    string         `json:"group_id,omitempty"`
+	BridgeID   string         `json:"bridge_id"`
+	BridgeName string         `json:"bridge_name"`
+	Bridge     *bridge.Bridge `json:"-"`
+}
+
+// Label returns the entry's name, or its ID if the name is unknown
+func (e Entry) Label() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%q", e.Name)
+	}
+	return e.ID
+}
+
+// Describe returns a short human-readable description of the entry
+func (e Entry) Describe() string {
+	desc := fmt.Sprintf("%s %q (id %s", e.Kind, e.Name, e.ID)
+	if e.Group != "" {
+		desc += fmt.Sprintf(", in %q", e.Group)
+	}
+	return desc + fmt.Sprintf(", bridge %s)", e.BridgeID)
+}
+
+// NotFoundError is returned when a query matches no resource
+type NotFoundError struct {
+	Kind        Kind
+	Query       string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("no %s matching %q found", e.Kind, e.Query)
+	if len(e.Suggestions) > 0 {
+		quoted := make([]string, len(e.Suggestions))
+		for i, s := range e.Suggestions {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		msg += fmt.Sprintf(". Did you mean: %s?", strings.Join(quoted, ", "))
+	}
+	return msg
+}
+
+// AmbiguousError is returned when a query matches more than one resource
+type AmbiguousError struct {
+	Kind       Kind
+	Query      string
+	Candidates []Entry
+}
+
+func (e *AmbiguousError) Error() string {
+	lines := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		lines[i] = "  - " + c.Describe()
+	}
+	return fmt.Sprintf("%q matches %d %ss. Use an ID, a \"Room/Name\" path, or bridge_id to pick one:\n%s",
+		e.Query, len(e.Candidates), e.Kind, strings.Join(lines, "\n"))
+}
+
+// Resolver turns IDs, names, "Room/Name" paths and aliases into resources
+type Resolver struct {
+	bm  *bridge.Manager
+	cfg *config.Config
+
+	mu         sync.Mutex
+	entries    []Entry
+	built      time.Time
+	generation uint64
+}
+
+// New creates a resolver backed by the bridge caches. Its index is rebuilt
+// whenever the manager's cross-bridge index reports added, removed, renamed
+// or regrouped resources.
+func New(bm *bridge.Manager, cfg *config.Config) *Resolver {
+	return &Resolver{
+		bm:  bm,
+		cfg: cfg,
+	}
+}
+
+// Resolve finds the single resource of the given kind matching query.
+// The query may be an ID, a name, a "Room/Name" path, or a configured alias.
+// If bridgeID is set, only resources on that bridge are considered.
+func (r *Resolver) Resolve(ctx context.Context, kind Kind, query, bridgeID string) (*Entry, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty %s reference", kind)
+	}
+
+	if target, ok := r.cfg.Alias(query); ok {
+		query = target
+	}
+
+	entries := r.index(ctx, false)
+
+	matches := match(entries, kind, query, bridgeID)
+	if len(matches) == 0 && r.stale() {
+		// The resource may be newer than the index
+		entries = r.index(ctx, true)
+		matches = match(entries, kind, query, bridgeID)
+	}
+
+	switch len(matches) {
+	case 1:
+		return &matches[0], nil
+	case 0:
+		// Unknown IDs are passed through so tools keep working while the cache is incomplete
+		if uuidPattern.MatchString(query) {
+			return r.passthrough(kind, query, bridgeID)
+		}
+		return nil, &NotFoundError{Kind: kind, Query: query, Suggestions: suggest(entries, kind, query, bridgeID)}
+	default:
+		return nil, &AmbiguousError{Kind: kind, Query: query, Candidates: matches}
+	}
+}
+
+// GroupedLightFor returns the grouped light owned by a room or zone entry.
+// An owner that is not indexed yet is looked up on its bridge.
+func (r *Resolver) GroupedLightFor(ctx context.Context, owner *Entry) (*Entry, error) {
+	for _, e := range r.index(ctx, false) {
+		if e.Kind == KindGroupedLight && e.GroupID == owner.ID && e.BridgeID == owner.BridgeID {
+			return &e, nil
+		}
+	}
+
+	if id := groupedLightOf(ctx, owner); id != "" {
+		return r.passthrough(KindGroupedLight, id, owner.BridgeID)
+	}
+
+	return nil, fmt.Errorf("%s %q has no grouped light (does it contain any lights?)", owner.Kind, owner.Name)
+}
+
+// groupedLightOf asks the bridge for the grouped light service of a room or
+// zone, or returns "" if it has none
+func groupedLightOf(ctx context.Context, owner *Entry) string {
+	if owner.Bridge == nil {
+		return ""
+	}
+
+	var services []resources.ResourceIdentifier
+	if room, err := owner.Bridge.CachedClient.Rooms().Get(ctx, owner.ID); err == nil {
+		services = room.Services
+	} else if zone, err := owner.Bridge.CachedClient.Zones().Get(ctx, owner.ID); err == nil {
+		services = zone.Services
+	}
+
+	for _, service := range services {
+		if service.RType == "grouped_light" {
+			return service.RID
+		}
+	}
+	return ""
+}
+
+// Entries returns all indexed resources of the given kind
+func (r *Resolver) Entries(ctx context.Context, kind Kind) ([]Entry, error) {
+	var result []Entry
+	for _, e := range r.index(ctx, false) {
+		if e.Kind == kind {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
+
+// Invalidate forces the next lookup to rebuild the index
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+	r.built = time.Time{}
+}
+
+// stale reports whether the index may be rebuilt after a failed lookup
+func (r *Resolver) stale() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Since(r.built) > minRebuildInterval
+}
+
+// passthrough builds an entry for an ID that is not in the index
+func (r *Resolver) passthrough(kind Kind, id, bridgeID string) (*Entry, error) {
+	var br *bridge.Bridge
+	var err error
+
+	if bridgeID != "" {
+		br, err = r.bm.GetBridge(bridgeID)
+	} else {
+		br, err = r.bm.LocateBridge(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entry{
+		Kind:       kind,
+		ID:         id,
+		BridgeID:   br.ID,
+		BridgeName: br.Name,
+		Bridge:     br,
+	}, nil
+}
+
+// index returns the current index, rebuilding it from the caches if needed
+func (r *Resolver) index(ctx context.Context, force bool) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	generation := r.bm.Index().Generation()
+	if !force && r.entries != nil && generation == r.generation {
+		return r.entries
+	}
+
+	var entries []Entry
+	for _, br := range r.bm.ListBridges() {
+		if !br.Connected {
+			continue
+		}
+
+		entries = append(entries, indexBridge(ctx, br)...)
+	}
+
+	r.entries = entries
+	r.built = time.Now()
+	r.generation = generation
+
+	return entries
+}
+
+// indexBridge builds index entries for all resources on one bridge. A
+// resource type that cannot be listed is left out rather than hiding every
+// other resource.
+func indexBridge(ctx context.Context, br *bridge.Bridge) []Entry {
+	rooms, _ := br.CachedClient.Rooms().List(ctx)
+	zones, _ := br.CachedClient.Zones().List(ctx)
+	lights, _ := br.CachedClient.Lights().List(ctx)
+	scenes, _ := br.CachedClient.Scenes().List(ctx)
+	groupedLights, _ := br.CachedClient.GroupedLights().List(ctx)
+	devices, _ := br.CachedClient.Devices().List(ctx)
+	smartScenes, _ := br.CachedClient.SmartScenes().List(ctx)
+	entertainment, _ := br.CachedClient.EntertainmentConfigurations().List(ctx)
+
+	entry := func(kind Kind, id, name string) Entry {
+		return Entry{Kind: kind, ID: id, Name: name, BridgeID: br.ID, BridgeName: br.Name, Bridge: br}
+	}
+
+	var entries []Entry
+	groupNames := make(map[string]string)
+	deviceRooms := make(map[string]Entry)
+
+	for _, room := range rooms {
+		e := entry(KindRoom, room.ID, room.Metadata.Name)
+		entries = append(entries, e)
+		groupNames[room.ID] = room.Metadata.Name
+
+		// Rooms contain devices; lights are found through their owning device
+		for _, child := range room.Children {
+			deviceRooms[child.RID] = e
+		}
+	}
+
+	for _, zone := range zones {
+		entries = append(entries, entry(KindZone, zone.ID, zone.Metadata.Name))
+		groupNames[zone.ID] = zone.Metadata.Name
+	}
+
+	for _, light := range lights {
+		e := entry(KindLight, light.ID, light.Metadata.Name)
+		if room, ok := deviceRooms[light.Owner.RID]; ok {
+			e.Group = room.Name
+			e.GroupID = room.ID
+		}
+		entries = append(entries, e)
+	}
+
+	for _, device := range devices {
+		e := entry(KindDevice, device.ID, device.Metadata.Name)
+		if room, ok := deviceRooms[device.ID]; ok {
+			e.Group = room.Name
+			e.GroupID = room.ID
+		}
+		entries = append(entries, e)
+	}
+
+	for _, scene := range scenes {
+		e := entry(KindScene, scene.ID, scene.Metadata.Name)
+		e.Group = groupNames[scene.Group.RID]
+		e.GroupID = scene.Group.RID
+		entries = append(entries, e)
+	}
+
+	for _, smartScene := range smartScenes {
+		e := entry(KindSmartScene, smartScene.ID, smartScene.Metadata.Name)
+		e.Group = groupNames[smartScene.Group.RID]
+		e.GroupID = smartScene.Group.RID
+		entries = append(entries, e)
+	}
+
+	for _, ec := range entertainment {
+		entries = append(entries, entry(KindEntertainment, ec.ID, ec.Metadata.Name))
+	}
+
+	for _, gl := range groupedLights {
+		// Grouped lights have no name of their own; they are known by their owner
+		name, ok := groupNames[gl.Owner.RID]
+		if !ok {
+			if gl.Owner.RType != "bridge_home" {
+				continue
+			}
+			name = "All lights"
+		}
+		e := entry(KindGroupedLight, gl.ID, name)
+		e.Group = name
+		e.GroupID = gl.Owner.RID
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// match returns the entries of a kind matching the query.
+// IDs win over paths, and paths win over plain names.
+func match(entries []Entry, kind Kind, query, bridgeID string) []Entry {
+	var byID, byPath, byName []Entry
+
+	group, name, isPath := strings.Cut(query, "/")
+	group, name = strings.TrimSpace(group), strings.TrimSpace(name)
+
+	for _, e := range entries {
+		if e.Kind != kind || (bridgeID != "" && e.BridgeID != bridgeID) {
+			continue
+		}
+
+		switch {
+		case e.ID == query || (kind == KindGroupedLight && e.GroupID == query):
+			byID = append(byID, e)
+		case isPath && strings.EqualFold(e.Group, group) && strings.EqualFold(e.Name, name):
+			byPath = append(byPath, e)
+		case strings.EqualFold(e.Name, query):
+			byName = append(byName, e)
+		}
+	}
+
+	switch {
+	case len(byID) > 0:
+		return byID
+	case len(byPath) > 0:
+		return byPath
+	default:
+		return byName
+	}
+}
+
+// suggest returns up to three names of the given kind that closely resemble the query
+func suggest(entries []Entry, kind Kind, query, bridgeID string) []string {
+	var names []string
+	for _, e := range entries {
+		if e.Kind != kind || (bridgeID != "" && e.BridgeID != bridgeID) {
+			continue
+		}
+		names = append(names, e.Name)
+		if e.Group != "" && e.Group != e.Name {
+			names = append(names, e.Group+"/"+e.Name)
+		}
+	}
+
+	return SuggestNames(query, names)
+}
+
+// SuggestNames returns up to three names that closely resemble the query
+func SuggestNames(query string, names []string) []string {
+	q := strings.ToLower(query)
+
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	seen := make(map[string]bool)
+	var candidates []scored
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		n := strings.ToLower(name)
+		distance := levenshtein(q, n)
+		if strings.Contains(n, q) || strings.Contains(q, n) {
+			distance = 0
+		}
+
+		// Allow roughly one typo per three characters
+		if distance <= len(q)/3+1 {
+			candidates = append(candidates, scored{name: name, distance: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+
+	return suggestions
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
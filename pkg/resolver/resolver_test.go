@@ -0,0 +1,103 @@
+package resolver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testEntries() []Entry {
+	return []Entry{
+		{Kind: KindRoom, ID: "room-1", Name: "Living Room", BridgeID: "a"},
+		{Kind: KindRoom, ID: "room-2", Name: "Bedroom", BridgeID: "a"},
+		{Kind: KindRoom, ID: "room-3", Name: "Bedroom", BridgeID: "b"},
+		{Kind: KindLight, ID: "light-1", Name: "Lamp", Group: "Living Room", GroupID: "room-1", BridgeID: "a"},
+		{Kind: KindLight, ID: "light-2", Name: "Lamp", Group: "Bedroom", GroupID: "room-2", BridgeID: "a"},
+		{Kind: KindGroupedLight, ID: "gl-1", Name: "Living Room", Group: "Living Room", GroupID: "room-1", BridgeID: "a"},
+	}
+}
+
+func ids(entries []Entry) []string {
+	var result []string
+	for _, e := range entries {
+		result = append(result, e.ID)
+	}
+	return result
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     Kind
+		query    string
+		bridgeID string
+		want     []string
+	}{
+		{"by id", KindLight, "light-2", "", []string{"light-2"}},
+		{"by name is case-insensitive", KindRoom, "living room", "", []string{"room-1"}},
+		{"ambiguous name", KindLight, "Lamp", "", []string{"light-1", "light-2"}},
+		{"path picks one", KindLight, "bedroom/lamp", "", []string{"light-2"}},
+		{"path with spaces", KindLight, "Living Room / Lamp", "", []string{"light-1"}},
+		{"bridge narrows names", KindRoom, "Bedroom", "b", []string{"room-3"}},
+		{"grouped light by owner id", KindGroupedLight, "room-1", "", []string{"gl-1"}},
+		{"other kinds are ignored", KindZone, "Bedroom", "", nil},
+		{"no match", KindLight, "Desk", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(match(testEntries(), tt.kind, tt.query, tt.bridgeID))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	got := suggest(testEntries(), KindRoom, "bedrom", "")
+	if !reflect.DeepEqual(got, []string{"Bedroom"}) {
+		t.Errorf("suggest = %v, want [Bedroom]", got)
+	}
+
+	got = suggest(testEntries(), KindLight, "Bedroom/Lmap", "")
+	if !reflect.DeepEqual(got, []string{"Bedroom/Lamp"}) {
+		t.Errorf("suggest path = %v, want [Bedroom/Lamp]", got)
+	}
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"Kitchen", "Kitchen Island", "Office", "Hallway", "Hall"}
+
+	got := SuggestNames("kitchn", names)
+	if len(got) == 0 || got[0] != "Kitchen" {
+		t.Errorf("SuggestNames(kitchn) = %v, want Kitchen first", got)
+	}
+
+	if got := SuggestNames("garage", names); len(got) != 0 {
+		t.Errorf("SuggestNames(garage) = %v, want none", got)
+	}
+
+	if got := SuggestNames("hall", names); len(got) > 3 {
+		t.Errorf("SuggestNames returned %d names, want at most 3", len(got))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"lamp", "lamp", 0},
+		{"lamp", "lmap", 2},
+		{"kitchen", "kitchn", 1},
+		{"", "desk", 4},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CommandKind identifies which bridge rate limit a command counts against
+type CommandKind int
+
+const (
+	// LightCommand is a command to a single light (~10 per second per bridge)
+	LightCommand CommandKind = iota
+
+	// GroupCommand is a grouped_light or scene command (~1 per second per bridge)
+	GroupCommand
+)
+
+// Config holds the limits of a scheduler. Zero values use the defaults.
+type Config struct {
+	// LightRate is the number of light commands per second
+	LightRate float64
+
+	// GroupRate is the number of group commands per second
+	GroupRate float64
+
+	// LightBurst is the number of light commands that may be sent back to back
+	LightBurst int
+
+	// GroupBurst is the number of group commands that may be sent back to back
+	GroupBurst int
+
+	// MaxInFlight bounds the number of commands sent to the bridge at once
+	MaxInFlight int
+}
+
+// DefaultConfig returns limits matching the Hue bridge guidance
+func DefaultConfig() Config {
+	return Config{
+		LightRate:   10,
+		GroupRate:   1,
+		LightBurst:  2,
+		GroupBurst:  1,
+		MaxInFlight: 4,
+	}
+}
+
+// Scheduler paces commands to a single bridge with token buckets and
+// bounds how many are in flight at once
+type Scheduler struct {
+	lights *tokenBucket
+	groups *tokenBucket
+	slots  chan struct{}
+}
+
+// New creates a scheduler for one bridge
+func New(cfg Config) *Scheduler {
+	def := DefaultConfig()
+	if cfg.LightRate <= 0 {
+		cfg.LightRate = def.LightRate
+	}
+	if cfg.GroupRate <= 0 {
+		cfg.GroupRate = def.GroupRate
+	}
+	if cfg.LightBurst <= 0 {
+		cfg.LightBurst = def.LightBurst
+	}
+	if cfg.GroupBurst <= 0 {
+		cfg.GroupBurst = def.GroupBurst
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = def.MaxInFlight
+	}
+
+	return &Scheduler{
+		lights: newTokenBucket(cfg.LightRate, cfg.LightBurst),
+		groups: newTokenBucket(cfg.GroupRate, cfg.GroupBurst),
+		slots:  make(chan struct{}, cfg.MaxInFlight),
+	}
+}
+
+// Do waits for a token of the given kind and then a free slot, then runs fn.
+// The token comes first so commands waiting on their rate limit do not hold
+// slots that commands of the other kind could use. It returns the context
+// error if ctx is done before fn could start.
+func (s *Scheduler) Do(ctx context.Context, kind CommandKind, fn func(ctx context.Context) error) error {
+	bucket := s.lights
+	if kind == GroupCommand {
+		bucket = s.groups
+	}
+	if err := bucket.wait(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.slots }()
+
+	return fn(ctx)
+}
+
+// LightRate returns the number of light commands per second the scheduler
+// allows
+func (s *Scheduler) LightRate() float64 {
+	return s.lights.rate
+}
+
+// GroupRate returns the number of group commands per second the scheduler
+// allows
+func (s *Scheduler) GroupRate() float64 {
+	return s.groups.rate
+}
+
+// tokenBucket is a simple token bucket rate limiter
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
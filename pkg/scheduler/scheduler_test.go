@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewDefaults(t *testing.T) {
+	s := New(Config{})
+
+	if s.LightRate() != 10 || s.GroupRate() != 1 {
+		t.Errorf("rates = %v/%v, want 10/1", s.LightRate(), s.GroupRate())
+	}
+	if s.lights.burst != 2 || s.groups.burst != 1 {
+		t.Errorf("bursts = %v/%v, want 2/1", s.lights.burst, s.groups.burst)
+	}
+	if cap(s.slots) != 4 {
+		t.Errorf("slots = %d, want 4", cap(s.slots))
+	}
+}
+
+func TestGroupCommandsArePaced(t *testing.T) {
+	s := New(Config{GroupRate: 20})
+
+	var times []time.Time
+	for i := 0; i < 3; i++ {
+		err := s.Do(context.Background(), GroupCommand, func(ctx context.Context) error {
+			times = append(times, time.Now())
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+
+	// A burst of one means even the second command waits a full interval
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("group command %d sent %v after the previous one, want at least 50ms", i, gap)
+		}
+	}
+}
+
+func TestWaitingGroupCommandsDoNotHoldSlots(t *testing.T) {
+	s := New(Config{GroupRate: 0.5, MaxInFlight: 1})
+
+	// Use up the group token so the next group command has to wait
+	if err := s.Do(context.Background(), GroupCommand, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = s.Do(ctx, GroupCommand, func(ctx context.Context) error { return nil })
+	}()
+
+	// Let the group command start waiting before the light command arrives
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Do(context.Background(), LightCommand, func(ctx context.Context) error { return nil })
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("light command: %v", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("light command was blocked by a group command waiting for its rate limit")
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestMaxInFlight(t *testing.T) {
+	s := New(Config{LightRate: 1000, LightBurst: 100, MaxInFlight: 2})
+
+	var mu sync.Mutex
+	var running, peak int
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = s.Do(context.Background(), LightCommand, func(ctx context.Context) error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("%d commands ran at once, want at most 2", peak)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	s := New(Config{GroupRate: 0.1})
+	if err := s.Do(context.Background(), GroupCommand, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	ran := false
+	err := s.Do(ctx, GroupCommand, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do = %v, want context.DeadlineExceeded", err)
+	}
+	if ran {
+		t.Error("command ran after its context was done")
+	}
+}
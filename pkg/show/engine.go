@@ -0,0 +1,217 @@
+package show
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cleanupTimeout bounds the cleanup of a show that ended on its own
+const cleanupTimeout = 30 * time.Second
+
+// playing is a show registered with the engine
+type playing struct {
+	player  *Player
+	cleanup func(context.Context) error
+	once    sync.Once
+	err     error
+}
+
+// Engine keeps track of playing shows so they can be paused, sought,
+// listed and stopped, keeps two shows off the same target, and cleans up
+// every show when it ends or the server shuts down
+type Engine struct {
+	mu     sync.Mutex
+	shows  map[string]*playing
+	lastID int64
+}
+
+// NewEngine creates an engine with no shows
+func NewEngine() *Engine {
+	return &Engine{shows: make(map[string]*playing)}
+}
+
+// Play starts a show. cleanup runs exactly once when the show ends,
+// whether it is stopped or plays to the end.
+func (e *Engine) Play(cfg Config, cleanup func(context.Context) error) (Status, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, targets := range cfg.Targets {
+		for _, target := range targets {
+			if id := e.playing(target); id != "" {
+				return Status{}, fmt.Errorf("%s is already playing in show %s; stop it first", targetLabel(target), id)
+			}
+		}
+	}
+
+	id := e.nextID(time.Now())
+	p, err := play(id, cfg)
+	if err != nil {
+		return Status{}, err
+	}
+
+	pl := &playing{player: p, cleanup: cleanup}
+	e.shows[id] = pl
+
+	// Clean up shows that end on their own
+	go func() {
+		<-p.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		e.finish(ctx, id, pl)
+	}()
+
+	return p.Status(), nil
+}
+
+// playing returns the ID of the show playing on a target, or "". The
+// caller must hold the lock.
+func (e *Engine) playing(target Target) string {
+	for id, pl := range e.shows {
+		for _, targets := range pl.player.cfg.Targets {
+			for _, t := range targets {
+				if t.BridgeID == target.BridgeID && t.ID == target.ID {
+					return id
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// get returns a playing show
+func (e *Engine) get(id string) (*playing, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pl, ok := e.shows[id]
+	if !ok {
+		return nil, fmt.Errorf("no show %q is playing", id)
+	}
+	return pl, nil
+}
+
+// Show returns the show being played under an ID
+func (e *Engine) Show(id string) (*Show, error) {
+	pl, err := e.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return pl.player.cfg.Show, nil
+}
+
+// Pause holds a show at its current position
+func (e *Engine) Pause(id string) (Status, error) {
+	pl, err := e.get(id)
+	if err != nil {
+		return Status{}, err
+	}
+	pl.player.Pause()
+	return pl.player.Status(), nil
+}
+
+// Resume continues a paused show
+func (e *Engine) Resume(id string) (Status, error) {
+	pl, err := e.get(id)
+	if err != nil {
+		return Status{}, err
+	}
+	pl.player.Resume()
+	return pl.player.Status(), nil
+}
+
+// Seek moves a show to a position in seconds
+func (e *Engine) Seek(id string, position float64) (Status, error) {
+	pl, err := e.get(id)
+	if err != nil {
+		return Status{}, err
+	}
+	if err := pl.player.Seek(position); err != nil {
+		return Status{}, err
+	}
+	return pl.player.Status(), nil
+}
+
+// List returns the playing shows, oldest first
+func (e *Engine) List() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := make([]Status, 0, len(e.shows))
+	for _, pl := range e.shows {
+		list = append(list, pl.player.Status())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.Before(list[j].Started)
+	})
+
+	return list
+}
+
+// Stop stops a show and waits for its cleanup
+func (e *Engine) Stop(ctx context.Context, id string) (Status, error) {
+	pl, err := e.get(id)
+	if err != nil {
+		return Status{}, err
+	}
+
+	pl.player.Stop()
+	err = e.finish(ctx, id, pl)
+	return pl.player.Status(), err
+}
+
+// StopAll stops every show and waits for their cleanup
+func (e *Engine) StopAll(ctx context.Context) ([]Status, error) {
+	e.mu.Lock()
+	shows := make(map[string]*playing, len(e.shows))
+	for id, pl := range e.shows {
+		shows[id] = pl
+	}
+	e.mu.Unlock()
+
+	var stopped []Status
+	var errs []error
+	for id, pl := range shows {
+		pl.player.Stop()
+		if err := e.finish(ctx, id, pl); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+		}
+		stopped = append(stopped, pl.player.Status())
+	}
+
+	if len(errs) > 0 {
+		return stopped, fmt.Errorf("cleaning up shows: %v", errs)
+	}
+	return stopped, nil
+}
+
+// finish runs a show's cleanup once and forgets the show
+func (e *Engine) finish(ctx context.Context, id string, pl *playing) error {
+	pl.once.Do(func() {
+		if pl.cleanup != nil {
+			pl.err = pl.cleanup(ctx)
+		}
+
+		e.mu.Lock()
+		if e.shows[id] == pl {
+			delete(e.shows, id)
+		}
+		e.mu.Unlock()
+	})
+	return pl.err
+}
+
+// nextID returns a unique, time-ordered show ID. The caller must hold the
+// lock.
+func (e *Engine) nextID(t time.Time) string {
+	id := t.UnixMilli()
+	if id <= e.lastID {
+		id = e.lastID + 1
+	}
+	e.lastID = id
+	return "show-" + strconv.FormatInt(id, 36)
+}
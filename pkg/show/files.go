@@ -0,0 +1,135 @@
+package show
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+)
+
+// Saved is a show file in the shows directory
+type Saved struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Format   string  `json:"format"`
+	Length   float64 `json:"length_seconds,omitempty"`
+	Tracks   int     `json:"tracks,omitempty"`
+	Problems string  `json:"problems,omitempty"`
+}
+
+// extensions maps file extensions to formats
+var extensions = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+// Save writes a valid show to dir, named after the show
+func Save(dir string, s *Show, format string, overwrite bool) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+
+	data, err := Marshal(s, format)
+	if err != nil {
+		return "", err
+	}
+
+	if existing, err := find(dir, s.Name); err == nil && !overwrite {
+		return "", fmt.Errorf("a show named %q already exists at %s; pass overwrite to replace it", s.Name, existing)
+	}
+
+	path := filepath.Join(dir, s.Name+"."+format)
+	if err := config.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("writing show: %w", err)
+	}
+
+	// Drop a copy saved earlier in the other format
+	for ext, f := range extensions {
+		if other := filepath.Join(dir, s.Name+ext); f != format && other != path {
+			_ = os.Remove(other)
+		}
+	}
+
+	return path, nil
+}
+
+// Load reads a saved show by name
+func Load(dir, name string) (*Show, error) {
+	path, err := find(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading show: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// List returns the saved shows, by name
+func List(dir string) ([]Saved, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Saved{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading shows directory: %w", err)
+	}
+
+	saved := []Saved{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		format, ok := extensions[ext]
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		item := Saved{
+			Name:   strings.TrimSuffix(entry.Name(), ext),
+			Path:   filepath.Join(dir, entry.Name()),
+			Format: format,
+		}
+		data, err := os.ReadFile(item.Path)
+		if err == nil {
+			var s *Show
+			if s, err = Parse(data); err == nil {
+				if err = s.Validate(); err == nil {
+					item.Length = s.Length()
+					item.Tracks = len(s.Tracks)
+				}
+			}
+		}
+		if err != nil {
+			item.Problems = err.Error()
+		}
+
+		saved = append(saved, item)
+	}
+	sort.Slice(saved, func(i, j int) bool {
+		return saved[i].Name < saved[j].Name
+	})
+
+	return saved, nil
+}
+
+// find returns the path of a saved show
+func find(dir, name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid show name %q", name)
+	}
+
+	for ext := range extensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no saved show named %q in %s", name, dir)
+}
@@ -0,0 +1,421 @@
+package show
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/animation"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Target is a light, or a room or zone's grouped light, a track plays on
+type Target struct {
+	BridgeID string `json:"bridge_id"`
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Group    bool   `json:"group,omitempty"`
+}
+
+// SendFunc sends one update to a target, going through the bridge's
+// scheduler
+type SendFunc func(ctx context.Context, target Target, update resources.LightUpdate) error
+
+// Rates are a bridge's command rates
+type Rates struct {
+	Light float64
+	Group float64
+}
+
+// Config describes a show to play
+type Config struct {
+	Show *Show
+
+	// Targets are the resolved targets of each track, in track order
+	Targets [][]Target
+
+	// Rates are the command rates of the bridges, by bridge ID
+	Rates map[string]Rates
+
+	// Send delivers each update
+	Send SendFunc
+}
+
+// Status is a snapshot of a show's playback
+type Status struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Position   float64   `json:"position_seconds"`
+	Length     float64   `json:"length_seconds"`
+	Play       int       `json:"play"`
+	Loops      int       `json:"loops,omitempty"`
+	Loop       bool      `json:"loop,omitempty"`
+	IntervalMS int64     `json:"step_interval_ms"`
+	Targets    []Target  `json:"targets"`
+	Started    time.Time `json:"started"`
+	Updates    int       `json:"updates"`
+	Errors     int       `json:"errors,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	Paused     bool      `json:"paused"`
+	Running    bool      `json:"running"`
+}
+
+// Player plays a show, sampling every track once per interval
+type Player struct {
+	id       string
+	cfg      Config
+	tracks   []track
+	interval time.Duration
+	length   float64
+	total    float64
+	started  time.Time
+	cancel   context.CancelFunc
+	done     chan struct{}
+	kick     chan struct{}
+
+	mu        sync.Mutex
+	basePos   float64
+	baseTime  time.Time
+	paused    bool
+	resend    bool
+	updates   int
+	errors    int
+	lastError error
+}
+
+// Plan checks a show against its targets and returns the step interval it
+// would play at and the updates of its first step, without sending anything
+func Plan(cfg Config) (time.Duration, [][]resources.LightUpdate, error) {
+	tracks, interval, err := prepare(cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	updates := make([][]resources.LightUpdate, len(tracks))
+	for i, t := range tracks {
+		update := t.at(0).Update(0)
+		for range cfg.Targets[i] {
+			updates[i] = append(updates[i], update)
+		}
+	}
+
+	return interval, updates, nil
+}
+
+// prepare validates a config, compiles its tracks and works out the step
+// interval
+func prepare(cfg Config) ([]track, time.Duration, error) {
+	if cfg.Show == nil {
+		return nil, 0, errors.New("a show is required")
+	}
+	if err := cfg.Show.Validate(); err != nil {
+		return nil, 0, err
+	}
+	if len(cfg.Targets) != len(cfg.Show.Tracks) {
+		return nil, 0, fmt.Errorf("show has %d tracks but %d target lists", len(cfg.Show.Tracks), len(cfg.Targets))
+	}
+	if cfg.Send == nil {
+		return nil, 0, errors.New("a send function is required")
+	}
+
+	tracks := make([]track, len(cfg.Show.Tracks))
+	for i, t := range cfg.Show.Tracks {
+		if len(cfg.Targets[i]) == 0 {
+			return nil, 0, fmt.Errorf("track %d has no lights", i)
+		}
+		tracks[i] = compile(t)
+	}
+
+	return tracks, stepInterval(cfg), nil
+}
+
+// stepInterval is the shortest interval at which every target can be
+// updated once within each bridge's share of its rate limits
+func stepInterval(cfg Config) time.Duration {
+	lights := make(map[string]int)
+	groups := make(map[string]int)
+	for _, targets := range cfg.Targets {
+		for _, target := range targets {
+			if target.Group {
+				groups[target.BridgeID]++
+			} else {
+				lights[target.BridgeID]++
+			}
+		}
+	}
+
+	var interval time.Duration
+	pace := func(n int, rate, fallback float64) {
+		if rate <= 0 {
+			rate = fallback
+		}
+		interval = max(interval, time.Duration(float64(n)/(rate*animation.Share)*float64(time.Second)))
+	}
+	for bridgeID, n := range lights {
+		pace(n, cfg.Rates[bridgeID].Light, 10)
+	}
+	for bridgeID, n := range groups {
+		pace(n, cfg.Rates[bridgeID].Group, 1)
+	}
+
+	// The bridge counts transitions in 100ms; round up so the rate holds
+	step := 100 * time.Millisecond
+	return max(step, (interval + step - 1).Truncate(step))
+}
+
+// play starts playing a show
+func play(id string, cfg Config) (*Player, error) {
+	tracks, interval, err := prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	length := cfg.Show.Length()
+	total := length * float64(max(1, cfg.Show.Loops))
+	if cfg.Show.Loop {
+		total = math.Inf(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	p := &Player{
+		id:       id,
+		cfg:      cfg,
+		tracks:   tracks,
+		interval: interval,
+		length:   length,
+		total:    total,
+		started:  now,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		kick:     make(chan struct{}, 1),
+		baseTime: now,
+	}
+	go p.run(ctx)
+
+	return p, nil
+}
+
+// Stop stops the show and waits for it to finish sending. It is safe to
+// call more than once.
+func (p *Player) Stop() {
+	p.cancel()
+	<-p.done
+}
+
+// Done is closed once the show has stopped
+func (p *Player) Done() <-chan struct{} {
+	return p.done
+}
+
+// Pause holds the show at its current position. The lights keep their
+// current state.
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.paused {
+		p.basePos = p.position()
+		p.paused = true
+	}
+}
+
+// Resume continues a paused show from where it was paused
+func (p *Player) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused {
+		p.baseTime = time.Now()
+		p.paused = false
+	}
+}
+
+// Seek moves the show to a position in seconds and sends every target its
+// state there, whether or not the show is paused
+func (p *Player) Seek(position float64) error {
+	if position < 0 || position > p.total || math.IsNaN(position) {
+		return fmt.Errorf("position must be between 0 and %.1f seconds", p.total)
+	}
+
+	p.mu.Lock()
+	p.basePos = position
+	p.baseTime = time.Now()
+	p.resend = true
+	p.mu.Unlock()
+
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status returns the show's progress
+func (p *Player) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var targets []Target
+	for _, t := range p.cfg.Targets {
+		targets = append(targets, t...)
+	}
+
+	position := p.position()
+	status := Status{
+		ID:         p.id,
+		Name:       p.cfg.Show.Name,
+		Position:   math.Round(position*10) / 10,
+		Length:     p.length,
+		Play:       1,
+		Loops:      p.cfg.Show.Loops,
+		Loop:       p.cfg.Show.Loop,
+		IntervalMS: p.interval.Milliseconds(),
+		Targets:    targets,
+		Started:    p.started,
+		Updates:    p.updates,
+		Errors:     p.errors,
+		Paused:     p.paused,
+	}
+	if p.length > 0 {
+		status.Play = min(int(position/p.length)+1, max(1, p.cfg.Show.Loops))
+		if p.cfg.Show.Loop {
+			status.Play = int(position/p.length) + 1
+		}
+	}
+	if p.lastError != nil {
+		status.LastError = p.lastError.Error()
+	}
+	select {
+	case <-p.done:
+	default:
+		status.Running = true
+	}
+
+	return status
+}
+
+// position is the show position in seconds. The caller must hold the lock.
+func (p *Player) position() float64 {
+	pos := p.basePos
+	if !p.paused {
+		pos += time.Since(p.baseTime).Seconds()
+	}
+	return math.Min(pos, p.total)
+}
+
+// showTime maps a position, which counts up across loops, to a time
+// within one play of the show
+func (p *Player) showTime(pos float64) float64 {
+	if p.length == 0 {
+		return 0
+	}
+	if pos >= p.total {
+		return p.length
+	}
+	return math.Mod(pos, p.length)
+}
+
+// run sends a step every interval, aiming each step at where the show will
+// be when the lights finish their transition, until the show ends or is
+// stopped
+func (p *Player) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	last := make([][]*State, len(p.tracks))
+	for i := range last {
+		last[i] = make([]*State, len(p.cfg.Targets[i]))
+	}
+
+	for {
+		p.mu.Lock()
+		pos, paused, resend := p.position(), p.paused, p.resend
+		p.resend = false
+		p.mu.Unlock()
+
+		if resend {
+			for i := range last {
+				clear(last[i])
+			}
+		}
+
+		switch {
+		case resend && paused:
+			p.step(ctx, p.showTime(pos), 0, last)
+		case !paused:
+			target := math.Min(pos+p.interval.Seconds(), p.total)
+			p.step(ctx, p.showTime(target), time.Duration((target-pos)*float64(time.Second)), last)
+			if pos >= p.total {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.kick:
+		}
+	}
+}
+
+// step sends the state of every target whose state changed, one sender per
+// bridge so a slow bridge does not hold up the others
+func (p *Player) step(ctx context.Context, t float64, transition time.Duration, last [][]*State) {
+	type send struct {
+		track, target int
+		state         State
+	}
+
+	byBridge := make(map[string][]send)
+	for i, tr := range p.tracks {
+		state := tr.at(t)
+		for j, target := range p.cfg.Targets[i] {
+			if last[i][j] != nil && *last[i][j] == state {
+				continue
+			}
+			byBridge[target.BridgeID] = append(byBridge[target.BridgeID], send{i, j, state})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, sends := range byBridge {
+		wg.Add(1)
+		go func(sends []send) {
+			defer wg.Done()
+			for _, s := range sends {
+				target := p.cfg.Targets[s.track][s.target]
+				err := p.cfg.Send(ctx, target, s.state.Update(transition))
+				if ctx.Err() != nil {
+					return
+				}
+
+				p.mu.Lock()
+				if err != nil {
+					p.errors++
+					p.lastError = fmt.Errorf("%s: %w", targetLabel(target), err)
+				} else {
+					p.updates++
+					state := s.state
+					last[s.track][s.target] = &state
+				}
+				p.mu.Unlock()
+			}
+		}(sends)
+	}
+	wg.Wait()
+}
+
+// targetLabel names a target for messages
+func targetLabel(target Target) string {
+	if target.Name != "" {
+		return fmt.Sprintf("%q", target.Name)
+	}
+	return target.ID
+}
@@ -0,0 +1,185 @@
+package show
+
+import (
+	"math"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// State is what a track shows at one moment. It is comparable so unchanged
+// states need not be sent again.
+type State struct {
+	HasOn bool `json:"-"`
+	On    bool `json:"on"`
+
+	HasBrightness bool    `json:"-"`
+	Brightness    float64 `json:"brightness"`
+
+	HasXY bool    `json:"-"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+
+	HasMirek bool `json:"-"`
+	Mirek    int  `json:"mirek"`
+}
+
+// Update converts the state to a light update reached over transition
+func (s State) Update(transition time.Duration) resources.LightUpdate {
+	ms := int(transition.Milliseconds())
+	update := resources.LightUpdate{Dynamics: &resources.Dynamics{Duration: &ms}}
+
+	if s.HasOn {
+		update.On = &resources.OnState{On: s.On}
+	}
+	if s.HasBrightness {
+		update.Dimming = &resources.Dimming{Brightness: s.Brightness}
+	}
+	if s.HasXY {
+		update.Color = &resources.Color{XY: resources.ColorXY{X: s.X, Y: s.Y}}
+	}
+	if s.HasMirek {
+		update.ColorTemperature = &resources.ColorTemperature{Mirek: s.Mirek}
+	}
+
+	return update
+}
+
+// colorKind tells the color channel's XY points from color temperatures
+type colorKind int
+
+const (
+	kindXY colorKind = iota
+	kindMirek
+)
+
+// point is a keyframe value on one channel
+type point struct {
+	t      float64
+	v      [2]float64
+	kind   colorKind
+	easing string
+}
+
+// track is a compiled track: each property on its own channel so fields a
+// keyframe leaves out move between the keyframes that set them
+type track struct {
+	on         []point
+	brightness []point
+	color      []point
+	length     float64
+	loop       bool
+}
+
+// compile splits a track's keyframes into channels
+func compile(t Track) track {
+	c := track{loop: t.Loop}
+
+	for _, kf := range t.Keyframes {
+		easing := kf.Easing
+		if easing == "" {
+			easing = "linear"
+		}
+		c.length = math.Max(c.length, kf.Time)
+
+		if kf.On != nil {
+			v := 0.0
+			if *kf.On {
+				v = 1
+			}
+			// On and off switch at the keyframe; there is nothing between
+			c.on = append(c.on, point{t: kf.Time, v: [2]float64{v}, easing: "step"})
+		}
+		if kf.Brightness != nil {
+			c.brightness = append(c.brightness, point{t: kf.Time, v: [2]float64{*kf.Brightness}, easing: easing})
+		}
+
+		switch {
+		case kf.Color != "":
+			rgb, _ := color.Parse(kf.Color)
+			xy, _ := rgb.XY()
+			c.color = append(c.color, point{t: kf.Time, v: [2]float64{xy.X, xy.Y}, kind: kindXY, easing: easing})
+		case kf.ColorXY != nil:
+			c.color = append(c.color, point{t: kf.Time, v: [2]float64{kf.ColorXY.X, kf.ColorXY.Y}, kind: kindXY, easing: easing})
+		case kf.ColorTemp != nil:
+			c.color = append(c.color, point{t: kf.Time, v: [2]float64{float64(*kf.ColorTemp)}, kind: kindMirek, easing: easing})
+		}
+	}
+
+	return c
+}
+
+// at returns the track's state t seconds into the show
+func (c track) at(t float64) State {
+	if c.loop && c.length > 0 {
+		t = math.Mod(t, c.length)
+	}
+
+	var s State
+	if p, ok := sample(c.on, t); ok {
+		s.HasOn, s.On = true, p.v[0] >= 0.5
+	}
+	if p, ok := sample(c.brightness, t); ok {
+		s.HasBrightness, s.Brightness = true, math.Round(p.v[0]*10)/10
+	}
+	if p, ok := sample(c.color, t); ok {
+		if p.kind == kindMirek {
+			s.HasMirek, s.Mirek = true, int(math.Round(p.v[0]))
+		} else {
+			s.HasXY, s.X, s.Y = true, math.Round(p.v[0]*10000)/10000, math.Round(p.v[1]*10000)/10000
+		}
+	}
+
+	return s
+}
+
+// sample interpolates a channel at t. Before the first keyframe the channel
+// holds the first value, after the last it holds the last.
+func sample(points []point, t float64) (point, bool) {
+	if len(points) == 0 {
+		return point{}, false
+	}
+	if t <= points[0].t {
+		return points[0], true
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if t >= b.t {
+			continue
+		}
+
+		f := ease(b.easing, (t-a.t)/(b.t-a.t))
+		if a.kind != b.kind {
+			// XY and color temperature cannot be mixed; switch at the keyframe
+			return a, true
+		}
+		return point{
+			t:    t,
+			v:    [2]float64{a.v[0] + (b.v[0]-a.v[0])*f, a.v[1] + (b.v[1]-a.v[1])*f},
+			kind: a.kind,
+		}, true
+	}
+
+	return points[len(points)-1], true
+}
+
+// ease maps the linear progress f from 0 to 1 through an easing curve
+func ease(easing string, f float64) float64 {
+	switch easing {
+	case "ease_in":
+		return f * f
+	case "ease_out":
+		return 1 - (1-f)*(1-f)
+	case "ease_in_out":
+		if f < 0.5 {
+			return 2 * f * f
+		}
+		return 1 - math.Pow(-2*f+2, 2)/2
+	case "step":
+		return 0
+	default:
+		return f
+	}
+}
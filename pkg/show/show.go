@@ -0,0 +1,269 @@
+// Package show reads, validates and plays keyframe light shows written as
+// JSON or YAML timelines
+package show
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+	"gopkg.in/yaml.v3"
+)
+
+// Easings are the ways a value can move from one keyframe to the next
+var Easings = []string{"linear", "ease_in", "ease_out", "ease_in_out", "step"}
+
+// Formats are the file formats a show can be written in
+var Formats = []string{"json", "yaml"}
+
+// Mirek range of Hue color temperature lights
+const (
+	MinMirek = 153
+	MaxMirek = 500
+)
+
+// Show is a light show: tracks of keyframes played against one clock
+type Show struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Duration is the length of one play in seconds; it defaults to the
+	// time of the last keyframe
+	Duration float64 `json:"duration,omitempty" yaml:"duration,omitempty"`
+
+	// Loops is how many times the show plays (default once)
+	Loops int `json:"loops,omitempty" yaml:"loops,omitempty"`
+
+	// Loop repeats the show until it is stopped
+	Loop bool `json:"loop,omitempty" yaml:"loop,omitempty"`
+
+	Tracks []Track `json:"tracks" yaml:"tracks"`
+}
+
+// Track animates one target: a list of lights, or a room or zone as a group
+type Track struct {
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Lights   []string `json:"lights,omitempty" yaml:"lights,omitempty"`
+	Room     string   `json:"room,omitempty" yaml:"room,omitempty"`
+	Zone     string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	BridgeID string   `json:"bridge_id,omitempty" yaml:"bridge_id,omitempty"`
+
+	// Loop repeats the track's keyframes on their own for the whole show
+	Loop bool `json:"loop,omitempty" yaml:"loop,omitempty"`
+
+	Keyframes []Keyframe `json:"keyframes" yaml:"keyframes"`
+}
+
+// Keyframe is the state a track reaches at a time. Fields that are left out
+// keep moving between the keyframes that set them.
+type Keyframe struct {
+	// Time is seconds from the start of the show
+	Time float64 `json:"time" yaml:"time"`
+
+	On         *bool    `json:"on,omitempty" yaml:"on,omitempty"`
+	Brightness *float64 `json:"brightness,omitempty" yaml:"brightness,omitempty"`
+
+	// Color is a named or hex color; ColorXY and ColorTemp set the color
+	// directly
+	Color     string `json:"color,omitempty" yaml:"color,omitempty"`
+	ColorXY   *XY    `json:"color_xy,omitempty" yaml:"color_xy,omitempty"`
+	ColorTemp *int   `json:"color_temp,omitempty" yaml:"color_temp,omitempty"`
+
+	// Easing is how values move from the previous keyframe to this one
+	// (default linear)
+	Easing string `json:"easing,omitempty" yaml:"easing,omitempty"`
+}
+
+// XY is a CIE color point
+type XY struct {
+	X float64 `json:"x" yaml:"x"`
+	Y float64 `json:"y" yaml:"y"`
+}
+
+// ValidationError lists everything wrong with a show
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid show:\n- " + strings.Join(e.Problems, "\n- ")
+}
+
+// Parse reads a show from JSON or YAML, telling them apart by the first
+// character
+func Parse(data []byte) (*Show, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("show is empty")
+	}
+
+	var s Show
+	if data[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&s); err != nil {
+			return nil, fmt.Errorf("parsing show JSON: %w", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&s); err != nil {
+			return nil, fmt.Errorf("parsing show YAML: %w", err)
+		}
+	}
+
+	return &s, nil
+}
+
+// Marshal writes a show as JSON or YAML
+func Marshal(s *Show, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(s, "", "  ")
+	case "yaml":
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(s); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), encoder.Close()
+	default:
+		return nil, fmt.Errorf("unknown format %q (valid: %v)", format, Formats)
+	}
+}
+
+// validName is what a show name may contain so it can name a file
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _-]*$`)
+
+// Validate checks a show's structure and values. Targets are checked for
+// presence only; resolving them to lights is up to the caller.
+func (s *Show) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if s.Name == "" {
+		add("name is required")
+	} else if !validName.MatchString(s.Name) {
+		add("name %q may only contain letters, digits, spaces, '_' and '-'", s.Name)
+	}
+	if s.Duration < 0 {
+		add("duration must not be negative")
+	}
+	if s.Loops < 0 {
+		add("loops must not be negative")
+	}
+	if len(s.Tracks) == 0 {
+		add("tracks: a show needs at least one track")
+	}
+
+	for i, track := range s.Tracks {
+		path := fmt.Sprintf("tracks[%d]", i)
+
+		targets := 0
+		if len(track.Lights) > 0 {
+			targets++
+		}
+		if track.Room != "" {
+			targets++
+		}
+		if track.Zone != "" {
+			targets++
+		}
+		if targets != 1 {
+			add("%s: set exactly one of lights, room or zone", path)
+		}
+
+		if len(track.Keyframes) == 0 {
+			add("%s.keyframes: a track needs at least one keyframe", path)
+		}
+
+		for j, kf := range track.Keyframes {
+			kpath := fmt.Sprintf("%s.keyframes[%d]", path, j)
+
+			if kf.Time < 0 || math.IsNaN(kf.Time) {
+				add("%s.time must not be negative", kpath)
+			}
+			if j > 0 && kf.Time <= track.Keyframes[j-1].Time {
+				add("%s.time %.3g must be after the previous keyframe's %.3g", kpath, kf.Time, track.Keyframes[j-1].Time)
+			}
+			if s.Duration > 0 && kf.Time > s.Duration {
+				add("%s.time %.3g is after the show's duration %.3g", kpath, kf.Time, s.Duration)
+			}
+
+			if kf.On == nil && kf.Brightness == nil && kf.Color == "" && kf.ColorXY == nil && kf.ColorTemp == nil {
+				add("%s sets nothing; set on, brightness, color, color_xy or color_temp", kpath)
+			}
+			if kf.Brightness != nil && (*kf.Brightness < 0 || *kf.Brightness > 100) {
+				add("%s.brightness must be between 0 and 100", kpath)
+			}
+
+			colors := 0
+			if kf.Color != "" {
+				colors++
+				if _, err := color.Parse(kf.Color); err != nil {
+					add("%s.color: %v", kpath, err)
+				}
+			}
+			if kf.ColorXY != nil {
+				colors++
+				if kf.ColorXY.X < 0 || kf.ColorXY.X > 1 || kf.ColorXY.Y < 0 || kf.ColorXY.Y > 1 {
+					add("%s.color_xy must be between 0 and 1", kpath)
+				}
+			}
+			if kf.ColorTemp != nil {
+				colors++
+				if *kf.ColorTemp < MinMirek || *kf.ColorTemp > MaxMirek {
+					add("%s.color_temp must be between %d and %d mirek", kpath, MinMirek, MaxMirek)
+				}
+			}
+			if colors > 1 {
+				add("%s: set only one of color, color_xy or color_temp", kpath)
+			}
+
+			if kf.Easing != "" && !validEasing(kf.Easing) {
+				add("%s.easing %q is not one of %v", kpath, kf.Easing, Easings)
+			}
+		}
+	}
+
+	if (s.Loop || s.Loops > 1) && len(problems) == 0 && s.Length() == 0 {
+		add("a looping show must be longer than 0 seconds; set duration or later keyframes")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Length is the length of one play of the show in seconds
+func (s *Show) Length() float64 {
+	if s.Duration > 0 {
+		return s.Duration
+	}
+
+	var length float64
+	for _, track := range s.Tracks {
+		for _, kf := range track.Keyframes {
+			length = math.Max(length, kf.Time)
+		}
+	}
+	return length
+}
+
+// validEasing reports whether an easing is known
+func validEasing(name string) bool {
+	for _, easing := range Easings {
+		if easing == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,398 @@
+package show
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+const sunrise = `
+name: Sunrise
+description: Warm fade up with a lamp that breathes
+loops: 2
+tracks:
+  - lights: [lamp]
+    keyframes:
+      - {time: 0, on: true, brightness: 1, color: red}
+      - {time: 1, brightness: 100, color: amber, easing: ease_in_out}
+      - {time: 2, brightness: 50, color_temp: 300}
+  - room: Bedroom
+    loop: true
+    keyframes:
+      - {time: 0, brightness: 20}
+      - {time: 0.5, brightness: 60, easing: step}
+      - {time: 1, brightness: 20}
+`
+
+// recorder keeps the updates a show sends
+type recorder struct {
+	mu      sync.Mutex
+	updates []sent
+}
+
+// sent is one update the recorder received
+type sent struct {
+	target Target
+	update resources.LightUpdate
+}
+
+func (r *recorder) send(ctx context.Context, target Target, update resources.LightUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, sent{target: target, update: update})
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.updates)
+}
+
+func (r *recorder) last(id string) resources.LightUpdate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.updates) - 1; i >= 0; i-- {
+		if r.updates[i].target.ID == id {
+			return r.updates[i].update
+		}
+	}
+	return resources.LightUpdate{}
+}
+
+func parseSunrise(t *testing.T) *Show {
+	t.Helper()
+
+	s, err := Parse([]byte(sunrise))
+	if err != nil {
+		t.Fatalf("parsing sunrise: %v", err)
+	}
+	return s
+}
+
+// lampConfig plays the lamp track of sunrise on its own
+func lampConfig(t *testing.T, rec *recorder) Config {
+	t.Helper()
+
+	s := parseSunrise(t)
+	s.Tracks = s.Tracks[:1]
+	return Config{
+		Show:    s,
+		Targets: [][]Target{{{BridgeID: "test", ID: "lamp", Name: "Lamp"}}},
+		Rates:   map[string]Rates{"test": {Light: 10, Group: 1}},
+		Send:    rec.send,
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	s := parseSunrise(t)
+	if s.Length() != 2 {
+		t.Fatalf("sunrise is %.1fs long, want 2", s.Length())
+	}
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			data, err := Marshal(s, format)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			again, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if again.Name != s.Name || len(again.Tracks) != 2 || len(again.Tracks[0].Keyframes) != 3 || *again.Tracks[0].Keyframes[2].ColorTemp != 300 {
+				t.Errorf("exported %s does not match the show", format)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	for _, data := range []string{`{"name": "x", "trakcs": []}`, "name: x\ntrakcs: []\n"} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse accepted %q", data)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		show string
+		want []string
+	}{
+		{
+			name: "valid",
+			show: sunrise,
+		},
+		{
+			name: "no tracks",
+			show: `{"name": "empty", "tracks": []}`,
+			want: []string{"at least one track"},
+		},
+		{
+			name: "bad name and target",
+			show: `{"name": "bad/name", "tracks": [{"lights": ["a"], "room": "b", "keyframes": [{"time": 0, "on": true}]}]}`,
+			want: []string{"name", "exactly one of lights"},
+		},
+		{
+			name: "bad keyframes",
+			show: `{"name": "bad", "tracks": [{"lights": ["a"], "keyframes": [{"time": 2, "brightness": 150}, {"time": 1, "color": "chartreuse", "color_temp": 100, "easing": "bounce"}]}]}`,
+			want: []string{"brightness", "after the previous", "chartreuse", "color_temp", "only one of", "bounce"},
+		},
+		{
+			name: "empty keyframe",
+			show: `{"name": "bad", "tracks": [{"zone": "z", "keyframes": [{"time": 0}]}]}`,
+			want: []string{"sets nothing"},
+		},
+		{
+			name: "keyframe after duration",
+			show: `{"name": "bad", "duration": 1, "tracks": [{"lights": ["a"], "keyframes": [{"time": 2, "on": true}]}]}`,
+			want: []string{"after the show's duration"},
+		},
+		{
+			name: "looping without length",
+			show: `{"name": "bad", "loop": true, "tracks": [{"lights": ["a"], "keyframes": [{"time": 0, "on": true}]}]}`,
+			want: []string{"longer than 0 seconds"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.show))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			err = s.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Validate = %v, want a ValidationError", err)
+			}
+			if len(invalid.Problems) != len(tt.want) {
+				t.Errorf("Validate found %d problems, want %d: %v", len(invalid.Problems), len(tt.want), invalid.Problems)
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, problem := range invalid.Problems {
+					found = found || strings.Contains(problem, want)
+				}
+				if !found {
+					t.Errorf("no problem mentions %q: %v", want, invalid.Problems)
+				}
+			}
+		})
+	}
+}
+
+func TestEase(t *testing.T) {
+	tests := []struct {
+		easing string
+		f      float64
+		want   float64
+	}{
+		{easing: "linear", f: 0.25, want: 0.25},
+		{easing: "ease_in", f: 0.5, want: 0.25},
+		{easing: "ease_out", f: 0.5, want: 0.75},
+		{easing: "ease_in_out", f: 0.25, want: 0.125},
+		{easing: "ease_in_out", f: 0.75, want: 0.875},
+		{easing: "ease_in_out", f: 1, want: 1},
+		{easing: "step", f: 0.9, want: 0},
+		{easing: "", f: 0.4, want: 0.4},
+	}
+
+	for _, tt := range tests {
+		if got := ease(tt.easing, tt.f); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ease(%q, %g) = %g, want %g", tt.easing, tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	points := func(easing string) []point {
+		return []point{
+			{t: 0, v: [2]float64{0, 0}, easing: "linear"},
+			{t: 1, v: [2]float64{100, 10}, easing: easing},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		points []point
+		t      float64
+		want   [2]float64
+		none   bool
+	}{
+		{name: "no keyframes", none: true},
+		{name: "before the first", points: points("linear"), t: -1, want: [2]float64{0, 0}},
+		{name: "linear halfway", points: points("linear"), t: 0.5, want: [2]float64{50, 5}},
+		{name: "eased halfway", points: points("ease_in"), t: 0.5, want: [2]float64{25, 2.5}},
+		{name: "step holds", points: points("step"), t: 0.9, want: [2]float64{0, 0}},
+		{name: "after the last", points: points("linear"), t: 2, want: [2]float64{100, 10}},
+		{
+			name: "color kinds switch at the keyframe",
+			points: []point{
+				{t: 0, v: [2]float64{0.6, 0.3}, kind: kindXY},
+				{t: 1, v: [2]float64{300}, kind: kindMirek, easing: "linear"},
+			},
+			t:    0.5,
+			want: [2]float64{0.6, 0.3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := sample(tt.points, tt.t)
+			if ok == tt.none {
+				t.Fatalf("sample ok = %v, want %v", ok, !tt.none)
+			}
+			if math.Abs(p.v[0]-tt.want[0]) > 1e-9 || math.Abs(p.v[1]-tt.want[1]) > 1e-9 {
+				t.Errorf("sample = %v, want %v", p.v, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepInterval(t *testing.T) {
+	rec := &recorder{}
+	s := parseSunrise(t)
+	cfg := Config{
+		Show: s,
+		Targets: [][]Target{
+			{{BridgeID: "test", ID: "lamp", Name: "Lamp"}},
+			{{BridgeID: "test", ID: "bedroom", Name: "Bedroom", Group: true}},
+		},
+		Rates: map[string]Rates{"test": {Light: 10, Group: 1}},
+		Send:  rec.send,
+	}
+
+	interval, first, err := Plan(cfg)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if interval < time.Second {
+		t.Errorf("a group target must pace steps to the group rate, got %s", interval)
+	}
+	if !first[0][0].On.On || first[0][0].Dimming.Brightness != 1 || first[0][0].Color == nil {
+		t.Errorf("first step of the lamp is %+v", first[0][0])
+	}
+
+	if interval, _, _ := Plan(lampConfig(t, rec)); interval > 200*time.Millisecond {
+		t.Errorf("a single light steps every %s", interval)
+	}
+}
+
+func TestPlayerPauseAndSeek(t *testing.T) {
+	rec := &recorder{}
+	p, err := play("test", lampConfig(t, rec))
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	t.Cleanup(p.Stop)
+
+	time.Sleep(700 * time.Millisecond)
+	p.Pause()
+	paused := p.Status()
+	count := rec.count()
+	time.Sleep(500 * time.Millisecond)
+	if n := rec.count() - count; n != 0 {
+		t.Errorf("%d update(s) sent while paused", n)
+	}
+	p.Resume()
+	if again := p.Status(); again.Paused || again.Position < paused.Position || again.Position > paused.Position+0.1 {
+		t.Errorf("resumed at %.1fs after pausing at %.1fs", again.Position, paused.Position)
+	}
+
+	p.Pause()
+	if err := p.Seek(4); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	end := rec.last("lamp")
+	if end.ColorTemperature == nil || end.ColorTemperature.Mirek != 300 || end.Dimming == nil || end.Dimming.Brightness != 50 || *end.Dynamics.Duration != 0 {
+		t.Errorf("after seeking to the end the lamp got %+v", end)
+	}
+
+	// Positions count across plays, so 2s is the start of the second play
+	if err := p.Seek(2); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if start := rec.last("lamp"); start.Color == nil || start.Dimming == nil || start.Dimming.Brightness != 1 {
+		t.Errorf("after seeking to 2s the lamp got %+v", start)
+	}
+	if status := p.Status(); status.Play != 2 || !status.Paused {
+		t.Errorf("after seeking to 2s the show is on play %d, paused=%v", status.Play, status.Paused)
+	}
+
+	for _, position := range []float64{-1, 10, math.NaN()} {
+		if err := p.Seek(position); err == nil {
+			t.Errorf("seeking to %g succeeded", position)
+		}
+	}
+
+	p.Resume()
+	select {
+	case <-p.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the show did not end after two plays")
+	}
+	if end := rec.last("lamp"); end.ColorTemperature == nil || end.Dimming == nil || end.Dimming.Brightness != 50 {
+		t.Errorf("the show ended on %+v instead of its last keyframe", end)
+	}
+}
+
+func TestEnginePlay(t *testing.T) {
+	engine := NewEngine()
+	var cleanups atomic.Int32
+	cleanup := func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	}
+
+	rec := &recorder{}
+	cfg := lampConfig(t, rec)
+	cfg.Show.Loops, cfg.Show.Loop = 0, true
+
+	status, err := engine.Play(cfg, cleanup)
+	if err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if _, err := engine.Play(cfg, cleanup); err == nil {
+		t.Error("a second show started on the same lights")
+	}
+
+	// Looping shows run until they are stopped
+	time.Sleep(2500 * time.Millisecond)
+	if list := engine.List(); len(list) != 1 || list[0].Play != 2 {
+		t.Errorf("looping show is not on its second play: %+v", list)
+	}
+
+	stopped, err := engine.Stop(context.Background(), status.ID)
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if stopped.Running {
+		t.Error("show still running after Stop")
+	}
+	if n := cleanups.Load(); n != 1 {
+		t.Errorf("cleanup ran %d times, want once", n)
+	}
+	if len(engine.List()) != 0 {
+		t.Error("engine still lists the stopped show")
+	}
+}
@@ -0,0 +1,243 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// MaxAutomatic is the number of automatic snapshots kept. Named snapshots
+// are kept until they are deleted.
+const MaxAutomatic = 25
+
+// LightState is the captured state of one light
+type LightState struct {
+	BridgeID string                `json:"bridge_id"`
+	LightID  string                `json:"light_id"`
+	Name     string                `json:"name,omitempty"`
+	State    resources.LightUpdate `json:"state"`
+}
+
+// Snapshot is the state of a set of lights at one point in time
+type Snapshot struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name,omitempty"`
+	Scope     string       `json:"scope"`
+	Automatic bool         `json:"automatic"`
+	Tool      string       `json:"tool,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	Lights    []LightState `json:"lights"`
+}
+
+// Store keeps snapshots in memory and persists them to a JSON file
+type Store struct {
+	mu        sync.Mutex
+	path      string
+	snapshots []Snapshot
+	lastID    int64
+}
+
+// NewStore loads the snapshots saved at path. A missing file is an empty store.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshots: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.snapshots); err != nil {
+		return nil, fmt.Errorf("parsing snapshots: %w", err)
+	}
+
+	return s, nil
+}
+
+// Add stores a snapshot, assigning its ID and creation time, and drops the
+// oldest automatic snapshots beyond MaxAutomatic
+func (s *Store) Add(snap Snapshot) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap.CreatedAt = time.Now()
+	snap.ID = s.nextID(snap.CreatedAt)
+	s.snapshots = append(s.snapshots, snap)
+
+	automatic := 0
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if !s.snapshots[i].Automatic {
+			continue
+		}
+		automatic++
+		if automatic > MaxAutomatic {
+			s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+		}
+	}
+
+	return snap, s.save()
+}
+
+// List returns all snapshots, newest first
+func (s *Store) List() []Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Snapshot, len(s.snapshots))
+	copy(list, s.snapshots)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	return list
+}
+
+// Find returns the snapshot with the given ID, or the newest one with the
+// given name (case-insensitive)
+func (s *Store) Find(ref string) (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, snap := range s.snapshots {
+		if snap.ID == ref {
+			return snap, true
+		}
+	}
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].Name != "" && strings.EqualFold(s.snapshots[i].Name, ref) {
+			return s.snapshots[i], true
+		}
+	}
+
+	return Snapshot{}, false
+}
+
+// LatestAutomatic returns the newest automatic snapshot
+func (s *Store) LatestAutomatic() (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].Automatic {
+			return s.snapshots[i], true
+		}
+	}
+
+	return Snapshot{}, false
+}
+
+// Delete removes a snapshot by ID
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, snap := range s.snapshots {
+		if snap.ID == id {
+			s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+			return s.save()
+		}
+	}
+
+	return fmt.Errorf("snapshot %q not found", id)
+}
+
+// nextID returns a unique, time-ordered snapshot ID
+func (s *Store) nextID(t time.Time) string {
+	id := t.UnixMilli()
+	if id <= s.lastID {
+		id = s.lastID + 1
+	}
+	s.lastID = id
+	return "snap-" + strconv.FormatInt(id, 36)
+}
+
+// save writes all snapshots to disk
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling snapshots: %w", err)
+	}
+
+	if err := config.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("writing snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// Capture reads the current state of lights on a bridge from its cache.
+// If lightIDs is empty, every light on the bridge is captured.
+func Capture(ctx context.Context, br *bridge.Bridge, lightIDs []string) ([]LightState, error) {
+	lights, err := br.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing lights on bridge %s: %w", br.ID, err)
+	}
+
+	wanted := make(map[string]bool, len(lightIDs))
+	for _, id := range lightIDs {
+		wanted[id] = true
+	}
+
+	var states []LightState
+	for _, light := range lights {
+		if len(wanted) > 0 && !wanted[light.ID] {
+			continue
+		}
+		states = append(states, LightState{
+			BridgeID: br.ID,
+			LightID:  light.ID,
+			Name:     light.Metadata.Name,
+			State:    StateOf(light),
+		})
+	}
+
+	return states, nil
+}
+
+// StateOf returns the update that puts a light back into its current state.
+// Brightness and color are kept for lights that are off too, so they come
+// back as they were when they are next turned on.
+func StateOf(light resources.Light) resources.LightUpdate {
+	state := resources.LightUpdate{
+		On: &resources.OnState{On: light.On.On},
+	}
+
+	if light.Dimming != nil {
+		state.Dimming = &resources.Dimming{Brightness: light.Dimming.Brightness}
+	}
+
+	switch {
+	case light.Gradient != nil && len(light.Gradient.Points) > 0:
+		state.Gradient = &resources.Gradient{Points: light.Gradient.Points, Mode: light.Gradient.Mode}
+	case light.ColorTemperature != nil && light.ColorTemperature.MirekValid:
+		state.ColorTemperature = &resources.ColorTemperature{Mirek: light.ColorTemperature.Mirek}
+	case light.Color != nil:
+		state.Color = &resources.Color{XY: light.Color.XY}
+	}
+
+	if light.Effects != nil && light.Effects.Status != "" {
+		state.Effects = &resources.EffectsUpdate{Effect: light.Effects.Status}
+	}
+
+	return state
+}
+
+// Equal reports whether two captured states are the same
+func Equal(a, b resources.LightUpdate) bool {
+	dataA, _ := json.Marshal(a)
+	dataB, _ := json.Marshal(b)
+	return string(dataA) == string(dataB)
+}
@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// cleanupTimeout bounds the cleanup of a stream that ended on its own
+const cleanupTimeout = 30 * time.Second
+
+// Active is a running stream and what it is playing
+type Active struct {
+	BridgeID string `json:"bridge_id"`
+	Name     string `json:"name"`
+	Effect   string `json:"effect"`
+	Status
+}
+
+// stream is a session registered with the engine
+type stream struct {
+	info    Active
+	session *Session
+	cleanup func(context.Context) error
+	once    sync.Once
+	err     error
+}
+
+// Engine keeps track of running streams so they can be listed and stopped,
+// and so every stream is cleaned up when it ends or the server shuts down
+type Engine struct {
+	mu      sync.Mutex
+	streams map[string]*stream
+}
+
+// NewEngine creates an engine with no streams
+func NewEngine() *Engine {
+	return &Engine{streams: make(map[string]*stream)}
+}
+
+// Add registers a running session. cleanup runs exactly once when the
+// session ends, whether it is stopped, reaches its duration or fails, and
+// should hand the lights back and restore their state. Add fails if the
+// configuration is already being streamed; the caller still owns the session
+// then and must stop it.
+func (e *Engine) Add(info Active, session *Session, cleanup func(context.Context) error) error {
+	st := &stream{info: info, session: session, cleanup: cleanup}
+	key := streamKey(info.BridgeID, info.ConfigurationID)
+
+	e.mu.Lock()
+	if _, ok := e.streams[key]; ok {
+		e.mu.Unlock()
+		return fmt.Errorf("entertainment configuration %s is already streaming", info.ConfigurationID)
+	}
+	e.streams[key] = st
+	e.mu.Unlock()
+
+	// Clean up streams that end on their own
+	go func() {
+		<-session.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		e.finish(ctx, key, st)
+	}()
+
+	return nil
+}
+
+// Running reports whether a configuration is being streamed
+func (e *Engine) Running(bridgeID, configurationID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.streams[streamKey(bridgeID, configurationID)]
+	return ok
+}
+
+// List returns the running streams
+func (e *Engine) List() []Active {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := make([]Active, 0, len(e.streams))
+	for _, st := range e.streams {
+		info := st.info
+		info.Status = st.session.Status()
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.Before(list[j].Started)
+	})
+
+	return list
+}
+
+// Stop stops a stream and waits for its cleanup
+func (e *Engine) Stop(ctx context.Context, bridgeID, configurationID string) (Active, error) {
+	key := streamKey(bridgeID, configurationID)
+
+	e.mu.Lock()
+	st, ok := e.streams[key]
+	e.mu.Unlock()
+	if !ok {
+		return Active{}, fmt.Errorf("no stream is running on entertainment configuration %s", configurationID)
+	}
+
+	st.session.Stop()
+	err := e.finish(ctx, key, st)
+
+	info := st.info
+	info.Status = st.session.Status()
+	return info, err
+}
+
+// StopAll stops every stream and waits for their cleanup, for shutdown
+func (e *Engine) StopAll(ctx context.Context) error {
+	e.mu.Lock()
+	streams := make(map[string]*stream, len(e.streams))
+	for key, st := range e.streams {
+		streams[key] = st
+	}
+	e.mu.Unlock()
+
+	var errs []error
+	for key, st := range streams {
+		st.session.Stop()
+		if err := e.finish(ctx, key, st); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.info.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cleaning up streams: %v", errs)
+	}
+	return nil
+}
+
+// finish runs a stream's cleanup once and forgets the stream
+func (e *Engine) finish(ctx context.Context, key string, st *stream) error {
+	st.once.Do(func() {
+		if st.cleanup != nil {
+			st.err = st.cleanup(ctx)
+		}
+
+		e.mu.Lock()
+		if e.streams[key] == st {
+			delete(e.streams, key)
+		}
+		e.mu.Unlock()
+	})
+	return st.err
+}
+
+// streamKey identifies a configuration across bridges
+func streamKey(bridgeID, configurationID string) string {
+	return bridgeID + "/" + configurationID
+}
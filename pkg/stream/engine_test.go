@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEngineAddRejectsRunningConfiguration(t *testing.T) {
+	addr, _ := listen(t)
+	engine := NewEngine()
+	info := Active{BridgeID: "test", Status: Status{ConfigurationID: testConfigurationID}}
+
+	var cleanups atomic.Int32
+	cleanup := func(context.Context) error {
+		cleanups.Add(1)
+		return nil
+	}
+
+	first := dial(t, addr, []uint8{0}, 0)
+	if err := engine.Add(info, first, cleanup); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	second := dial(t, addr, []uint8{0}, 0)
+	if err := engine.Add(info, second, cleanup); err == nil {
+		t.Fatal("Add replaced a running stream")
+	}
+	second.Stop()
+
+	stopped, err := engine.Stop(context.Background(), "test", testConfigurationID)
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if stopped.Running {
+		t.Error("first stream still running after Stop")
+	}
+	if n := cleanups.Load(); n != 1 {
+		t.Errorf("cleanup ran %d times, want once for the first stream", n)
+	}
+	if engine.Running("test", testConfigurationID) {
+		t.Error("engine still lists the stopped stream")
+	}
+}
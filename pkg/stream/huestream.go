@@ -0,0 +1,112 @@
+// Package stream sends Hue entertainment frames to a bridge over DTLS
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Port is the UDP port the bridge listens on for entertainment streams
+const Port = 2100
+
+// MaxChannels is the most channels one HueStream frame can carry
+const MaxChannels = 20
+
+// protocolName starts every HueStream message
+var protocolName = []byte("HueStream")
+
+// headerSize is the HueStream v2 header: protocol name, version, sequence,
+// reserved, color space, reserved and the entertainment configuration ID
+const headerSize = 9 + 2 + 1 + 2 + 1 + 1 + 36
+
+// channelSize is a channel ID followed by three 16-bit color values
+const channelSize = 7
+
+// colorSpaceRGB selects 16-bit RGB channel values
+const colorSpaceRGB = 0x00
+
+// Channel is the color of one entertainment channel, each component from 0
+// to 1
+type Channel struct {
+	ID uint8   `json:"id"`
+	R  float64 `json:"r"`
+	G  float64 `json:"g"`
+	B  float64 `json:"b"`
+}
+
+// Frame is one HueStream v2 message
+type Frame struct {
+	ConfigurationID string
+	Sequence        uint8
+	Channels        []Channel
+}
+
+// MarshalBinary encodes the frame as a HueStream v2 RGB message
+func (f Frame) MarshalBinary() ([]byte, error) {
+	if len(f.ConfigurationID) != 36 {
+		return nil, fmt.Errorf("entertainment configuration ID must be 36 characters, got %q", f.ConfigurationID)
+	}
+	if len(f.Channels) > MaxChannels {
+		return nil, fmt.Errorf("a frame carries at most %d channels, got %d", MaxChannels, len(f.Channels))
+	}
+
+	buf := make([]byte, 0, headerSize+channelSize*len(f.Channels))
+	buf = append(buf, protocolName...)
+	buf = append(buf, 0x02, 0x00)           // version 2.0
+	buf = append(buf, f.Sequence)           // sequence ID, ignored by the bridge
+	buf = append(buf, 0x00, 0x00)           // reserved
+	buf = append(buf, colorSpaceRGB)        // color space
+	buf = append(buf, 0x00)                 // reserved
+	buf = append(buf, f.ConfigurationID...) // entertainment configuration ID
+
+	for _, ch := range f.Channels {
+		buf = append(buf, ch.ID)
+		buf = binary.BigEndian.AppendUint16(buf, component(ch.R))
+		buf = binary.BigEndian.AppendUint16(buf, component(ch.G))
+		buf = binary.BigEndian.AppendUint16(buf, component(ch.B))
+	}
+
+	return buf, nil
+}
+
+// ParseFrame decodes a HueStream v2 RGB message
+func ParseFrame(data []byte) (Frame, error) {
+	if len(data) < headerSize || !bytes.Equal(data[:9], protocolName) {
+		return Frame{}, fmt.Errorf("not a HueStream message")
+	}
+	if data[9] != 0x02 {
+		return Frame{}, fmt.Errorf("unsupported HueStream version %d.%d", data[9], data[10])
+	}
+	if data[14] != colorSpaceRGB {
+		return Frame{}, fmt.Errorf("unsupported color space %d", data[14])
+	}
+
+	body := data[headerSize:]
+	if len(body)%channelSize != 0 {
+		return Frame{}, fmt.Errorf("truncated channel data")
+	}
+
+	frame := Frame{
+		ConfigurationID: string(data[16:headerSize]),
+		Sequence:        data[11],
+		Channels:        make([]Channel, 0, len(body)/channelSize),
+	}
+	for i := 0; i < len(body); i += channelSize {
+		frame.Channels = append(frame.Channels, Channel{
+			ID: body[i],
+			R:  float64(binary.BigEndian.Uint16(body[i+1:])) / math.MaxUint16,
+			G:  float64(binary.BigEndian.Uint16(body[i+3:])) / math.MaxUint16,
+			B:  float64(binary.BigEndian.Uint16(body[i+5:])) / math.MaxUint16,
+		})
+	}
+
+	return frame, nil
+}
+
+// component scales a color component from 0-1 to 16 bits
+func component(v float64) uint16 {
+	v = math.Max(0, math.Min(1, v))
+	return uint16(math.Round(v * math.MaxUint16))
+}
@@ -0,0 +1,250 @@
+package stream
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pion/dtls/v3"
+)
+
+// Frame rates the bridge handles well; it renders at most about 25 Hz but
+// sending faster hides lost packets
+const (
+	MinRate     = 25
+	MaxRate     = 50
+	DefaultRate = 50
+)
+
+// handshakeTimeout bounds the DTLS handshake with the bridge
+const handshakeTimeout = 10 * time.Second
+
+// Config describes a stream to one entertainment configuration
+type Config struct {
+	// Addr is the bridge's host, optionally with a port (default 2100)
+	Addr string
+
+	// Identity is the PSK identity, the bridge's hue-application-id
+	Identity string
+
+	// ClientKey is the hex client key returned when the app key was created
+	ClientKey string
+
+	// ConfigurationID is the entertainment configuration being streamed
+	ConfigurationID string
+
+	// Channels are the channel IDs passed to the source each frame
+	Channels []uint8
+
+	// Rate is the frame rate in Hz, between MinRate and MaxRate
+	Rate int
+
+	// Duration stops the stream after this long; zero streams until stopped
+	Duration time.Duration
+
+	// Source produces the colors of each frame
+	Source Source
+}
+
+// Status is a snapshot of a session's progress
+type Status struct {
+	ConfigurationID string    `json:"configuration_id"`
+	Rate            int       `json:"rate"`
+	Started         time.Time `json:"started"`
+	Frames          int       `json:"frames"`
+	Running         bool      `json:"running"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Session is an open DTLS stream sending frames at a fixed rate
+type Session struct {
+	cfg     Config
+	conn    *dtls.Conn
+	started time.Time
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu     sync.Mutex
+	frames int
+	err    error
+}
+
+// Dial opens a DTLS-PSK session to the bridge and starts sending frames.
+// Streaming must already be started on the configuration through the API.
+func Dial(ctx context.Context, cfg Config) (*Session, error) {
+	if cfg.Rate == 0 {
+		cfg.Rate = DefaultRate
+	}
+	if cfg.Rate < MinRate || cfg.Rate > MaxRate {
+		return nil, fmt.Errorf("rate must be between %d and %d Hz", MinRate, MaxRate)
+	}
+	if cfg.Source == nil {
+		return nil, fmt.Errorf("a frame source is required")
+	}
+	if len(cfg.Channels) == 0 || len(cfg.Channels) > MaxChannels {
+		return nil, fmt.Errorf("a stream needs between 1 and %d channels", MaxChannels)
+	}
+
+	psk, err := hex.DecodeString(cfg.ClientKey)
+	if err != nil || len(psk) == 0 {
+		return nil, fmt.Errorf("client key must be a hex string")
+	}
+
+	addr := cfg.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(Port))
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", addr, err)
+	}
+
+	conn, err := dtls.Dial("udp", udpAddr, &dtls.Config{
+		PSK: func([]byte) ([]byte, error) {
+			return psk, nil
+		},
+		PSKIdentityHint: []byte(cfg.Identity),
+		CipherSuites:    []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_GCM_SHA256},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening DTLS connection: %w", err)
+	}
+
+	handshakeCtx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	if err := conn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("DTLS handshake with %s: %w", addr, err)
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	s := &Session{
+		cfg:     cfg,
+		conn:    conn,
+		started: time.Now(),
+		cancel:  stop,
+		done:    make(chan struct{}),
+	}
+	go s.run(runCtx)
+
+	return s, nil
+}
+
+// Stop stops sending frames and closes the connection. It is safe to call
+// more than once.
+func (s *Session) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// Done is closed once the session has stopped, whether it was stopped,
+// reached its duration or failed
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, if any
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Status returns the session's progress
+func (s *Session) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{
+		ConfigurationID: s.cfg.ConfigurationID,
+		Rate:            s.cfg.Rate,
+		Started:         s.started,
+		Frames:          s.frames,
+	}
+	select {
+	case <-s.done:
+	default:
+		status.Running = true
+	}
+	if s.err != nil {
+		status.Error = s.err.Error()
+	}
+
+	return status
+}
+
+// run sends a frame every tick until the session is stopped
+func (s *Session) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.conn.Close()
+
+	ticker := time.NewTicker(time.Second / time.Duration(s.cfg.Rate))
+	defer ticker.Stop()
+
+	var sequence uint8
+	for {
+		elapsed := time.Since(s.started)
+		if s.cfg.Duration > 0 && elapsed >= s.cfg.Duration {
+			return
+		}
+
+		frame := Frame{
+			ConfigurationID: s.cfg.ConfigurationID,
+			Sequence:        sequence,
+			Channels:        s.cfg.Source.Colors(elapsed, s.cfg.Channels),
+		}
+		data, err := frame.MarshalBinary()
+		if err == nil {
+			_, err = s.conn.Write(data)
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.err = fmt.Errorf("sending frame %d: %w", s.frames+1, err)
+			s.mu.Unlock()
+			return
+		}
+
+		s.mu.Lock()
+		s.frames++
+		s.mu.Unlock()
+		sequence++
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApplicationID asks the bridge for the hue-application-id of an app key,
+// the PSK identity for streaming. client should be the bridge's own HTTP
+// client so the bridge is trusted the same way as for the REST API.
+func ApplicationID(ctx context.Context, client *http.Client, host, appKey string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+"/auth/v1", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("hue-application-key", appKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting application ID: %w", err)
+	}
+	defer resp.Body.Close()
+
+	id := resp.Header.Get("hue-application-id")
+	if resp.StatusCode != http.StatusOK || id == "" {
+		return "", fmt.Errorf("bridge returned no application ID (status %d)", resp.StatusCode)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,164 @@
+package stream
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/dtls/v3"
+)
+
+const (
+	testIdentity        = "test-application-id"
+	testClientKey       = "0123456789abcdef0123456789abcdef"
+	testConfigurationID = "1a8d99cc-967b-44f2-9202-43f976c0fa6b"
+)
+
+// listen starts a DTLS-PSK listener standing in for a bridge's
+// entertainment endpoint and returns its address and the frames it decodes
+func listen(t *testing.T) (string, <-chan Frame) {
+	t.Helper()
+
+	psk, _ := hex.DecodeString(testClientKey)
+	listener, err := dtls.Listen("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, &dtls.Config{
+		PSK: func(hint []byte) ([]byte, error) {
+			if string(hint) != testIdentity {
+				return nil, fmt.Errorf("unknown PSK identity %q", hint)
+			}
+			return psk, nil
+		},
+		PSKIdentityHint: []byte("test-bridge"),
+		CipherSuites:    []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_GCM_SHA256},
+	})
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	frames := make(chan Frame, 1000)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1500)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					frame, err := ParseFrame(buf[:n])
+					if err != nil {
+						t.Errorf("decoding frame: %v", err)
+						continue
+					}
+					frames <- frame
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), frames
+}
+
+// dial opens a session to a listener with a fixed color per channel
+func dial(t *testing.T, addr string, channels []uint8, duration time.Duration) *Session {
+	t.Helper()
+
+	source := SourceFunc(func(elapsed time.Duration, channels []uint8) []Channel {
+		return fill(channels, func(i int) (float64, float64, float64) {
+			return 1, float64(i) / float64(len(channels)), 0
+		})
+	})
+
+	session, err := Dial(context.Background(), Config{
+		Addr:            addr,
+		Identity:        testIdentity,
+		ClientKey:       testClientKey,
+		ConfigurationID: testConfigurationID,
+		Channels:        channels,
+		Rate:            MaxRate,
+		Duration:        duration,
+		Source:          source,
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(session.Stop)
+
+	return session
+}
+
+func TestSessionSendsFrames(t *testing.T) {
+	addr, frames := listen(t)
+	channels := []uint8{0, 1, 2, 3}
+
+	session := dial(t, addr, channels, 200*time.Millisecond)
+	<-session.Done()
+	if err := session.Err(); err != nil {
+		t.Fatalf("session failed: %v", err)
+	}
+
+	var frame Frame
+	select {
+	case frame = <-frames:
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener received no frames")
+	}
+
+	if frame.ConfigurationID != testConfigurationID {
+		t.Errorf("ConfigurationID = %q, want %q", frame.ConfigurationID, testConfigurationID)
+	}
+	if len(frame.Channels) != len(channels) {
+		t.Fatalf("frame has %d channels, want %d", len(frame.Channels), len(channels))
+	}
+	for i, channel := range frame.Channels {
+		if channel.ID != channels[i] {
+			t.Errorf("channel %d has ID %d, want %d", i, channel.ID, channels[i])
+		}
+		wantG := float64(i) / float64(len(channels))
+		if math.Abs(channel.R-1) > 0.001 || math.Abs(channel.G-wantG) > 0.001 || channel.B != 0 {
+			t.Errorf("channel %d = (%.3f, %.3f, %.3f), want (1, %.3f, 0)", channel.ID, channel.R, channel.G, channel.B, wantG)
+		}
+	}
+
+	// Sequence numbers count up by one for every frame
+	received := 1
+	previous := frame.Sequence
+	for done := false; !done; {
+		select {
+		case frame = <-frames:
+			if frame.Sequence != previous+1 {
+				t.Errorf("sequence %d follows %d", frame.Sequence, previous)
+			}
+			previous = frame.Sequence
+			received++
+		case <-time.After(200 * time.Millisecond):
+			done = true
+		}
+	}
+	if sent := session.Status().Frames; received != sent {
+		t.Errorf("listener decoded %d frames, session sent %d", received, sent)
+	}
+}
+
+func TestDialRejectsTooManyChannels(t *testing.T) {
+	channels := make([]uint8, MaxChannels+1)
+	_, err := Dial(context.Background(), Config{
+		Addr:      "127.0.0.1",
+		Identity:  testIdentity,
+		ClientKey: testClientKey,
+		Channels:  channels,
+		Source:    SourceFunc(func(time.Duration, []uint8) []Channel { return nil }),
+	})
+	if err == nil {
+		t.Error("Dial accepted more than MaxChannels channels")
+	}
+}
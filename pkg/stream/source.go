@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Source produces the channel colors for each frame of a stream
+type Source interface {
+	// Colors returns the colors of the given channels at a point in the
+	// stream. Channels it leaves out keep their previous color.
+	Colors(elapsed time.Duration, channels []uint8) []Channel
+}
+
+// SourceFunc adapts a function to a Source
+type SourceFunc func(elapsed time.Duration, channels []uint8) []Channel
+
+// Colors calls f
+func (f SourceFunc) Colors(elapsed time.Duration, channels []uint8) []Channel {
+	return f(elapsed, channels)
+}
+
+// Effects are the built-in effect names accepted by Effect
+var Effects = []string{"solid", "rainbow", "pulse", "chase"}
+
+// EffectOptions configures a built-in effect
+type EffectOptions struct {
+	// R, G and B are the effect's base color, each from 0 to 1
+	R, G, B float64
+
+	// Period is how long one cycle of the effect takes
+	Period time.Duration
+}
+
+// Effect returns the built-in effect with the given name
+func Effect(name string, opts EffectOptions) (Source, error) {
+	if opts.Period <= 0 {
+		opts.Period = 4 * time.Second
+	}
+
+	switch name {
+	case "solid":
+		return SourceFunc(func(elapsed time.Duration, channels []uint8) []Channel {
+			return fill(channels, func(i int) (float64, float64, float64) {
+				return opts.R, opts.G, opts.B
+			})
+		}), nil
+
+	case "rainbow":
+		// The hue wheel turns once per period, spread across the channels
+		return SourceFunc(func(elapsed time.Duration, channels []uint8) []Channel {
+			turn := cycle(elapsed, opts.Period)
+			return fill(channels, func(i int) (float64, float64, float64) {
+				return hsv(math.Mod(turn+float64(i)/float64(len(channels)), 1), 1, 1)
+			})
+		}), nil
+
+	case "pulse":
+		// Brightness follows a sine between 10% and 100%
+		return SourceFunc(func(elapsed time.Duration, channels []uint8) []Channel {
+			level := 0.55 - 0.45*math.Cos(2*math.Pi*cycle(elapsed, opts.Period))
+			return fill(channels, func(i int) (float64, float64, float64) {
+				return opts.R * level, opts.G * level, opts.B * level
+			})
+		}), nil
+
+	case "chase":
+		// One channel at a time is lit, with a fading tail behind it
+		return SourceFunc(func(elapsed time.Duration, channels []uint8) []Channel {
+			head := cycle(elapsed, opts.Period) * float64(len(channels))
+			return fill(channels, func(i int) (float64, float64, float64) {
+				behind := math.Mod(head-float64(i)+float64(len(channels)), float64(len(channels)))
+				level := math.Max(0, 1-behind/2)
+				return opts.R * level, opts.G * level, opts.B * level
+			})
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unknown effect %q (valid: %v)", name, Effects)
+}
+
+// fill builds a channel list from a color function of the channel's index
+func fill(channels []uint8, color func(i int) (float64, float64, float64)) []Channel {
+	colors := make([]Channel, len(channels))
+	for i, id := range channels {
+		r, g, b := color(i)
+		colors[i] = Channel{ID: id, R: r, G: g, B: b}
+	}
+	return colors
+}
+
+// cycle returns how far into its current period the stream is, from 0 to 1
+func cycle(elapsed, period time.Duration) float64 {
+	return float64(elapsed%period) / float64(period)
+}
+
+// hsv converts a hue, saturation and value, each from 0 to 1, to RGB
+func hsv(h, s, v float64) (float64, float64, float64) {
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p := v * (1 - s)
+	q := v * (1 - f*s)
+	t := v * (1 - (1-f)*s)
+
+	switch int(i) % 6 {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+)
+
+// RegisterAliasTools registers alias management and name resolution tools
+func RegisterAliasTools(s *server.MCPServer, cfg *config.Config, res *resolver.Resolver) {
+	// resolve_resource tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "resolve_resource",
+			Description: "Resolve a name, \"Room/Name\" path, alias or ID to a specific resource. Every tool argument ending in _id accepts the same forms, so this is only needed to check what a name refers to.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Name, path (e.g., 'Office/Desk Lamp'), alias or ID",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"description": "Type of resource to resolve",
+						"enum":        []string{"light", "room", "zone", "scene", "grouped_light", "device", "smart_scene", "entertainment_configuration"},
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID to restrict the search to",
+					},
+				},
+				Required: []string{"query", "kind"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("query")
+			if err != nil {
+				return mcp.NewToolResultError("query is required"), nil
+			}
+
+			kind, err := request.RequireString("kind")
+			if err != nil {
+				return mcp.NewToolResultError("kind is required"), nil
+			}
+
+			entry, err := res.Resolve(ctx, resolver.Kind(kind), query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			data, err := json.MarshalIndent(entry, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal resource: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// list_aliases tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_aliases",
+			Description: "List all user-defined aliases",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			type aliasInfo struct {
+				Alias  string `json:"alias"`
+				Target string `json:"target"`
+			}
+
+			targets := cfg.AliasTargets()
+			aliases := make([]aliasInfo, 0, len(targets))
+			for name, target := range targets {
+				aliases = append(aliases, aliasInfo{Alias: name, Target: target})
+			}
+			sort.Slice(aliases, func(i, j int) bool { return aliases[i].Alias < aliases[j].Alias })
+
+			data, err := json.MarshalIndent(aliases, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal aliases: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// set_alias tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "set_alias",
+			Description: "Define an alias (e.g., 'reading light') for a resource. The alias can then be used in place of any ID.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"alias": map[string]interface{}{
+						"type":        "string",
+						"description": "The alias to define. Matched case-insensitively",
+					},
+					"target": map[string]interface{}{
+						"type":        "string",
+						"description": "What the alias refers to: an ID, name or \"Room/Name\" path",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"alias", "target"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			alias, err := request.RequireString("alias")
+			if err != nil {
+				return mcp.NewToolResultError("alias is required"), nil
+			}
+
+			target, err := request.RequireString("target")
+			if err != nil {
+				return mcp.NewToolResultError("target is required"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{Method: "config.set_alias", TargetID: alias, Payload: target}).result()
+			}
+
+			if err := cfg.SetAlias(alias, target); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to save alias: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Alias %q now refers to %q", alias, target)), nil
+		},
+	)
+
+	// remove_alias tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "remove_alias",
+			Description: "Remove a user-defined alias",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"alias": map[string]interface{}{
+						"type":        "string",
+						"description": "The alias to remove",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"alias"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			alias, err := request.RequireString("alias")
+			if err != nil {
+				return mcp.NewToolResultError("alias is required"), nil
+			}
+
+			if isDryRun(ctx) {
+				if _, ok := cfg.Alias(alias); !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to remove alias: alias %q not found", alias)), nil
+				}
+				return newDryRun(dryRunCommand{Method: "config.remove_alias", TargetID: alias}).result()
+			}
+
+			if err := cfg.RemoveAlias(alias); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to remove alias: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Alias %q removed", alias)), nil
+		},
+	)
+}
@@ -0,0 +1,407 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/animation"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Animation run limits
+const (
+	defaultAnimationDuration = 10 * time.Minute
+	maxAnimationDuration     = 4 * time.Hour
+)
+
+// RegisterAnimationTools registers the tools that play animations with
+// ordinary light commands
+func RegisterAnimationTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver, animations *animation.Engine, owners *lightOwners) {
+	// start_animation tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "start_animation",
+			Description: "Play an animation on a set of lights using normal light commands, paced to stay within each bridge's rate limit: color_loop fades through colors, breathe fades bright and dim, chase lights one light at a time in order, flash blinks on and off (never faster than twice a second), twinkle flickers random lights. Works on any lights, unlike streaming effects, but updates at most a few times a second. The lights are restored when the animation ends or is stopped.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"animation": map[string]interface{}{
+						"type":        "string",
+						"description": "The animation to play",
+						"enum":        animation.Patterns,
+					},
+					"lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to animate, in order (IDs, names, \"Room/Light\" paths, or aliases)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Animate all lights in a room (ID, name, or alias)",
+					},
+					"zone": map[string]interface{}{
+						"type":        "string",
+						"description": "Animate all lights in a zone (ID, name, or alias)",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"colors": map[string]interface{}{
+						"type":        "array",
+						"description": fmt.Sprintf("Colors to use, as hex codes (#ff8800) or names (%s)", strings.Join(colorNames(), ", ")),
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"palette": map[string]interface{}{
+						"type":        "string",
+						"description": "Named palette to use instead of colors",
+						"enum":        paletteNames(),
+					},
+					"period_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Length of one animation cycle in seconds. It is stretched if the bridge cannot update the lights that fast.",
+						"minimum":     0.5,
+					},
+					"brightness": map[string]interface{}{
+						"type":        "number",
+						"description": "Peak brightness percentage (default 100)",
+						"minimum":     1,
+						"maximum":     100,
+					},
+					"cycles": map[string]interface{}{
+						"type":        "integer",
+						"description": "Stop after this many cycles",
+						"minimum":     1,
+					},
+					"duration_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Stop after this long (default %d without cycles, at most %d)", int(defaultAnimationDuration.Seconds()), int(maxAnimationDuration.Seconds())),
+						"minimum":     1,
+						"maximum":     maxAnimationDuration.Seconds(),
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"animation"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if len(request.GetStringSlice("lights", nil)) == 0 && request.GetString("room", "") == "" && request.GetString("zone", "") == "" {
+				return mcp.NewToolResultError("lights, room or zone is required"), nil
+			}
+
+			scope, previous, err := animationTargets(ctx, bm, res, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			colors, err := colorsFromArgs(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			cycles := request.GetInt("cycles", 0)
+			duration := time.Duration(request.GetFloat("duration_seconds", 0) * float64(time.Second))
+			if duration == 0 {
+				duration = maxAnimationDuration
+				if cycles == 0 {
+					duration = defaultAnimationDuration
+				}
+			}
+			if duration < time.Second || duration > maxAnimationDuration {
+				return mcp.NewToolResultError(fmt.Sprintf("duration_seconds must be between 1 and %d", int(maxAnimationDuration.Seconds()))), nil
+			}
+
+			// Leave out lights that cannot take commands, and do not send
+			// colors to lights without color
+			var warnings []string
+			var lights []animation.Light
+			noColor := make(map[string]bool)
+			rates := make(map[string]float64)
+			for _, state := range previous {
+				br, err := bm.GetBridge(state.BridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				rates[br.ID] = br.Scheduler.LightRate()
+
+				light, err := br.CachedClient.Lights().Get(ctx, state.LightID)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to get light %s: %v", state.LightID, err)), nil
+				}
+				if busyLights(ctx, br)[light.ID] {
+					warnings = append(warnings, fmt.Sprintf("%q is installing a software update and was left out", light.Metadata.Name))
+					continue
+				}
+				if br.UnreachableDevices(ctx)[light.Owner.RID] {
+					warnings = append(warnings, fmt.Sprintf("%q is unreachable and will not animate", light.Metadata.Name))
+				}
+				if light.Color == nil {
+					noColor[animationKey(br.ID, light.ID)] = true
+				}
+
+				lights = append(lights, animation.Light{BridgeID: br.ID, ID: light.ID, Name: light.Metadata.Name})
+			}
+			if len(lights) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("%s has no lights that can be animated", scope)), nil
+			}
+
+			cfg := animation.Config{
+				Pattern: request.GetString("animation", ""),
+				Lights:  lights,
+				Options: animation.Options{
+					Colors:     colors,
+					Period:     time.Duration(request.GetFloat("period_seconds", 0) * float64(time.Second)),
+					Brightness: request.GetFloat("brightness", 0),
+				},
+				Rates:    rates,
+				Cycles:   cycles,
+				Duration: duration,
+				Send: func(ctx context.Context, light animation.Light, update resources.LightUpdate) error {
+					br, err := bm.GetBridge(light.BridgeID)
+					if err != nil {
+						return err
+					}
+					if noColor[animationKey(light.BridgeID, light.ID)] {
+						update.Color = nil
+					}
+					return br.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+						return br.CachedClient.Lights().Update(ctx, light.ID, update)
+					})
+				},
+			}
+
+			interval, updates, err := animation.Plan(cfg)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			report := newDryRun()
+			for i, light := range lights {
+				update := updates[i]
+				if noColor[animationKey(light.BridgeID, light.ID)] {
+					update.Color = nil
+				}
+				report.Commands = append(report.Commands, dryRunCommand{BridgeID: light.BridgeID, Method: "lights.update", TargetID: light.ID, Payload: update})
+				if br, err := bm.GetBridge(light.BridgeID); err == nil {
+					report.predictLight(ctx, br, light.ID, update)
+				}
+			}
+			report.Plan = map[string]interface{}{
+				"animation":        cfg.Pattern,
+				"lights":           len(lights),
+				"step_interval_ms": interval.Milliseconds(),
+				"cycles":           cycles,
+				"duration_seconds": duration.Seconds(),
+			}
+			report.Warnings = append(append(report.Warnings, warnings...), fmt.Sprintf("Commands are the first step; a step is sent every %s and the lights are restored when the animation ends", interval))
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			claim, err := owners.claim("an animation", previous)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			status, err := animations.Start(cfg, func(ctx context.Context) error {
+				defer claim.release()
+				restoreLights(ctx, bm, previous)
+				return nil
+			})
+			if err != nil {
+				claim.release()
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to start animation: %v", err)), nil
+			}
+			claim.rename("animation " + status.ID)
+
+			text := fmt.Sprintf("✅ Playing %s on %d light(s) from %s (id %s), one step every %s, until %s. Stop it with stop_animation; the lights are restored when it ends.",
+				cfg.Pattern, len(lights), scope, status.ID, interval, status.Ends.Format(time.Kitchen))
+			if cycles > 0 {
+				text = fmt.Sprintf("✅ Playing %s on %d light(s) from %s (id %s), one step every %s, for %d cycle(s). Stop it with stop_animation; the lights are restored when it ends.",
+					cfg.Pattern, len(lights), scope, status.ID, interval, cycles)
+			}
+			for _, warning := range warnings {
+				text += "\n⚠️ " + warning
+			}
+
+			return mcp.NewToolResultText(text), nil
+		},
+	)
+
+	// list_animations tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_animations",
+			Description: "List running animations with their lights, step interval and progress",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			data, err := json.MarshalIndent(animations.List(), "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal animations: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// stop_animation tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "stop_animation",
+			Description: "Stop a running animation, or all of them, and restore the lights to their state from before it started",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"animation_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The animation ID from start_animation or list_animations, or \"all\"",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"animation_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("animation_id")
+			if err != nil {
+				return mcp.NewToolResultError("animation_id is required"), nil
+			}
+
+			running := animations.List()
+			if id != "all" {
+				var found []animation.Status
+				for _, status := range running {
+					if status.ID == id {
+						found = append(found, status)
+					}
+				}
+				running = found
+			}
+			if len(running) == 0 {
+				if id == "all" {
+					return mcp.NewToolResultText("No animations are running"), nil
+				}
+				return mcp.NewToolResultError(fmt.Sprintf("No animation %q is running", id)), nil
+			}
+
+			if isDryRun(ctx) {
+				report := newDryRun()
+				report.Plan = running
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%d animation(s) would stop and their lights would be restored", len(running)))
+				return report.result()
+			}
+
+			if id == "all" {
+				stopped, err := animations.StopAll(ctx)
+				if err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("⚠️ Stopped %d animation(s), but restoring some lights failed: %v", len(stopped), err)), nil
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Stopped %d animation(s) and restored their lights", len(stopped))), nil
+			}
+
+			stopped, err := animations.Stop(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("⚠️ Animation %s stopped after %d step(s), but restoring the lights failed: %v", id, stopped.Steps, err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Animation %s stopped after %d step(s) and the lights were restored", id, stopped.Steps)), nil
+		},
+	)
+}
+
+// animationTargets captures the lights an animation plays on, in the order
+// they were given, or by name for a room or zone
+func animationTargets(ctx context.Context, bm *bridge.Manager, res *resolver.Resolver, request mcp.CallToolRequest) (string, []snapshot.LightState, error) {
+	scope, states, err := captureScope(ctx, bm, res, request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	queries := request.GetStringSlice("lights", nil)
+	if len(queries) == 0 {
+		sort.SliceStable(states, func(i, j int) bool {
+			return states[i].Name < states[j].Name
+		})
+		return scope, states, nil
+	}
+
+	order := make(map[string]int, len(queries))
+	for i, query := range queries {
+		light, err := res.Resolve(ctx, resolver.KindLight, query, request.GetString("bridge_id", ""))
+		if err != nil {
+			return "", nil, err
+		}
+		if _, ok := order[animationKey(light.BridgeID, light.ID)]; !ok {
+			order[animationKey(light.BridgeID, light.ID)] = i
+		}
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return order[animationKey(states[i].BridgeID, states[i].LightID)] < order[animationKey(states[j].BridgeID, states[j].LightID)]
+	})
+
+	return scope, states, nil
+}
+
+// colorsFromArgs reads the colors or palette argument
+func colorsFromArgs(request mcp.CallToolRequest) ([]color.RGB, error) {
+	if palette := request.GetString("palette", ""); palette != "" {
+		return color.Palette(palette)
+	}
+
+	var colors []color.RGB
+	for _, name := range request.GetStringSlice("colors", nil) {
+		c, err := color.Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+
+	return colors, nil
+}
+
+// colorNames returns the named colors, sorted
+func colorNames() []string {
+	names := make([]string, 0, len(color.Named))
+	for name := range color.Named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// paletteNames returns the named palettes, sorted
+func paletteNames() []string {
+	names := make([]string, 0, len(color.Palettes))
+	for name := range color.Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// animationKey identifies a light across bridges
+func animationKey(bridgeID, lightID string) string {
+	return bridgeID + "/" + lightID
+}
@@ -16,7 +16,7 @@ func RegisterBridgeTools(s *server.MCPServer, bm *bridge.Manager) {
 	s.AddTool(
 		mcp.Tool{
 			Name:        "list_bridges",
-			Description: "List all configured Hue bridges",
+			Description: "List all configured Hue bridges. The default bridge is used when creating new resources.",
 			InputSchema: mcp.ToolInputSchema{
 				Type:       "object",
 				Properties: map[string]interface{}{},
@@ -30,6 +30,12 @@ func RegisterBridgeTools(s *server.MCPServer, bm *bridge.Manager) {
 				Name      string `json:"name"`
 				IP        string `json:"ip"`
 				Connected bool   `json:"connected"`
+				Default   bool   `json:"default,omitempty"`
+			}
+
+			var defaultID string
+			if def, err := bm.GetDefaultBridge(); err == nil {
+				defaultID = def.ID
 			}
 
 			infos := make([]bridgeInfo, len(bridges))
@@ -39,6 +45,7 @@ func RegisterBridgeTools(s *server.MCPServer, bm *bridge.Manager) {
 					Name:      br.Name,
 					IP:        br.IP,
 					Connected: br.Connected,
+					Default:   br.ID == defaultID,
 				}
 			}
 
@@ -82,6 +82,11 @@ func RegisterCacheTools(s *server.MCPServer, bm *bridge.Manager) {
 				} else {
 					result.TotalWarmed = stats.TotalWarmed
 					result.Duration = stats.Duration.String()
+
+					// Pick up resources the index may have missed
+					if err := bm.RefreshIndex(ctx, br.ID); err != nil {
+						result.Error = err.Error()
+					}
 				}
 
 				results = append(results, result)
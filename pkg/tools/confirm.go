@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// defaultConfirmTools need confirmation when no tools are configured
+var defaultConfirmTools = []string{
+	"remove_bridge",
+	"delete_room",
+	"delete_zone",
+	"delete_scene",
+	"delete_smart_scene",
+	"delete_snapshot",
+	"delete_entertainment_configuration",
+	"install_software_update",
+}
+
+// confirmTokenArgument is the schema of the confirm_token argument of
+// mutating tools
+var confirmTokenArgument = map[string]interface{}{
+	"type":        "string",
+	"description": "Token returned by an earlier call that needed confirmation. Pass it with the same arguments once the user has confirmed",
+}
+
+// confirmTokenTTL is how long a confirm token stays valid
+const confirmTokenTTL = 5 * time.Minute
+
+// confirmation is a pending confirm token
+type confirmation struct {
+	call    string
+	expires time.Time
+}
+
+// confirmations holds the confirm tokens handed out to clients without
+// elicitation support
+type confirmations struct {
+	mu      sync.Mutex
+	pending map[string]confirmation
+}
+
+// issue returns a new token for a tool call
+func (c *confirmations) issue(call string) (string, time.Time, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("generating confirm token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	expires := time.Now().Add(confirmTokenTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for t, pending := range c.pending {
+		if time.Now().After(pending.expires) {
+			delete(c.pending, t)
+		}
+	}
+	c.pending[token] = confirmation{call: call, expires: expires}
+
+	return token, expires, nil
+}
+
+// redeem consumes a token if it was issued for the same tool call and has
+// not expired
+func (c *confirmations) redeem(token, call string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok || pending.call != call {
+		return false
+	}
+	delete(c.pending, token)
+
+	return time.Now().Before(pending.expires)
+}
+
+// confirmRequired is returned instead of running a tool call when the client
+// cannot be asked directly
+type confirmRequired struct {
+	ConfirmationRequired bool      `json:"confirmation_required"`
+	Tool                 string    `json:"tool"`
+	Reasons              []string  `json:"reasons"`
+	ConfirmToken         string    `json:"confirm_token"`
+	ExpiresAt            time.Time `json:"expires_at"`
+	Message              string    `json:"message"`
+}
+
+// ConfirmMiddleware asks the user before running tool calls matched by the
+// confirmation rules in the server configuration. Clients with elicitation
+// support are asked directly; others get a confirm token that must be passed
+// back with the same arguments. Tools that always need confirmation are
+// confirmed before they run; the light count and night room rules are
+// checked once a tool reports the lights it is about to change. Dry runs are
+// never confirmed, so this must run inside DryRunMiddleware.
+func ConfirmMiddleware(cfg *config.Config, res *resolver.Resolver) server.ToolHandlerMiddleware {
+	tokens := &confirmations{pending: make(map[string]confirmation)}
+
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			rules := cfg.Server.Confirm
+			if rules.Disabled || isDryRun(ctx) {
+				return next(ctx, request)
+			}
+
+			name := request.Params.Name
+			call := confirmCallKey(request)
+			if token := request.GetString("confirm_token", ""); token != "" {
+				if !tokens.redeem(token, call) {
+					return mcp.NewToolResultError("confirm_token is invalid, expired, or was issued for different arguments; call again without it to get a new one"), nil
+				}
+				return next(ctx, request)
+			}
+
+			if reasons := confirmToolReasons(rules, name); len(reasons) > 0 {
+				if result := confirmCall(ctx, tokens, name, call, reasons); result != nil {
+					return result, nil
+				}
+				return next(ctx, request)
+			}
+
+			if mutatingTools[name] && (rules.MaxLights > 0 || len(rules.NightRooms) > 0) {
+				ctx = withPreflight(ctx, func(ctx context.Context, report *dryRunReport) *mcp.CallToolResult {
+					reasons := confirmLightReasons(ctx, res, rules, report)
+					if len(reasons) == 0 {
+						return nil
+					}
+					return confirmCall(ctx, tokens, name, call, reasons)
+				})
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// confirmCall asks the user to confirm a tool call. It returns nil if the
+// call was confirmed, and otherwise the result to return in its place: an
+// error if the user declined, or a confirm token if they cannot be asked.
+func confirmCall(ctx context.Context, tokens *confirmations, name, call string, reasons []string) *mcp.CallToolResult {
+	message := fmt.Sprintf("%s needs confirmation: %s", name, strings.Join(reasons, "; "))
+
+	confirmed, err := elicitConfirmation(ctx, message)
+	if err == nil {
+		if !confirmed {
+			return mcp.NewToolResultError(fmt.Sprintf("%s was not confirmed; nothing was changed", name))
+		}
+		return nil
+	}
+	if !errors.Is(err, server.ErrElicitationNotSupported) {
+		log.Printf("Warning: asking for confirmation of %s failed, falling back to a confirm token: %v", name, err)
+	}
+
+	token, expires, err := tokens.issue(call)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+
+	data, err := json.MarshalIndent(confirmRequired{
+		ConfirmationRequired: true,
+		Tool:                 name,
+		Reasons:              reasons,
+		ConfirmToken:         token,
+		ExpiresAt:            expires,
+		Message:              "Nothing was changed. Ask the user to confirm, then call the tool again with the same arguments and confirm_token.",
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal confirmation: %v", err))
+	}
+
+	return mcp.NewToolResultText(string(data))
+}
+
+// confirmTools returns the tools that always need confirmation
+func confirmTools(rules config.ConfirmConfig) []string {
+	if len(rules.Tools) == 0 {
+		return defaultConfirmTools
+	}
+	return rules.Tools
+}
+
+// confirmToolReasons returns why a tool always needs confirmation
+func confirmToolReasons(rules config.ConfirmConfig, name string) []string {
+	if !containsString(confirmTools(rules), name) {
+		return nil
+	}
+	if strings.HasPrefix(name, "delete_") || strings.HasPrefix(name, "remove_") {
+		return []string{"it cannot be undone"}
+	}
+	if name == "install_software_update" {
+		return []string{"the device is unavailable while the update installs"}
+	}
+	return []string{"it is configured to always ask"}
+}
+
+// confirmLightReasons checks the lights a tool call is about to change
+// against the light count and night room rules
+func confirmLightReasons(ctx context.Context, res *resolver.Resolver, rules config.ConfirmConfig, report *dryRunReport) []string {
+	changing := make(map[string]bool)
+	for _, diff := range report.Changes {
+		if len(diff.Changes) > 0 {
+			changing[diff.LightID] = true
+		}
+	}
+
+	var reasons []string
+	if rules.MaxLights > 0 && len(changing) > rules.MaxLights {
+		reasons = append(reasons, fmt.Sprintf("it changes %d lights (more than %d)", len(changing), rules.MaxLights))
+	}
+
+	if len(rules.NightRooms) == 0 || len(changing) == 0 {
+		return reasons
+	}
+	night, err := isNight(rules, time.Now())
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return reasons
+	}
+	if !night {
+		return reasons
+	}
+
+	for _, room := range rules.NightRooms {
+		lightIDs, err := nightRoomLights(ctx, res, room)
+		if err != nil {
+			log.Printf("Warning: night room %q: %v", room, err)
+			continue
+		}
+		for _, id := range lightIDs {
+			if changing[id] {
+				reasons = append(reasons, fmt.Sprintf("it changes lights in %s at night", room))
+				break
+			}
+		}
+	}
+
+	return reasons
+}
+
+// nightRoomLights returns the lights of a configured night room or zone
+func nightRoomLights(ctx context.Context, res *resolver.Resolver, name string) ([]string, error) {
+	group, err := res.Resolve(ctx, resolver.KindRoom, name, "")
+	if err != nil {
+		var notFound *resolver.NotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
+		if group, err = res.Resolve(ctx, resolver.KindZone, name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return groupLightIDs(ctx, group.Bridge, resources.ResourceIdentifier{RID: group.ID, RType: string(group.Kind)})
+}
+
+// isNight reports whether t falls in the configured night hours, which
+// default to 22:00-07:00
+func isNight(rules config.ConfirmConfig, t time.Time) (bool, error) {
+	start, end := rules.NightStart, rules.NightEnd
+	if start == "" {
+		start = "22:00"
+	}
+	if end == "" {
+		end = "07:00"
+	}
+
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false, fmt.Errorf("invalid night_start %q, expected HH:MM", start)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false, fmt.Errorf("invalid night_end %q, expected HH:MM", end)
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	from := startTime.Hour()*60 + startTime.Minute()
+	to := endTime.Hour()*60 + endTime.Minute()
+
+	if from <= to {
+		return now >= from && now < to, nil
+	}
+	return now >= from || now < to, nil
+}
+
+// elicitConfirmation asks the user to confirm through MCP elicitation. It
+// returns server.ErrElicitationNotSupported if the client cannot be asked.
+func elicitConfirmation(ctx context.Context, message string) (bool, error) {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return false, server.ErrElicitationNotSupported
+	}
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		if session.GetClientCapabilities().Elicitation == nil {
+			return false, server.ErrElicitationNotSupported
+		}
+	}
+
+	result, err := srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: message,
+			RequestedSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"title":       "Proceed",
+						"description": "Run this action",
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+
+	content, _ := result.Content.(map[string]interface{})
+	confirmed, _ := content["confirm"].(bool)
+	return confirmed, nil
+}
+
+// confirmCallKey identifies a tool call by its name and arguments, ignoring
+// the confirmation and dry-run arguments
+func confirmCallKey(request mcp.CallToolRequest) string {
+	args := make(map[string]interface{})
+	for key, value := range request.GetArguments() {
+		if key != "confirm_token" && key != "dry_run" {
+			args[key] = value
+		}
+	}
+
+	// Map keys are marshaled in sorted order, so equal arguments give equal keys
+	data, _ := json.Marshal(args)
+	return request.Params.Name + " " + string(data)
+}
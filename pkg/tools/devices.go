@@ -0,0 +1,579 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// powerUpPresets are the power-on behaviors a light supports
+var powerUpPresets = []string{"safety", "powerfail", "last_on_state", "custom"}
+
+// deviceInfo is a device with its product data and group lookup
+type deviceInfo struct {
+	BridgeID        string                         `json:"bridge_id"`
+	BridgeName      string                         `json:"bridge_name"`
+	ID              string                         `json:"id"`
+	Name            string                         `json:"name"`
+	Archetype       string                         `json:"archetype,omitempty"`
+	Product         string                         `json:"product,omitempty"`
+	Model           string                         `json:"model,omitempty"`
+	Manufacturer    string                         `json:"manufacturer,omitempty"`
+	SoftwareVersion string                         `json:"software_version,omitempty"`
+	Certified       bool                           `json:"certified"`
+	Room            string                         `json:"room,omitempty"`
+	RoomID          string                         `json:"room_id,omitempty"`
+	Zones           []string                       `json:"zones,omitempty"`
+	Connectivity    string                         `json:"connectivity,omitempty"`
+	Unreachable     bool                           `json:"unreachable,omitempty"`
+	Battery         *int                           `json:"battery,omitempty"`
+	Services        []resources.ResourceIdentifier `json:"services"`
+}
+
+// deviceLookup maps the devices on one bridge to their room, zones,
+// connectivity and battery
+type deviceLookup struct {
+	rooms        map[string]resources.Room
+	zones        map[string][]string
+	connectivity map[string]string
+	batteries    map[string]resources.PowerState
+}
+
+// lookupDevices returns the rooms, zones, connectivity and battery of every
+// device on a bridge. Rooms contain devices; zones contain lights, so a
+// device is in a zone if any of its lights are.
+func lookupDevices(ctx context.Context, br *bridge.Bridge, devices []resources.Device) deviceLookup {
+	lookup := deviceLookup{
+		rooms:        make(map[string]resources.Room),
+		zones:        make(map[string][]string),
+		connectivity: br.Connectivity(ctx),
+		batteries:    batteriesOf(ctx, br),
+	}
+
+	if rooms, err := br.CachedClient.Rooms().List(ctx); err == nil {
+		lookup.rooms = deviceRooms(rooms)
+	}
+
+	owners := make(map[string]string)
+	for _, device := range devices {
+		for _, service := range device.Services {
+			owners[service.RID] = device.ID
+		}
+	}
+
+	if zones, err := br.CachedClient.Zones().List(ctx); err == nil {
+		for _, zone := range zones {
+			seen := make(map[string]bool)
+			for _, child := range zone.Children {
+				deviceID, ok := owners[child.RID]
+				if !ok || seen[deviceID] {
+					continue
+				}
+				seen[deviceID] = true
+				lookup.zones[deviceID] = append(lookup.zones[deviceID], zone.Metadata.Name)
+			}
+		}
+	}
+
+	return lookup
+}
+
+// describeDevice returns the details of a device
+func describeDevice(br *bridge.Bridge, device resources.Device, lookup deviceLookup) deviceInfo {
+	info := deviceInfo{
+		BridgeID:        br.ID,
+		BridgeName:      br.Name,
+		ID:              device.ID,
+		Name:            device.Metadata.Name,
+		Archetype:       device.Metadata.Archetype,
+		Product:         device.ProductData.ProductName,
+		Model:           device.ProductData.ModelID,
+		Manufacturer:    device.ProductData.ManufacturerName,
+		SoftwareVersion: device.ProductData.SoftwareVersion,
+		Certified:       device.ProductData.Certified,
+		Zones:           lookup.zones[device.ID],
+		Connectivity:    lookup.connectivity[device.ID],
+		Services:        device.Services,
+	}
+	info.Unreachable = info.Connectivity != "" && info.Connectivity != "connected"
+	if power, ok := lookup.batteries[device.ID]; ok {
+		level := power.BatteryLevel
+		info.Battery = &level
+	}
+	if info.Archetype == "" {
+		info.Archetype = device.ProductData.ProductArchetype
+	}
+	if room, ok := lookup.rooms[device.ID]; ok {
+		info.Room = room.Metadata.Name
+		info.RoomID = room.ID
+	}
+
+	return info
+}
+
+// RegisterDeviceTools registers all device tools
+func RegisterDeviceTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// list_devices tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_devices",
+			Description: "List devices with model, product name, manufacturer, software version, archetype, the services each provides, and their room and zones",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists devices from all bridges",
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional room name to only list the devices in that room",
+					},
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional service type to only list devices that provide it, e.g. light, motion, button, temperature",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+			room := request.GetString("room", "")
+			service := request.GetString("service", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			allDevices := []deviceInfo{}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				devices, err := br.CachedClient.Devices().List(ctx)
+				if err != nil {
+					continue
+				}
+
+				lookup := lookupDevices(ctx, br, devices)
+
+				for _, device := range devices {
+					info := describeDevice(br, device, lookup)
+					if room != "" && !strings.EqualFold(info.Room, room) {
+						continue
+					}
+					if service != "" && !hasService(device.Services, service) {
+						continue
+					}
+					allDevices = append(allDevices, info)
+				}
+			}
+
+			sort.SliceStable(allDevices, func(i, j int) bool {
+				return allDevices[i].Name < allDevices[j].Name
+			})
+
+			data, err := json.MarshalIndent(allDevices, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal devices: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_device tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_device",
+			Description: "Get a device's product data, services, room and zones, and the power-on behavior of its lights",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"device_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The device ID, name or alias, or one of its lights",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"device_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("device_id")
+			if err != nil {
+				return mcp.NewToolResultError("device_id is required"), nil
+			}
+
+			target, err := resolveDevice(ctx, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			devices, err := target.Bridge.CachedClient.Devices().List(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list devices: %v", err)), nil
+			}
+
+			var device *resources.Device
+			for i := range devices {
+				if devices[i].ID == target.ID {
+					device = &devices[i]
+					break
+				}
+			}
+			if device == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("device %s not found", target.Label())), nil
+			}
+
+			type lightPowerUp struct {
+				LightID string             `json:"light_id"`
+				Name    string             `json:"name"`
+				PowerUp *resources.PowerUp `json:"power_on_behavior,omitempty"`
+			}
+
+			details := struct {
+				deviceInfo
+				Lights []lightPowerUp `json:"lights,omitempty"`
+			}{
+				deviceInfo: describeDevice(target.Bridge, *device, lookupDevices(ctx, target.Bridge, devices)),
+			}
+
+			for _, service := range device.Services {
+				if service.RType != "light" {
+					continue
+				}
+				light, err := target.Bridge.CachedClient.Lights().Get(ctx, service.RID)
+				if err != nil {
+					continue
+				}
+				details.Lights = append(details.Lights, lightPowerUp{
+					LightID: light.ID,
+					Name:    light.Metadata.Name,
+					PowerUp: light.PowerUp,
+				})
+			}
+
+			data, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal device: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// rename_device tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "rename_device",
+			Description: "Rename a device and optionally change its archetype (the icon shown in the Hue app)",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"device_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The device ID, name or alias, or one of its lights",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "The new device name",
+					},
+					"archetype": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional new archetype, e.g. sultan_bulb, pendant_round, hue_lightstrip",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"device_id", "name"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("device_id")
+			if err != nil {
+				return mcp.NewToolResultError("device_id is required"), nil
+			}
+			name, err := request.RequireString("name")
+			if err != nil || name == "" {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			target, err := resolveDevice(ctx, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			device, err := target.Bridge.CachedClient.Devices().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get device: %v", err)), nil
+			}
+
+			archetype := request.GetString("archetype", device.Metadata.Archetype)
+			update := resources.DeviceUpdate{
+				Metadata: &resources.Metadata{Name: name, Archetype: archetype},
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "devices.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Devices().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to rename device: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Device renamed from %q to %q, but refreshing the name index failed: %v", device.Metadata.Name, name, err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Device renamed from %q to %q", device.Metadata.Name, name)), nil
+		},
+	)
+
+	// identify_device tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "identify_device",
+			Description: "Make a device identify itself, e.g. a light blinks, so you can find which physical device it is",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"device_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The device ID, name or alias, or one of its lights",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"device_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("device_id")
+			if err != nil {
+				return mcp.NewToolResultError("device_id is required"), nil
+			}
+
+			target, err := resolveDevice(ctx, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			update := resources.DeviceUpdate{
+				Identify: &resources.DeviceIdentify{Action: "identify"},
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "devices.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Devices().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to identify device: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ %s is identifying itself", target.Label())), nil
+		},
+	)
+
+	// set_power_on_behavior tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "set_power_on_behavior",
+			Description: "Set what a device's lights do when power returns, e.g. after the wall switch is flipped: safety (bright warm white), powerfail (the state before power was lost), last_on_state (the last state the light was on in), or custom",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"device_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The device ID, name or alias, or one of its lights",
+					},
+					"preset": map[string]interface{}{
+						"type":        "string",
+						"description": "The power-on behavior",
+						"enum":        powerUpPresets,
+					},
+					"on_mode": map[string]interface{}{
+						"type":        "string",
+						"description": "For custom: whether the light turns on (default), stays off, toggles, or keeps its previous on/off state",
+						"enum":        []string{"on", "off", "toggle", "previous"},
+					},
+					"brightness": map[string]interface{}{
+						"type":        "number",
+						"description": "For custom: brightness percentage (1-100). Omit to keep the previous brightness",
+						"minimum":     1,
+						"maximum":     100,
+					},
+					"color_temp": map[string]interface{}{
+						"type":        "number",
+						"description": "For custom: color temperature in mirek (153-500). Omit to keep the previous color",
+						"minimum":     153,
+						"maximum":     500,
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"device_id", "preset"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("device_id")
+			if err != nil {
+				return mcp.NewToolResultError("device_id is required"), nil
+			}
+			preset, err := request.RequireString("preset")
+			if err != nil || !containsString(powerUpPresets, preset) {
+				return mcp.NewToolResultError(fmt.Sprintf("preset must be one of: %s", strings.Join(powerUpPresets, ", "))), nil
+			}
+
+			powerUp, err := powerUpFromArgs(preset, request.GetArguments())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			target, err := resolveDevice(ctx, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			device, err := target.Bridge.CachedClient.Devices().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get device: %v", err)), nil
+			}
+
+			update := resources.LightUpdate{PowerUp: powerUp}
+
+			var commands []dryRunCommand
+			for _, service := range device.Services {
+				if service.RType != "light" {
+					continue
+				}
+				if powerUp.Color != nil && powerUp.Color.ColorTemperature != nil {
+					light, err := target.Bridge.CachedClient.Lights().Get(ctx, service.RID)
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to get light: %v", err)), nil
+					}
+					if light.ColorTemperature == nil {
+						return mcp.NewToolResultError(fmt.Sprintf("%s does not support color temperature", light.Metadata.Name)), nil
+					}
+				}
+				commands = append(commands, dryRunCommand{BridgeID: target.BridgeID, Method: "lights.update", TargetID: service.RID, Payload: update})
+			}
+			if len(commands) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("%s has no lights", target.Label())), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(commands...).result()
+			}
+
+			for _, command := range commands {
+				lightID := command.TargetID
+				err := target.Bridge.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+					return target.Bridge.CachedClient.Lights().Update(ctx, lightID, update)
+				})
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to set power-on behavior: %v", err)), nil
+				}
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Power-on behavior of %s set to %s", target.Label(), preset)), nil
+		},
+	)
+}
+
+// hasService reports whether a device provides a service type
+func hasService(services []resources.ResourceIdentifier, rtype string) bool {
+	for _, service := range services {
+		if service.RType == rtype {
+			return true
+		}
+	}
+	return false
+}
+
+// powerUpFromArgs builds a power-on behavior from tool arguments
+func powerUpFromArgs(preset string, args map[string]interface{}) (*resources.PowerUp, error) {
+	powerUp := &resources.PowerUp{Preset: preset}
+
+	onMode, _ := args["on_mode"].(string)
+	brightness, hasBrightness := args["brightness"].(float64)
+	mirek, hasMirek := args["color_temp"].(float64)
+
+	if preset != "custom" {
+		if onMode != "" || hasBrightness || hasMirek {
+			return nil, fmt.Errorf("on_mode, brightness and color_temp only apply to the custom preset")
+		}
+		return powerUp, nil
+	}
+
+	switch onMode {
+	case "", "on":
+		powerUp.On = &resources.PowerUpOn{Mode: "on", On: &resources.OnState{On: true}}
+	case "off":
+		powerUp.On = &resources.PowerUpOn{Mode: "on", On: &resources.OnState{On: false}}
+	case "toggle", "previous":
+		powerUp.On = &resources.PowerUpOn{Mode: onMode}
+	default:
+		return nil, fmt.Errorf("on_mode must be on, off, toggle or previous")
+	}
+
+	powerUp.Dimming = &resources.PowerUpDimming{Mode: "previous"}
+	if hasBrightness {
+		if brightness < 1 || brightness > 100 {
+			return nil, fmt.Errorf("brightness must be between 1 and 100")
+		}
+		powerUp.Dimming = &resources.PowerUpDimming{Mode: "dimming", Dimming: &resources.Dimming{Brightness: brightness}}
+	}
+
+	powerUp.Color = &resources.PowerUpColor{Mode: "previous"}
+	if hasMirek {
+		if mirek < 153 || mirek > 500 {
+			return nil, fmt.Errorf("color_temp must be between 153 and 500 mirek")
+		}
+		powerUp.Color = &resources.PowerUpColor{
+			Mode:             "color_temperature",
+			ColorTemperature: &resources.ColorTemperature{Mirek: int(mirek)},
+		}
+	}
+
+	return powerUp, nil
+}
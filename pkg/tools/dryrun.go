@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// mutatingTools are the tools that change lights, bridge resources or saved
+// configuration and state. Each of them declares dryRunArgument and
+// confirmTokenArgument.
+var mutatingTools = map[string]bool{
+	"remove_bridge":                      true,
+	"set_default_bridge":                 true,
+	"set_alias":                          true,
+	"remove_alias":                       true,
+	"control_light":                      true,
+	"control_lights":                     true,
+	"control_room_lights":                true,
+	"set_gradient":                       true,
+	"activate_scene":                     true,
+	"create_scene":                       true,
+	"update_scene":                       true,
+	"delete_scene":                       true,
+	"activate_smart_scene":               true,
+	"deactivate_smart_scene":             true,
+	"create_smart_scene":                 true,
+	"delete_smart_scene":                 true,
+	"create_room":                        true,
+	"update_room":                        true,
+	"move_devices":                       true,
+	"delete_room":                        true,
+	"create_zone":                        true,
+	"update_zone":                        true,
+	"delete_zone":                        true,
+	"take_snapshot":                      true,
+	"restore_snapshot":                   true,
+	"delete_snapshot":                    true,
+	"undo_last_change":                   true,
+	"enable_sensor":                      true,
+	"disable_sensor":                     true,
+	"rename_device":                      true,
+	"identify_device":                    true,
+	"set_power_on_behavior":              true,
+	"install_software_update":            true,
+	"create_entertainment_configuration": true,
+	"update_entertainment_configuration": true,
+	"delete_entertainment_configuration": true,
+	"start_entertainment_streaming":      true,
+	"stop_entertainment_streaming":       true,
+	"start_stream_effect":                true,
+	"stop_stream_effect":                 true,
+	"start_animation":                    true,
+	"stop_animation":                     true,
+	"play_light_show":                    true,
+	"pause_light_show":                   true,
+	"resume_light_show":                  true,
+	"seek_light_show":                    true,
+	"stop_light_show":                    true,
+	"export_light_show":                  true,
+}
+
+// dryRunArgument is the schema of the dry_run argument of mutating tools
+var dryRunArgument = map[string]interface{}{
+	"type":        "boolean",
+	"description": "Resolve and validate everything and return the exact update payloads and predicted changes without sending anything",
+}
+
+// dryRunKey marks a context whose tool call must not send anything
+type dryRunKey struct{}
+
+// isDryRun reports whether the current tool call is a dry run
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// DryRunMiddleware marks mutating tool calls as dry runs when they pass
+// dry_run or the server is configured for dry runs. It must be the
+// outermost middleware so the others can see the mark.
+func DryRunMiddleware(cfg *config.Config) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if mutatingTools[request.Params.Name] && (cfg.Server.DryRun || request.GetBool("dry_run", false)) {
+				ctx = context.WithValue(ctx, dryRunKey{}, true)
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// dryRunCommand is one request a tool would send to a bridge
+type dryRunCommand struct {
+	BridgeID string      `json:"bridge_id,omitempty"`
+	Method   string      `json:"method"`
+	TargetID string      `json:"target_id,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// dryRunReport describes what a mutating tool would do
+type dryRunReport struct {
+	DryRun   bool            `json:"dry_run"`
+	Commands []dryRunCommand `json:"commands"`
+	Changes  []lightDiff     `json:"predicted_changes,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Plan     interface{}     `json:"plan,omitempty"`
+}
+
+// newDryRun starts a dry-run report with the given commands
+func newDryRun(commands ...dryRunCommand) *dryRunReport {
+	return &dryRunReport{DryRun: true, Commands: commands}
+}
+
+// preflightKey carries the checks middleware runs on a tool call's
+// predicted changes before anything is sent
+type preflightKey struct{}
+
+// preflightCheck inspects the predicted changes of a tool call. A non-nil
+// result stops the call and is returned in its place.
+type preflightCheck func(ctx context.Context, report *dryRunReport) *mcp.CallToolResult
+
+// withPreflight adds a check for the tool call of ctx. Checks run in the
+// order they were added.
+func withPreflight(ctx context.Context, check preflightCheck) context.Context {
+	checks, _ := ctx.Value(preflightKey{}).([]preflightCheck)
+	checks = append(checks[:len(checks):len(checks)], check)
+	return context.WithValue(ctx, preflightKey{}, checks)
+}
+
+// preflight is called by light-changing tools once the report holds what
+// they are about to send. For a dry run it returns the report; otherwise it
+// runs the middleware checks, so they see the changes without running the
+// tool a second time. It returns false if the tool should go ahead.
+func (r *dryRunReport) preflight(ctx context.Context) (*mcp.CallToolResult, bool) {
+	if isDryRun(ctx) {
+		result, _ := r.result()
+		return result, true
+	}
+
+	checks, _ := ctx.Value(preflightKey{}).([]preflightCheck)
+	for _, check := range checks {
+		if result := check(ctx, r); result != nil {
+			return result, true
+		}
+	}
+
+	return nil, false
+}
+
+// result returns the report as a tool result
+func (r *dryRunReport) result() (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal dry run: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// predictLight adds the predicted change of a light update to the report,
+// with a warning if the light cannot apply it
+func (r *dryRunReport) predictLight(ctx context.Context, br *bridge.Bridge, lightID string, update resources.LightUpdate) {
+	light, err := br.CachedClient.Lights().Get(ctx, lightID)
+	if err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("light %s: %v", lightID, err))
+		return
+	}
+
+	diff := diffLightUpdate(light, update)
+	diff.BridgeID = br.ID
+	diff.Reachable = !br.UnreachableDevices(ctx)[light.Owner.RID]
+	if !diff.Reachable {
+		diff.Warnings = append(diff.Warnings, "light is unreachable and will not change")
+	}
+
+	if err := validateSceneAction(light, stateAction(update)); err != nil {
+		diff.Warnings = append(diff.Warnings, err.Error())
+	}
+
+	r.Changes = append(r.Changes, diff)
+}
+
+// predictLights adds the predicted change of the same update for several lights
+func (r *dryRunReport) predictLights(ctx context.Context, br *bridge.Bridge, lightIDs []string, update resources.LightUpdate) {
+	for _, id := range lightIDs {
+		r.predictLight(ctx, br, id, update)
+	}
+}
+
+// affectLights adds lights whose change plays out over time, such as a
+// stream or show, to the report with a single change describing it
+func (r *dryRunReport) affectLights(ctx context.Context, br *bridge.Bridge, lightIDs []string, change fieldChange) {
+	unreachable := br.UnreachableDevices(ctx)
+	for _, id := range lightIDs {
+		light, err := br.CachedClient.Lights().Get(ctx, id)
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("light %s: %v", id, err))
+			continue
+		}
+
+		diff := lightDiff{
+			BridgeID:  br.ID,
+			LightID:   light.ID,
+			Name:      light.Metadata.Name,
+			Reachable: !unreachable[light.Owner.RID],
+			InGroup:   true,
+			Changes:   []fieldChange{change},
+		}
+		if !diff.Reachable {
+			diff.Warnings = append(diff.Warnings, "light is unreachable and will not change")
+		}
+		r.Changes = append(r.Changes, diff)
+	}
+}
+
+// diffLightUpdate compares a light update with a light's current state
+func diffLightUpdate(light *resources.Light, update resources.LightUpdate) lightDiff {
+	diff := lightDiff{
+		LightID:   light.ID,
+		Name:      light.Metadata.Name,
+		Reachable: true,
+		InGroup:   true,
+		Changes:   diffSceneAction(light, stateAction(update)),
+	}
+
+	if update.TimedEffects != nil {
+		diff.Changes = append(diff.Changes, fieldChange{Field: "timed_effect", From: nil, To: update.TimedEffects.Effect})
+	}
+	if update.Alert != nil {
+		diff.Changes = append(diff.Changes, fieldChange{Field: "alert", From: nil, To: update.Alert.Action})
+	}
+
+	return diff
+}
+
+// groupedLightUpdateAsLight expresses a grouped light update as the light
+// update each member receives
+func groupedLightUpdateAsLight(update resources.GroupedLightUpdate) resources.LightUpdate {
+	return resources.LightUpdate{
+		On:               update.On,
+		Dimming:          update.Dimming,
+		Color:            update.Color,
+		ColorTemperature: update.ColorTemperature,
+		Alert:            update.Alert,
+	}
+}
@@ -0,0 +1,749 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// entertainmentTypes are the configuration types the bridge accepts
+var entertainmentTypes = []string{"screen", "monitor", "music", "3dspace", "other"}
+
+// entertainmentSummary is an entertainment configuration in a listing
+type entertainmentSummary struct {
+	BridgeID          string `json:"bridge_id"`
+	BridgeName        string `json:"bridge_name"`
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	ConfigurationType string `json:"configuration_type"`
+	Status            string `json:"status"`
+	Lights            int    `json:"lights"`
+	Channels          int    `json:"channels"`
+}
+
+// entertainmentLight is a light placed in an entertainment configuration
+type entertainmentLight struct {
+	LightID   string               `json:"light_id,omitempty"`
+	Name      string               `json:"name,omitempty"`
+	ServiceID string               `json:"service_id"`
+	Positions []resources.Position `json:"positions"`
+}
+
+// entertainmentChannel is a streaming channel and the lights it drives
+type entertainmentChannel struct {
+	ChannelID int                `json:"channel_id"`
+	Position  resources.Position `json:"position"`
+	Lights    []string           `json:"lights"`
+}
+
+// entertainmentDetails is an entertainment configuration with its lights
+// and channels
+type entertainmentDetails struct {
+	BridgeID          string                 `json:"bridge_id"`
+	ID                string                 `json:"id"`
+	Name              string                 `json:"name"`
+	ConfigurationType string                 `json:"configuration_type"`
+	Status            string                 `json:"status"`
+	ActiveStreamer    string                 `json:"active_streamer,omitempty"`
+	StreamProxy       string                 `json:"stream_proxy_mode,omitempty"`
+	ClientKey         bool                   `json:"client_key_configured"`
+	Lights            []entertainmentLight   `json:"lights"`
+	Channels          []entertainmentChannel `json:"channels"`
+}
+
+// RegisterEntertainmentTools registers the entertainment configuration tools
+func RegisterEntertainmentTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	positionSchema := map[string]interface{}{
+		"type":        "object",
+		"description": "Position in channel space, each axis from -1 to 1: x left to right, y back to front, z floor to ceiling",
+		"properties": map[string]interface{}{
+			"x": map[string]interface{}{"type": "number", "minimum": -1, "maximum": 1},
+			"y": map[string]interface{}{"type": "number", "minimum": -1, "maximum": 1},
+			"z": map[string]interface{}{"type": "number", "minimum": -1, "maximum": 1},
+		},
+		"required": []string{"x", "y"},
+	}
+	lightsSchema := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "array",
+			"description": description,
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"light_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Light ID, name, \"Room/Light\" path or alias",
+					},
+					"position": positionSchema,
+					"positions": map[string]interface{}{
+						"type":        "array",
+						"description": "Several positions for a gradient light, from its start to its end",
+						"items":       positionSchema,
+					},
+				},
+				"required": []string{"light_id"},
+			},
+		}
+	}
+
+	// list_entertainment_configurations tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_entertainment_configurations",
+			Description: "List entertainment areas, the light groups used for streaming effects, with their type and whether they are streaming",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists entertainment areas from all bridges",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			allConfigs := []entertainmentSummary{}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				configs, err := br.CachedClient.EntertainmentConfigurations().List(ctx)
+				if err != nil {
+					continue
+				}
+
+				for _, ec := range configs {
+					allConfigs = append(allConfigs, entertainmentSummary{
+						BridgeID:          br.ID,
+						BridgeName:        br.Name,
+						ID:                ec.ID,
+						Name:              ec.Metadata.Name,
+						ConfigurationType: ec.ConfigurationType,
+						Status:            ec.Status,
+						Lights:            len(ec.Locations.ServiceLocations),
+						Channels:          len(ec.Channels),
+					})
+				}
+			}
+
+			data, err := json.MarshalIndent(allConfigs, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal entertainment configurations: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_entertainment_configuration tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_entertainment_configuration",
+			Description: "Get an entertainment area with the position of each light and the streaming channels the bridge derived from them",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"configuration_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The entertainment configuration ID, name or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"configuration_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			ec, err := target.Bridge.CachedClient.EntertainmentConfigurations().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get entertainment configuration: %v", err)), nil
+			}
+
+			data, err := json.MarshalIndent(describeEntertainment(ctx, target.Bridge, ec), "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal entertainment configuration: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// create_entertainment_configuration tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "create_entertainment_configuration",
+			Description: "Create an entertainment area from entertainment-capable lights on one bridge. Lights without a position are spread evenly from left to right across the front.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the new entertainment area",
+					},
+					"configuration_type": map[string]interface{}{
+						"type":        "string",
+						"description": "What the area is used for. Defaults to 'other'",
+						"enum":        entertainmentTypes,
+					},
+					"lights": lightsSchema("Lights to include, each with an optional position"),
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Defaults to the bridge the lights are on",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name", "lights"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			br, locations, err := entertainmentLocationsFromArgs(ctx, bm, res, request.GetArguments()["lights"], request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			create := resources.EntertainmentConfigurationCreate{
+				Metadata:          resources.Metadata{Name: name},
+				ConfigurationType: request.GetString("configuration_type", "other"),
+				Locations:         &resources.EntertainmentLocations{ServiceLocations: locations},
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: br.ID, Method: "entertainment_configurations.create", Payload: create}).result()
+			}
+
+			created, err := br.CachedClient.EntertainmentConfigurations().Create(ctx, create)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create entertainment configuration: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, br.ID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %q created (id %s) with %d light(s), but refreshing the index failed: %v", name, created.RID, len(locations), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %q created (id %s) with %d light(s) on bridge %s", name, created.RID, len(locations), br.ID)), nil
+		},
+	)
+
+	// update_entertainment_configuration tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "update_entertainment_configuration",
+			Description: "Rename an entertainment area, change its type, move lights in channel space, or add and remove lights",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"configuration_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The entertainment configuration ID, name or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for the entertainment area",
+					},
+					"configuration_type": map[string]interface{}{
+						"type":        "string",
+						"description": "New configuration type",
+						"enum":        entertainmentTypes,
+					},
+					"lights": lightsSchema("Lights to position. Lights already in the area are moved; others are added"),
+					"remove_lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to remove from the area",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"configuration_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			ec, err := target.Bridge.CachedClient.EntertainmentConfigurations().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get entertainment configuration: %v", err)), nil
+			}
+			if ec.Status == "active" {
+				return mcp.NewToolResultError(fmt.Sprintf("Entertainment area %s is streaming; stop it before changing it", target.Label())), nil
+			}
+
+			update := resources.EntertainmentConfigurationUpdate{}
+			changes := 0
+
+			if name := request.GetString("name", ""); name != "" {
+				update.Metadata = &resources.Metadata{Name: name}
+				changes++
+			}
+			if configType := request.GetString("configuration_type", ""); configType != "" {
+				update.ConfigurationType = configType
+				changes++
+			}
+
+			args := request.GetArguments()
+			removals := request.GetStringSlice("remove_lights", nil)
+			if args["lights"] != nil || len(removals) > 0 {
+				locations := ec.Locations.ServiceLocations
+
+				if args["lights"] != nil {
+					_, moved, err := entertainmentLocationsFromArgs(ctx, bm, res, args["lights"], target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					locations = mergeLocations(locations, moved)
+				}
+
+				for _, query := range removals {
+					light, err := res.Resolve(ctx, resolver.KindLight, query, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					service, err := entertainmentService(ctx, target.Bridge, light.ID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					locations = removeLocation(locations, service.ID)
+				}
+
+				if len(locations) == 0 {
+					return mcp.NewToolResultError("An entertainment area needs at least one light"), nil
+				}
+				update.Locations = &resources.EntertainmentLocations{ServiceLocations: locations}
+				changes++
+			}
+
+			if changes == 0 {
+				return mcp.NewToolResultError("Nothing to update: give name, configuration_type, lights or remove_lights"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "entertainment_configurations.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.EntertainmentConfigurations().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update entertainment configuration: %v", err)), nil
+			}
+
+			if update.Metadata != nil {
+				if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %s updated, but refreshing the index failed: %v", target.Label(), err)), nil
+				}
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %s updated", target.Label())), nil
+		},
+	)
+
+	// delete_entertainment_configuration tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_entertainment_configuration",
+			Description: "Delete an entertainment area. The lights themselves are not affected",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"configuration_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The entertainment configuration ID, name or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"configuration_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "entertainment_configurations.delete", TargetID: target.ID}).result()
+			}
+
+			if err := target.Bridge.CachedClient.EntertainmentConfigurations().Delete(ctx, target.ID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete entertainment configuration: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %s deleted, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Entertainment area %s deleted", target.Label())), nil
+		},
+	)
+
+	// start_entertainment_streaming and stop_entertainment_streaming tools
+	for _, action := range []string{"start", "stop"} {
+		description := "Start streaming on an entertainment area. The bridge hands its lights to the streaming client, which must connect over DTLS with the bridge's client key within 10 seconds or the bridge stops the session."
+		if action == "stop" {
+			description = "Stop streaming on an entertainment area and hand its lights back to normal control"
+		}
+
+		s.AddTool(
+			mcp.Tool{
+				Name:        action + "_entertainment_streaming",
+				Description: description,
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"configuration_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The entertainment configuration ID, name or alias",
+						},
+						"bridge_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+						},
+						"dry_run":       dryRunArgument,
+						"confirm_token": confirmTokenArgument,
+					},
+					Required: []string{"configuration_id"},
+				},
+			},
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				if action == "start" && target.Bridge.ClientKey == "" {
+					return mcp.NewToolResultError(fmt.Sprintf("Bridge %s has no client key configured. Streaming needs the client_key returned by authenticate_bridge; authenticate again and add the bridge with it.", target.BridgeID)), nil
+				}
+
+				update := resources.EntertainmentConfigurationUpdate{Action: action}
+
+				if isDryRun(ctx) {
+					return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "entertainment_configurations.update", TargetID: target.ID, Payload: update}).result()
+				}
+
+				err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+					return target.Bridge.CachedClient.EntertainmentConfigurations().Update(ctx, target.ID, update)
+				})
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to %s streaming: %v", action, err)), nil
+				}
+
+				if action == "stop" {
+					return mcp.NewToolResultText(fmt.Sprintf("✅ Streaming stopped on entertainment area %s", target.Label())), nil
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Streaming started on entertainment area %s. Connect a DTLS client within 10 seconds to keep the session.", target.Label())), nil
+			},
+		)
+	}
+}
+
+// describeEntertainment resolves the entertainment services of a
+// configuration back to the lights that own them
+func describeEntertainment(ctx context.Context, br *bridge.Bridge, ec *resources.EntertainmentConfiguration) entertainmentDetails {
+	details := entertainmentDetails{
+		BridgeID:          br.ID,
+		ID:                ec.ID,
+		Name:              ec.Metadata.Name,
+		ConfigurationType: ec.ConfigurationType,
+		Status:            ec.Status,
+		StreamProxy:       ec.StreamProxy.Mode,
+		ClientKey:         br.ClientKey != "",
+		Lights:            []entertainmentLight{},
+		Channels:          []entertainmentChannel{},
+	}
+	if ec.ActiveStreamer != nil {
+		details.ActiveStreamer = ec.ActiveStreamer.RID
+	}
+
+	lights := entertainmentServiceLights(ctx, br)
+	label := func(serviceID string) string {
+		if light, ok := lights[serviceID]; ok && light.Metadata.Name != "" {
+			return light.Metadata.Name
+		}
+		return serviceID
+	}
+
+	for _, location := range ec.Locations.ServiceLocations {
+		light := entertainmentLight{
+			ServiceID: location.Service.RID,
+			Positions: location.Positions,
+		}
+		if l, ok := lights[location.Service.RID]; ok {
+			light.LightID = l.ID
+			light.Name = l.Metadata.Name
+		}
+		details.Lights = append(details.Lights, light)
+	}
+
+	for _, channel := range ec.Channels {
+		info := entertainmentChannel{
+			ChannelID: channel.ChannelID,
+			Position:  channel.Position,
+			Lights:    []string{},
+		}
+		for _, member := range channel.Members {
+			info.Lights = append(info.Lights, label(member.Service.RID))
+		}
+		details.Channels = append(details.Channels, info)
+	}
+	sort.Slice(details.Channels, func(i, j int) bool {
+		return details.Channels[i].ChannelID < details.Channels[j].ChannelID
+	})
+
+	return details
+}
+
+// entertainmentLocationsFromArgs parses a lights argument into service
+// locations. All lights must be on one bridge and support entertainment.
+func entertainmentLocationsFromArgs(ctx context.Context, bm *bridge.Manager, res *resolver.Resolver, raw interface{}, bridgeID string) (*bridge.Bridge, []resources.ServiceLocation, error) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, nil, fmt.Errorf("lights must be a non-empty array")
+	}
+
+	type placement struct {
+		light     *resolver.Entry
+		positions []resources.Position
+	}
+
+	placements := make([]placement, 0, len(list))
+	for i, item := range list {
+		args, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("light %d: invalid light", i+1)
+		}
+
+		query, _ := args["light_id"].(string)
+		if query == "" {
+			return nil, nil, fmt.Errorf("light %d: missing light_id", i+1)
+		}
+
+		light, err := res.Resolve(ctx, resolver.KindLight, query, bridgeID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("light %d: %w", i+1, err)
+		}
+		if bridgeID == "" {
+			bridgeID = light.BridgeID
+		} else if light.BridgeID != bridgeID {
+			return nil, nil, fmt.Errorf("light %s is on bridge %s, but all lights must be on bridge %s", light.Label(), light.BridgeID, bridgeID)
+		}
+
+		var positions []resources.Position
+		if p, ok := args["position"]; ok {
+			position, err := positionFromArgs(p)
+			if err != nil {
+				return nil, nil, fmt.Errorf("light %s: %w", light.Label(), err)
+			}
+			positions = append(positions, position)
+		}
+		if ps, ok := args["positions"].([]interface{}); ok {
+			for _, p := range ps {
+				position, err := positionFromArgs(p)
+				if err != nil {
+					return nil, nil, fmt.Errorf("light %s: %w", light.Label(), err)
+				}
+				positions = append(positions, position)
+			}
+		}
+
+		placements = append(placements, placement{light: light, positions: positions})
+	}
+
+	br, err := bm.GetBridge(bridgeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	locations := make([]resources.ServiceLocation, 0, len(placements))
+	for i, p := range placements {
+		service, err := entertainmentService(ctx, br, p.light.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Unplaced lights are spread from left to right across the front
+		positions := p.positions
+		if len(positions) == 0 {
+			x := 0.0
+			if len(placements) > 1 {
+				x = -1 + 2*float64(i)/float64(len(placements)-1)
+			}
+			positions = []resources.Position{{X: x, Y: 1}}
+		}
+
+		locations = append(locations, resources.ServiceLocation{
+			Service:   resources.ResourceIdentifier{RID: service.ID, RType: "entertainment"},
+			Positions: positions,
+		})
+	}
+
+	return br, locations, nil
+}
+
+// positionFromArgs parses and validates a channel space position
+func positionFromArgs(raw interface{}) (resources.Position, error) {
+	args, ok := raw.(map[string]interface{})
+	if !ok {
+		return resources.Position{}, fmt.Errorf("position must be an object with x, y and z")
+	}
+
+	var position resources.Position
+	for axis, value := range map[string]*float64{"x": &position.X, "y": &position.Y, "z": &position.Z} {
+		v, ok := args[axis].(float64)
+		if !ok {
+			if axis == "z" {
+				continue
+			}
+			return resources.Position{}, fmt.Errorf("position is missing %s", axis)
+		}
+		if v < -1 || v > 1 {
+			return resources.Position{}, fmt.Errorf("position %s must be between -1 and 1", axis)
+		}
+		*value = v
+	}
+
+	return position, nil
+}
+
+// mergeLocations replaces the positions of lights already in a
+// configuration and appends the others
+func mergeLocations(locations, changes []resources.ServiceLocation) []resources.ServiceLocation {
+	merged := append([]resources.ServiceLocation{}, locations...)
+	for _, change := range changes {
+		replaced := false
+		for i := range merged {
+			if merged[i].Service.RID == change.Service.RID {
+				merged[i].Positions = change.Positions
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, change)
+		}
+	}
+	return merged
+}
+
+// removeLocation drops an entertainment service from a list of locations
+func removeLocation(locations []resources.ServiceLocation, serviceID string) []resources.ServiceLocation {
+	kept := make([]resources.ServiceLocation, 0, len(locations))
+	for _, location := range locations {
+		if location.Service.RID != serviceID {
+			kept = append(kept, location)
+		}
+	}
+	return kept
+}
+
+// entertainmentService returns the entertainment service of a light's
+// device, failing if the light cannot render streamed colors
+func entertainmentService(ctx context.Context, br *bridge.Bridge, lightID string) (*resources.Entertainment, error) {
+	light, err := br.CachedClient.Lights().Get(ctx, lightID)
+	if err != nil {
+		return nil, fmt.Errorf("getting light %s: %w", lightID, err)
+	}
+
+	device, err := br.CachedClient.Devices().Get(ctx, light.Owner.RID)
+	if err != nil {
+		return nil, fmt.Errorf("getting device of light %q: %w", light.Metadata.Name, err)
+	}
+
+	for _, service := range device.Services {
+		if service.RType != "entertainment" {
+			continue
+		}
+		ent, err := br.CachedClient.Entertainment().Get(ctx, service.RID)
+		if err != nil {
+			return nil, fmt.Errorf("getting entertainment service of light %q: %w", light.Metadata.Name, err)
+		}
+		if !ent.Renderer {
+			break
+		}
+		return ent, nil
+	}
+
+	return nil, fmt.Errorf("light %q does not support entertainment streaming", light.Metadata.Name)
+}
+
+// entertainmentServiceLights maps the entertainment services on a bridge to
+// the lights of the same device
+func entertainmentServiceLights(ctx context.Context, br *bridge.Bridge) map[string]resources.Light {
+	byService := make(map[string]resources.Light)
+
+	lights, err := br.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return byService
+	}
+	devices, err := br.CachedClient.Devices().List(ctx)
+	if err != nil {
+		return byService
+	}
+
+	byDevice := make(map[string]resources.Light)
+	for _, light := range lights {
+		byDevice[light.Owner.RID] = light
+	}
+
+	for _, device := range devices {
+		light, ok := byDevice[device.ID]
+		if !ok {
+			continue
+		}
+		for _, service := range device.Services {
+			if service.RType == "entertainment" {
+				byService[service.RID] = light
+			}
+		}
+	}
+
+	return byService
+}
@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/color"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// Gradient modes of gradient-capable lights
+const (
+	gradientInterpolated = "interpolated_palette"
+	gradientMirrored     = "interpolated_palette_mirrored"
+	gradientPixelated    = "random_pixelated"
+)
+
+// gradientModes are the gradient modes set_gradient can build
+var gradientModes = []string{gradientInterpolated, gradientMirrored, gradientPixelated}
+
+// Gradient point limits
+const (
+	minGradientPoints     = 2
+	defaultGradientPoints = 5
+)
+
+// gradientLight is one light a gradient is built for
+type gradientLight struct {
+	target *resolver.Entry
+	light  *resources.Light
+	points int
+}
+
+// gradientResult describes the gradient sent to one light
+type gradientResult struct {
+	LightID string          `json:"light_id"`
+	Name    string          `json:"name"`
+	Mode    string          `json:"mode"`
+	Points  []gradientPoint `json:"points"`
+	Error   string          `json:"error,omitempty"`
+	Warning string          `json:"warning,omitempty"`
+}
+
+// gradientPoint is one gradient point in readable and bridge form
+type gradientPoint struct {
+	Hex string            `json:"hex"`
+	XY  resources.ColorXY `json:"xy"`
+}
+
+// RegisterGradientTools registers the tools that build gradients for
+// gradient-capable lights
+func RegisterGradientTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// set_gradient tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "set_gradient",
+			Description: "Set a gradient on gradient-capable lights (lightstrips, gradient bulbs and play bars) from named or hex colors or a palette. Colors are blended in a perceptual color space (OKLab) to as many points as each light supports. With several lights, one gradient can be spread across them in the order given, so it flows from strip to strip.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Gradient-capable lights, in order (IDs, names, \"Room/Light\" paths, or aliases)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"colors": map[string]interface{}{
+						"type":        "array",
+						"description": "Colors from start to end, as hex codes (#ff8800) or names",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"palette": map[string]interface{}{
+						"type":        "string",
+						"description": "Named palette to use instead of colors",
+						"enum":        paletteNames(),
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "interpolated_palette blends the points along the light (default); interpolated_palette_mirrored blends them out from the middle to both ends; random_pixelated scatters the colors in random pixels without blending",
+						"enum":        gradientModes,
+					},
+					"points": map[string]interface{}{
+						"type":        "integer",
+						"description": "Use fewer points than the light supports",
+						"minimum":     minGradientPoints,
+					},
+					"spread": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Spread one gradient across all lights in order instead of giving each light the whole gradient (default true). Ignored for random_pixelated.",
+					},
+					"brightness": map[string]interface{}{
+						"type":        "number",
+						"description": "Brightness percentage to set with the gradient",
+						"minimum":     1,
+						"maximum":     100,
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"lights"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			colors, err := colorsFromArgs(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(colors) == 0 {
+				return mcp.NewToolResultError("colors or palette is required"), nil
+			}
+
+			mode := request.GetString("mode", gradientInterpolated)
+			if !containsString(gradientModes, mode) {
+				return mcp.NewToolResultError(fmt.Sprintf("mode must be one of %s", strings.Join(gradientModes, ", "))), nil
+			}
+			maxPoints := request.GetInt("points", 0)
+			if maxPoints != 0 && maxPoints < minGradientPoints {
+				return mcp.NewToolResultError(fmt.Sprintf("points must be at least %d", minGradientPoints)), nil
+			}
+
+			lights, err := resolveGradientLights(ctx, res, request, mode, maxPoints)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			spread := request.GetBool("spread", true) && len(lights) > 1 && mode != gradientPixelated
+			points := buildGradients(colors, mode, lights, spread)
+
+			results := make([]gradientResult, len(lights))
+			updates := make([]resources.LightUpdate, len(lights))
+			for i, gl := range lights {
+				update := resources.LightUpdate{
+					On:       &resources.OnState{On: true},
+					Gradient: &resources.Gradient{Mode: mode},
+				}
+				if request.GetFloat("brightness", 0) > 0 {
+					update.Dimming = &resources.Dimming{Brightness: request.GetFloat("brightness", 0)}
+				}
+
+				result := gradientResult{LightID: gl.target.ID, Name: gl.target.Name, Mode: mode}
+				for _, c := range points[i] {
+					xy, _ := c.XY()
+					update.Gradient.Points = append(update.Gradient.Points, resources.GradientPoint{Color: resources.Color{XY: xy}})
+					result.Points = append(result.Points, gradientPoint{Hex: c.Hex(), XY: xy})
+				}
+
+				updates[i] = update
+				results[i] = result
+			}
+
+			report := newDryRun()
+			for i, gl := range lights {
+				report.Commands = append(report.Commands, dryRunCommand{BridgeID: gl.target.BridgeID, Method: "lights.update", TargetID: gl.target.ID, Payload: updates[i]})
+				report.predictLight(ctx, gl.target.Bridge, gl.target.ID, updates[i])
+			}
+			report.Plan = results
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			failed := 0
+			for i, gl := range lights {
+				br := gl.target.Bridge
+				if busyLights(ctx, br)[gl.target.ID] {
+					results[i].Error = "busy installing a software update"
+					failed++
+					continue
+				}
+
+				err := br.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+					return br.CachedClient.Lights().Update(ctx, gl.target.ID, updates[i])
+				})
+				if err != nil {
+					results[i].Error = err.Error()
+					failed++
+					continue
+				}
+				if br.UnreachableLights(ctx)[gl.target.ID] {
+					results[i].Warning = "accepted the update but is unreachable"
+				}
+			}
+
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal results: %v", err)), nil
+			}
+
+			summary := fmt.Sprintf("✅ Gradient set on %d light(s)", len(lights))
+			if failed > 0 {
+				summary = fmt.Sprintf("⚠️ Gradient set on %d of %d light(s)", len(lights)-failed, len(lights))
+			}
+			return mcp.NewToolResultText(summary + "\n\n" + string(data)), nil
+		},
+	)
+}
+
+// resolveGradientLights resolves the lights argument to gradient-capable
+// lights and the number of points to build for each
+func resolveGradientLights(ctx context.Context, res *resolver.Resolver, request mcp.CallToolRequest, mode string, maxPoints int) ([]gradientLight, error) {
+	queries := request.GetStringSlice("lights", nil)
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("at least one light is required")
+	}
+	bridgeID := request.GetString("bridge_id", "")
+
+	lights := make([]gradientLight, 0, len(queries))
+	for _, query := range queries {
+		target, err := res.Resolve(ctx, resolver.KindLight, query, bridgeID)
+		if err != nil {
+			return nil, err
+		}
+
+		light, err := target.Bridge.CachedClient.Lights().Get(ctx, target.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getting light %s: %w", target.Label(), err)
+		}
+		if light.Gradient == nil {
+			return nil, fmt.Errorf("light %s does not support gradients; use control_light with color_xy instead", target.Label())
+		}
+		if len(light.Gradient.ModeValues) > 0 && !containsString(light.Gradient.ModeValues, mode) {
+			return nil, fmt.Errorf("light %s does not support gradient mode %s (supported: %s)", target.Label(), mode, strings.Join(light.Gradient.ModeValues, ", "))
+		}
+
+		points := light.Gradient.PointsCapable
+		if points == 0 {
+			points = defaultGradientPoints
+		}
+		if maxPoints > 0 {
+			points = min(points, maxPoints)
+		}
+
+		lights = append(lights, gradientLight{target: target, light: light, points: points})
+	}
+
+	return lights, nil
+}
+
+// buildGradients returns the colors of each light's gradient points.
+// Interpolated modes blend the colors to the points of each light, or to
+// the points of all lights together when spreading so the gradient flows
+// from one light into the next. Pixelated mode uses the colors as they
+// are, as many as each light has points.
+func buildGradients(colors []color.RGB, mode string, lights []gradientLight, spread bool) [][]color.RGB {
+	points := make([][]color.RGB, len(lights))
+
+	if mode == gradientPixelated {
+		for i, gl := range lights {
+			points[i] = pickColors(colors, gl.points)
+		}
+		return points
+	}
+
+	if !spread {
+		for i, gl := range lights {
+			points[i] = color.Interpolate(colors, gl.points)
+		}
+		return points
+	}
+
+	total := 0
+	for _, gl := range lights {
+		total += gl.points
+	}
+	all := color.Interpolate(colors, total)
+	for i, gl := range lights {
+		points[i], all = all[:gl.points], all[gl.points:]
+	}
+
+	return points
+}
+
+// pickColors chooses n colors spread evenly through a list, repeating the
+// list when it is shorter than n
+func pickColors(colors []color.RGB, n int) []color.RGB {
+	picked := make([]color.RGB, max(minGradientPoints, min(n, len(colors))))
+	for i := range picked {
+		if len(colors) > len(picked) {
+			picked[i] = colors[i*(len(colors)-1)/max(1, len(picked)-1)]
+		} else {
+			picked[i] = colors[i%len(colors)]
+		}
+	}
+	return picked
+}
@@ -8,11 +8,13 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
 	"github.com/rmrfslashbin/hue-sdk/resources"
 )
 
 // RegisterGroupedLightTools registers all grouped light (room/zone) control tools
-func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
+func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
 	// list_grouped_lights tool
 	s.AddTool(
 		mcp.Tool{
@@ -43,13 +45,14 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 			}
 
 			type groupedLightInfo struct {
-				BridgeID   string  `json:"bridge_id"`
-				BridgeName string  `json:"bridge_name"`
-				ID         string  `json:"id"`
-				OwnerID    string  `json:"owner_id"`
-				OwnerType  string  `json:"owner_type"`
-				On         bool    `json:"on"`
-				Brightness float64 `json:"brightness,omitempty"`
+				BridgeID          string  `json:"bridge_id"`
+				BridgeName        string  `json:"bridge_name"`
+				ID                string  `json:"id"`
+				OwnerID           string  `json:"owner_id"`
+				OwnerType         string  `json:"owner_type"`
+				On                bool    `json:"on"`
+				Brightness        float64 `json:"brightness,omitempty"`
+				UnreachableLights int     `json:"unreachable_lights,omitempty"`
 			}
 
 			var allGroupedLights []groupedLightInfo
@@ -63,6 +66,7 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				if err != nil {
 					continue
 				}
+				unreachable := br.UnreachableLights(ctx)
 
 				for _, gl := range groupedLights {
 					info := groupedLightInfo{
@@ -76,6 +80,10 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 					if gl.Dimming != nil {
 						info.Brightness = gl.Dimming.Brightness
 					}
+					if len(unreachable) > 0 {
+						lightIDs, _ := groupLightIDs(ctx, br, gl.Owner)
+						info.UnreachableLights = countIn(lightIDs, unreachable)
+					}
 					allGroupedLights = append(allGroupedLights, info)
 				}
 			}
@@ -99,35 +107,23 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				Properties: map[string]interface{}{
 					"grouped_light_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The grouped light ID",
+						"description": "The grouped light ID, or the name or ID of the room/zone that owns it",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 				},
 				Required: []string{"grouped_light_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			groupedLightID, err := request.RequireString("grouped_light_id")
-			if err != nil {
-				return mcp.NewToolResultError("grouped_light_id is required"), nil
-			}
-
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
+			target, err := resolveArg(ctx, res, request, "grouped_light_id", resolver.KindGroupedLight)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			groupedLight, err := br.CachedClient.GroupedLights().Get(ctx, groupedLightID)
+			groupedLight, err := target.Bridge.CachedClient.GroupedLights().Get(ctx, target.ID)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to get grouped light: %v", err)), nil
 			}
@@ -145,17 +141,29 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 	s.AddTool(
 		mcp.Tool{
 			Name:        "control_room_lights",
-			Description: "Control all lights in a room or zone simultaneously. All lights will receive the same settings. Uses the grouped_light resource.",
+			Description: "Control all lights in a room or zone simultaneously. All lights will receive the same settings. Target by room name, zone name, room/zone ID, or grouped light ID. Names are matched case-insensitively across all bridges.",
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Room name (e.g., 'Living Room'). Case-insensitive",
+					},
+					"zone": map[string]interface{}{
+						"type":        "string",
+						"description": "Zone name (e.g., 'Downstairs'). Case-insensitive",
+					},
+					"room_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Room or zone ID",
+					},
 					"grouped_light_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The grouped light ID (get from room details or list_grouped_lights)",
+						"description": "The grouped light ID, or the name of the room/zone that owns it",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Required only when a room or zone name exists on more than one bridge",
 					},
 					"on": map[string]interface{}{
 						"type":        "boolean",
@@ -195,24 +203,13 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 						"description": "Trigger alert effect on all lights",
 						"enum":        []string{"breathe"},
 					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
 				},
-				Required: []string{"grouped_light_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			groupedLightID, err := request.RequireString("grouped_light_id")
-			if err != nil {
-				return mcp.NewToolResultError("grouped_light_id is required"), nil
-			}
-
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
+			target, err := resolveGroupTarget(ctx, res, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -269,11 +266,37 @@ func RegisterGroupedLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				}
 			}
 
-			if err := br.CachedClient.GroupedLights().Update(ctx, groupedLightID, update); err != nil {
+			report := newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "grouped_lights.update", TargetID: target.ID, Payload: update})
+			lightIDs, err := groupedLightMembers(ctx, target.Bridge, target.ID)
+			if err != nil {
+				report.Warnings = append(report.Warnings, err.Error())
+			}
+			report.predictLights(ctx, target.Bridge, lightIDs, groupedLightUpdateAsLight(update))
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.GroupedLights().Update(ctx, target.ID, update)
+			})
+			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to control room lights: %v", err)), nil
 			}
 
-			return mcp.NewToolResultText(fmt.Sprintf("✅ All lights in group %s updated successfully", groupedLightID)), nil
+			if busy := busyLights(ctx, target.Bridge); len(busy) > 0 {
+				lightIDs, _ := groupedLightMembers(ctx, target.Bridge, target.ID)
+				var skipped int
+				for _, id := range lightIDs {
+					if busy[id] {
+						skipped++
+					}
+				}
+				if skipped > 0 {
+					return mcp.NewToolResultText(fmt.Sprintf("⚠️ Lights in group %s updated, but %d of them are busy installing a software update and will not change until it finishes", target.Label(), skipped)), nil
+				}
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ All lights in group %s updated successfully", target.Label())), nil
 		},
 	)
 }
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/health"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// offlineDevice is a device whose zigbee connectivity is not connected
+type offlineDevice struct {
+	BridgeID string   `json:"bridge_id"`
+	DeviceID string   `json:"device_id"`
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Lights   []string `json:"lights,omitempty"`
+}
+
+// batteryInfo is the battery of a device
+type batteryInfo struct {
+	BridgeID string `json:"bridge_id"`
+	DeviceID string `json:"device_id"`
+	Name     string `json:"name"`
+	Level    int    `json:"level"`
+	State    string `json:"state,omitempty"`
+}
+
+// flappingDevice is a device that lost and regained connectivity repeatedly
+type flappingDevice struct {
+	BridgeID    string              `json:"bridge_id"`
+	DeviceID    string              `json:"device_id"`
+	Name        string              `json:"name"`
+	Changes     int                 `json:"changes"`
+	Transitions []health.Transition `json:"transitions"`
+}
+
+// healthReport is the health of all devices on the selected bridges
+type healthReport struct {
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Devices        int              `json:"devices"`
+	OfflineLights  []offlineDevice  `json:"offline_lights"`
+	OfflineDevices []offlineDevice  `json:"offline_devices"`
+	LowBatteries   []batteryInfo    `json:"low_batteries"`
+	Flapping       []flappingDevice `json:"flapping"`
+	Disconnected   []string         `json:"disconnected_bridges,omitempty"`
+}
+
+// RegisterHealthTools registers the health report tool
+func RegisterHealthTools(s *server.MCPServer, bm *bridge.Manager) {
+	// get_health_report tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_health_report",
+			Description: "Report device health: lights and other devices that are offline (zigbee connectivity), low batteries, and devices that have been flapping between connected and disconnected recently",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, reports on all bridges",
+					},
+					"battery_threshold": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Battery percentage at or below which a battery is low (default %d)", health.LowBattery),
+						"minimum":     0,
+						"maximum":     100,
+					},
+					"flap_minutes": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("How many minutes back connectivity changes count as flapping (default %d)", int(health.FlapWindow.Minutes())),
+						"minimum":     1,
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+			threshold := request.GetInt("battery_threshold", health.LowBattery)
+			window := time.Duration(request.GetFloat("flap_minutes", health.FlapWindow.Minutes()) * float64(time.Minute))
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			report := healthReport{
+				GeneratedAt:    time.Now(),
+				OfflineLights:  []offlineDevice{},
+				OfflineDevices: []offlineDevice{},
+				LowBatteries:   []batteryInfo{},
+				Flapping:       []flappingDevice{},
+			}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					report.Disconnected = append(report.Disconnected, br.ID)
+					continue
+				}
+
+				devices, err := br.CachedClient.Devices().List(ctx)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to list devices on bridge %s: %v", br.ID, err)), nil
+				}
+				report.Devices += len(devices)
+
+				connectivity := br.Connectivity(ctx)
+				batteries := batteriesOf(ctx, br)
+				tracker := bm.Health()
+
+				for _, device := range devices {
+					if status, ok := connectivity[device.ID]; ok && status != "connected" {
+						offline := offlineDevice{
+							BridgeID: br.ID,
+							DeviceID: device.ID,
+							Name:     device.Metadata.Name,
+							Status:   status,
+						}
+						for _, service := range device.Services {
+							if service.RType == "light" {
+								offline.Lights = append(offline.Lights, service.RID)
+							}
+						}
+						if len(offline.Lights) > 0 {
+							report.OfflineLights = append(report.OfflineLights, offline)
+						} else {
+							report.OfflineDevices = append(report.OfflineDevices, offline)
+						}
+					}
+
+					if power, ok := batteries[device.ID]; ok && (power.BatteryLevel <= threshold || power.BatteryState == "critical") {
+						report.LowBatteries = append(report.LowBatteries, batteryInfo{
+							BridgeID: br.ID,
+							DeviceID: device.ID,
+							Name:     device.Metadata.Name,
+							Level:    power.BatteryLevel,
+							State:    power.BatteryState,
+						})
+					}
+
+					transitions := tracker.Transitions(br.ID, device.ID, time.Now().Add(-window))
+					if len(transitions) >= health.FlapThreshold {
+						report.Flapping = append(report.Flapping, flappingDevice{
+							BridgeID:    br.ID,
+							DeviceID:    device.ID,
+							Name:        device.Metadata.Name,
+							Changes:     len(transitions),
+							Transitions: transitions,
+						})
+					}
+				}
+			}
+
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal health report: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// batteriesOf maps the battery-powered devices on a bridge to their power state
+func batteriesOf(ctx context.Context, br *bridge.Bridge) map[string]resources.PowerState {
+	batteries := make(map[string]resources.PowerState)
+
+	powers, err := br.CachedClient.DevicePower().List(ctx)
+	if err != nil {
+		return batteries
+	}
+
+	for _, power := range powers {
+		if power.PowerState.BatteryState == "" && power.PowerState.BatteryLevel == 0 {
+			continue
+		}
+		batteries[power.Owner.RID] = power.PowerState
+	}
+
+	return batteries
+}
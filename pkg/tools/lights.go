@@ -8,11 +8,13 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
 	"github.com/rmrfslashbin/hue-sdk/resources"
 )
 
 // RegisterLightTools registers all light-related tools
-func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
+func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
 	// list_lights tool
 	s.AddTool(
 		mcp.Tool{
@@ -43,13 +45,14 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 			}
 
 			type lightInfo struct {
-				BridgeID   string  `json:"bridge_id"`
-				BridgeName string  `json:"bridge_name"`
-				ID         string  `json:"id"`
-				Name       string  `json:"name"`
-				On         bool    `json:"on"`
-				Brightness float64 `json:"brightness,omitempty"`
-				Type       string  `json:"type"`
+				BridgeID    string  `json:"bridge_id"`
+				BridgeName  string  `json:"bridge_name"`
+				ID          string  `json:"id"`
+				Name        string  `json:"name"`
+				On          bool    `json:"on"`
+				Brightness  float64 `json:"brightness,omitempty"`
+				Type        string  `json:"type"`
+				Unreachable bool    `json:"unreachable,omitempty"`
 			}
 
 			var allLights []lightInfo
@@ -64,14 +67,17 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 					continue
 				}
 
+				unreachable := br.UnreachableDevices(ctx)
+
 				for _, light := range lights {
 					info := lightInfo{
-						BridgeID:   br.ID,
-						BridgeName: br.Name,
-						ID:         light.ID,
-						Name:       light.Metadata.Name,
-						On:         light.On.On,
-						Type:       light.Type,
+						BridgeID:    br.ID,
+						BridgeName:  br.Name,
+						ID:          light.ID,
+						Name:        light.Metadata.Name,
+						On:          light.On.On,
+						Type:        light.Type,
+						Unreachable: unreachable[light.Owner.RID],
 					}
 					if light.Dimming != nil {
 						info.Brightness = light.Dimming.Brightness
@@ -99,35 +105,23 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				Properties: map[string]interface{}{
 					"light_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The light ID",
+						"description": "The light ID, name, \"Room/Light\" path, or alias",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 				},
 				Required: []string{"light_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			lightID, err := request.RequireString("light_id")
-			if err != nil {
-				return mcp.NewToolResultError("light_id is required"), nil
-			}
-
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
+			target, err := resolveArg(ctx, res, request, "light_id", resolver.KindLight)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			light, err := br.CachedClient.Lights().Get(ctx, lightID)
+			light, err := target.Bridge.CachedClient.Lights().Get(ctx, target.ID)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to get light: %v", err)), nil
 			}
@@ -151,11 +145,11 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				Properties: map[string]interface{}{
 					"light_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The light ID",
+						"description": "The light ID, name, \"Room/Light\" path, or alias",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 					"on": map[string]interface{}{
 						"type":        "boolean",
@@ -213,7 +207,7 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 					},
 					"gradient": map[string]interface{}{
 						"type":        "array",
-						"description": "Gradient color points (for lightstrips). Array of XY color coordinates",
+						"description": "Gradient color points (for lightstrips). Array of XY color coordinates. set_gradient builds these from colors and knows each light's point count and modes",
 						"items": map[string]interface{}{
 							"type": "object",
 							"properties": map[string]interface{}{
@@ -231,141 +225,153 @@ func RegisterLightTools(s *server.MCPServer, bm *bridge.Manager) {
 							"required": []string{"x", "y"},
 						},
 					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
 				},
 				Required: []string{"light_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			lightID, err := request.RequireString("light_id")
+			target, err := resolveArg(ctx, res, request, "light_id", resolver.KindLight)
 			if err != nil {
-				return mcp.NewToolResultError("light_id is required"), nil
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+			if busyLights(ctx, target.Bridge)[target.ID] {
+				return mcp.NewToolResultError(fmt.Sprintf("Light %s is busy installing a software update; try again once get_software_updates no longer reports it as installing", target.Label())), nil
 			}
 
-			// Build update request
-			update := resources.LightUpdate{}
-			args := request.GetArguments()
+			update := lightUpdateFromArgs(request.GetArguments())
 
-			// On/Off
-			if onVal, ok := args["on"]; ok {
-				if on, ok := onVal.(bool); ok {
-					update.On = &resources.OnState{On: on}
-				}
+			report := newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "lights.update", TargetID: target.ID, Payload: update})
+			report.predictLight(ctx, target.Bridge, target.ID, update)
+			if result, done := report.preflight(ctx); done {
+				return result, nil
 			}
 
-			// Brightness
-			if brightnessVal, ok := args["brightness"]; ok {
-				if brightness, ok := brightnessVal.(float64); ok {
-					update.Dimming = &resources.Dimming{Brightness: brightness}
-				}
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Lights().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to control light: %v", err)), nil
 			}
 
-			// Color (XY coordinates)
-			if colorXYVal, ok := args["color_xy"]; ok {
-				if colorMap, ok := colorXYVal.(map[string]interface{}); ok {
-					x, xOk := colorMap["x"].(float64)
-					y, yOk := colorMap["y"].(float64)
-					if xOk && yOk {
-						update.Color = &resources.Color{
-							XY: resources.ColorXY{
-								X: x,
-								Y: y,
-							},
-						}
-					}
-				}
+			if target.Bridge.UnreachableLights(ctx)[target.ID] {
+				return mcp.NewToolResultText(fmt.Sprintf("⚠️ Light %s accepted the update but is unreachable, so it will not change until it reconnects. Check its power or wall switch; get_health_report lists all offline devices.", target.Label())), nil
 			}
 
-			// Color Temperature
-			if colorTempVal, ok := args["color_temp"]; ok {
-				if colorTemp, ok := colorTempVal.(float64); ok {
-					update.ColorTemperature = &resources.ColorTemperature{
-						Mirek: int(colorTemp),
-					}
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Light %s updated successfully", target.Label())), nil
+		},
+	)
+}
+
+// lightUpdateFromArgs builds a light update from control_light style arguments
+func lightUpdateFromArgs(args map[string]interface{}) resources.LightUpdate {
+	update := resources.LightUpdate{}
+
+	// On/Off
+	if onVal, ok := args["on"]; ok {
+		if on, ok := onVal.(bool); ok {
+			update.On = &resources.OnState{On: on}
+		}
+	}
+
+	// Brightness
+	if brightnessVal, ok := args["brightness"]; ok {
+		if brightness, ok := brightnessVal.(float64); ok {
+			update.Dimming = &resources.Dimming{Brightness: brightness}
+		}
+	}
+
+	// Color (XY coordinates)
+	if colorXYVal, ok := args["color_xy"]; ok {
+		if colorMap, ok := colorXYVal.(map[string]interface{}); ok {
+			x, xOk := colorMap["x"].(float64)
+			y, yOk := colorMap["y"].(float64)
+			if xOk && yOk {
+				update.Color = &resources.Color{
+					XY: resources.ColorXY{
+						X: x,
+						Y: y,
+					},
 				}
 			}
+		}
+	}
+
+	// Color Temperature
+	if colorTempVal, ok := args["color_temp"]; ok {
+		if colorTemp, ok := colorTempVal.(float64); ok {
+			update.ColorTemperature = &resources.ColorTemperature{
+				Mirek: int(colorTemp),
+			}
+		}
+	}
+
+	// Effects
+	if effectVal, ok := args["effect"]; ok {
+		if effect, ok := effectVal.(string); ok {
+			update.Effects = &resources.EffectsUpdate{
+				Effect: effect,
+			}
+		}
+	}
+
+	// Timed Effects
+	if timedEffectVal, ok := args["timed_effect"]; ok {
+		if timedEffect, ok := timedEffectVal.(string); ok {
+			timedEffects := &resources.TimedEffects{
+				Effect: timedEffect,
+			}
 
-			// Effects
-			if effectVal, ok := args["effect"]; ok {
-				if effect, ok := effectVal.(string); ok {
-					update.Effects = &resources.EffectsUpdate{
-						Effect: effect,
-					}
+			// Duration (optional, in seconds - convert to milliseconds)
+			if durationVal, ok := args["timed_effect_duration"]; ok {
+				if duration, ok := durationVal.(float64); ok {
+					durationMs := int(duration * 1000)
+					timedEffects.Duration = &durationMs
 				}
 			}
 
-			// Timed Effects
-			if timedEffectVal, ok := args["timed_effect"]; ok {
-				if timedEffect, ok := timedEffectVal.(string); ok {
-					timedEffects := &resources.TimedEffects{
-						Effect: timedEffect,
-					}
-
-					// Duration (optional, in seconds - convert to milliseconds)
-					if durationVal, ok := args["timed_effect_duration"]; ok {
-						if duration, ok := durationVal.(float64); ok {
-							durationMs := int(duration * 1000)
-							timedEffects.Duration = &durationMs
-						}
-					}
+			update.TimedEffects = timedEffects
+		}
+	}
 
-					update.TimedEffects = timedEffects
-				}
+	// Alert
+	if alertVal, ok := args["alert"]; ok {
+		if alert, ok := alertVal.(string); ok {
+			update.Alert = &resources.AlertAction{
+				Action: alert,
 			}
-
-			// Alert
-			if alertVal, ok := args["alert"]; ok {
-				if alert, ok := alertVal.(string); ok {
-					update.Alert = &resources.AlertAction{
-						Action: alert,
+		}
+	}
+
+	// Gradient (for lightstrips)
+	if gradientVal, ok := args["gradient"]; ok {
+		if gradientArray, ok := gradientVal.([]interface{}); ok {
+			var points []resources.GradientPoint
+			for _, point := range gradientArray {
+				if pointMap, ok := point.(map[string]interface{}); ok {
+					x, xOk := pointMap["x"].(float64)
+					y, yOk := pointMap["y"].(float64)
+					if xOk && yOk {
+						points = append(points, resources.GradientPoint{
+							Color: resources.Color{
+								XY: resources.ColorXY{
+									X: x,
+									Y: y,
+								},
+							},
+						})
 					}
 				}
 			}
-
-			// Gradient (for lightstrips)
-			if gradientVal, ok := args["gradient"]; ok {
-				if gradientArray, ok := gradientVal.([]interface{}); ok {
-					var points []resources.GradientPoint
-					for _, point := range gradientArray {
-						if pointMap, ok := point.(map[string]interface{}); ok {
-							x, xOk := pointMap["x"].(float64)
-							y, yOk := pointMap["y"].(float64)
-							if xOk && yOk {
-								points = append(points, resources.GradientPoint{
-									Color: resources.Color{
-										XY: resources.ColorXY{
-											X: x,
-											Y: y,
-										},
-									},
-								})
-							}
-						}
-					}
-					if len(points) > 0 {
-						update.Gradient = &resources.Gradient{
-							Points: points,
-						}
-					}
+			if len(points) > 0 {
+				update.Gradient = &resources.Gradient{
+					Points: points,
 				}
 			}
+		}
+	}
 
-			if err := br.CachedClient.Lights().Update(ctx, lightID, update); err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to control light: %v", err)), nil
-			}
-
-			return mcp.NewToolResultText(fmt.Sprintf("✅ Light %s updated successfully", lightID)), nil
-		},
-	)
+	return update
 }
@@ -2,27 +2,34 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/planner"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
 	"github.com/rmrfslashbin/hue-sdk/resources"
 )
 
 // RegisterBulkLightTools registers bulk/multi-light control tools
-func RegisterBulkLightTools(s *server.MCPServer, bm *bridge.Manager) {
+func RegisterBulkLightTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
 	// control_lights tool (plural) - control multiple lights in one call
 	s.AddTool(
 		mcp.Tool{
 			Name:        "control_lights",
-			Description: "Control multiple lights in a single call. Each light can have different settings (color, brightness, etc). Useful for setting a room to varying colors/brightness levels.",
+			Description: "Control multiple lights in a single call, across any number of bridges. Each light can have different settings (color, brightness, etc). Lights are routed to their own bridge automatically and sent concurrently within each bridge's rate limit. Returns JSON with the status, latency and any error for each light. Useful for setting a room or the whole house to varying colors/brightness levels.",
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Lights are routed to their own bridge automatically, so this is only needed to disambiguate names",
 					},
 					"lights": map[string]interface{}{
 						"type":        "array",
@@ -32,7 +39,11 @@ func RegisterBulkLightTools(s *server.MCPServer, bm *bridge.Manager) {
 							"properties": map[string]interface{}{
 								"light_id": map[string]interface{}{
 									"type":        "string",
-									"description": "The light ID",
+									"description": "The light ID, name, \"Room/Light\" path, or alias",
+								},
+								"bridge_id": map[string]interface{}{
+									"type":        "string",
+									"description": "Optional bridge for this light. Overrides the top-level bridge_id",
 								},
 								"on": map[string]interface{}{
 									"type":        "boolean",
@@ -112,20 +123,21 @@ func RegisterBulkLightTools(s *server.MCPServer, bm *bridge.Manager) {
 							"required": []string{"light_id"},
 						},
 					},
+					"optimize": map[string]interface{}{
+						"type":        "string",
+						"description": "How to send the updates. 'groups' (default) uses a room/zone grouped light for a state most of its lights share, avoiding the popcorn effect. 'scenes' also recalls a temporary scene for rooms with many distinct states. 'none' sends one command per light",
+						"enum":        []string{"groups", "scenes", "none"},
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
 				},
 				Required: []string{"lights"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-			var err error
 
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
+			mode, err := planner.ParseMode(request.GetString("optimize", string(planner.ModeGroups)))
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -136,146 +148,384 @@ func RegisterBulkLightTools(s *server.MCPServer, bm *bridge.Manager) {
 				return mcp.NewToolResultError("lights parameter must be an array"), nil
 			}
 
-			var results []string
-			var failures []string
-
-			for _, lightItem := range lightsArray {
+			// Resolve every light up front so the batch can be split by bridge
+			items := make([]bulkItem, len(lightsArray))
+			busy := make(map[string]map[string]bool)
+			for i, lightItem := range lightsArray {
 				lightConfig, ok := lightItem.(map[string]interface{})
 				if !ok {
-					failures = append(failures, "invalid light configuration")
+					items[i].err = fmt.Errorf("invalid light configuration")
 					continue
 				}
 
 				lightID, ok := lightConfig["light_id"].(string)
 				if !ok || lightID == "" {
-					failures = append(failures, "missing light_id")
+					items[i].err = fmt.Errorf("missing light_id")
 					continue
 				}
+				items[i].query = lightID
 
-				// Build update for this light
-				update := resources.LightUpdate{}
-
-				// On/Off
-				if onVal, ok := lightConfig["on"]; ok {
-					if on, ok := onVal.(bool); ok {
-						update.On = &resources.OnState{On: on}
-					}
+				itemBridgeID := bridgeID
+				if id, ok := lightConfig["bridge_id"].(string); ok && id != "" {
+					itemBridgeID = id
 				}
 
-				// Brightness
-				if brightnessVal, ok := lightConfig["brightness"]; ok {
-					if brightness, ok := brightnessVal.(float64); ok {
-						update.Dimming = &resources.Dimming{Brightness: brightness}
-					}
+				target, err := res.Resolve(ctx, resolver.KindLight, lightID, itemBridgeID)
+				if err != nil {
+					items[i].err = err
+					continue
 				}
 
-				// Color (XY coordinates)
-				if colorXYVal, ok := lightConfig["color_xy"]; ok {
-					if colorMap, ok := colorXYVal.(map[string]interface{}); ok {
-						x, xOk := colorMap["x"].(float64)
-						y, yOk := colorMap["y"].(float64)
-						if xOk && yOk {
-							update.Color = &resources.Color{
-								XY: resources.ColorXY{
-									X: x,
-									Y: y,
-								},
-							}
-						}
-					}
+				// Lights installing a software update are skipped, not sent
+				if _, ok := busy[target.BridgeID]; !ok {
+					busy[target.BridgeID] = busyLights(ctx, target.Bridge)
 				}
-
-				// Color Temperature
-				if colorTempVal, ok := lightConfig["color_temp"]; ok {
-					if colorTemp, ok := colorTempVal.(float64); ok {
-						update.ColorTemperature = &resources.ColorTemperature{
-							Mirek: int(colorTemp),
-						}
-					}
+				if busy[target.BridgeID][target.ID] {
+					items[i].err = fmt.Errorf("light %s is busy installing a software update", target.Label())
+					continue
 				}
 
-				// Effects
-				if effectVal, ok := lightConfig["effect"]; ok {
-					if effect, ok := effectVal.(string); ok {
-						update.Effects = &resources.EffectsUpdate{
-							Effect: effect,
-						}
-					}
-				}
+				items[i].target = target
+				items[i].update = lightUpdateFromArgs(lightConfig)
+			}
 
-				// Timed Effects
-				if timedEffectVal, ok := lightConfig["timed_effect"]; ok {
-					if timedEffect, ok := timedEffectVal.(string); ok {
-						timedEffects := &resources.TimedEffects{
-							Effect: timedEffect,
-						}
-
-						if durationVal, ok := lightConfig["timed_effect_duration"]; ok {
-							if duration, ok := durationVal.(float64); ok {
-								durationMs := int(duration * 1000)
-								timedEffects.Duration = &durationMs
-							}
-						}
-
-						update.TimedEffects = timedEffects
-					}
-				}
+			start := time.Now()
+			plan, err := planBulkUpdates(ctx, items, mode)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to plan updates: %v", err)), nil
+			}
 
-				// Alert
-				if alertVal, ok := lightConfig["alert"]; ok {
-					if alert, ok := alertVal.(string); ok {
-						update.Alert = &resources.AlertAction{
-							Action: alert,
-						}
-					}
-				}
+			if result, done := planDryRun(ctx, plan, items).preflight(ctx); done {
+				return result, nil
+			}
 
-				// Gradient
-				if gradientVal, ok := lightConfig["gradient"]; ok {
-					if gradientArray, ok := gradientVal.([]interface{}); ok {
-						var points []resources.GradientPoint
-						for _, point := range gradientArray {
-							if pointMap, ok := point.(map[string]interface{}); ok {
-								x, xOk := pointMap["x"].(float64)
-								y, yOk := pointMap["y"].(float64)
-								if xOk && yOk {
-									points = append(points, resources.GradientPoint{
-										Color: resources.Color{
-											XY: resources.ColorXY{
-												X: x,
-												Y: y,
-											},
-										},
-									})
-								}
-							}
-						}
-						if len(points) > 0 {
-							update.Gradient = &resources.Gradient{
-								Points: points,
-							}
-						}
-					}
-				}
+			planResult := executePlan(ctx, plan, items)
+			report := newBulkReport(ctx, items, time.Since(start))
+			report.Plan = planResult
+
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal results: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// bulkItem is one light in a control_lights batch
+type bulkItem struct {
+	query   string
+	target  *resolver.Entry
+	update  resources.LightUpdate
+	err     error
+	sent    bool
+	latency time.Duration
+}
+
+// bulkResult is the outcome for one light of a bulk request
+type bulkResult struct {
+	Light     string `json:"light"`
+	LightID   string `json:"light_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	BridgeID  string `json:"bridge_id,omitempty"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// bulkReport is the structured result of a bulk request
+type bulkReport struct {
+	Succeeded   int          `json:"succeeded"`
+	Failed      int          `json:"failed"`
+	Unreachable int          `json:"unreachable"`
+	Bridges     int          `json:"bridges"`
+	DurationMS  int64        `json:"duration_ms"`
+	Results     []bulkResult `json:"results"`
+	Plan        *planReport  `json:"plan,omitempty"`
+}
+
+// stepReport is the outcome of one plan step
+type stepReport struct {
+	planner.Step
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// planReport describes how a bulk update was sent
+type planReport struct {
+	Mode          planner.Mode `json:"mode"`
+	Commands      int          `json:"commands"`
+	NaiveCommands int          `json:"naive_commands"`
+	Steps         []stepReport `json:"steps"`
+}
+
+// planBulkUpdates plans the commands for all resolved items
+func planBulkUpdates(ctx context.Context, items []bulkItem, mode planner.Mode) (planner.Plan, error) {
+	var planItems []planner.Item
+	bridges := make(map[string]*bridge.Bridge)
+	for _, item := range items {
+		if item.err != nil {
+			continue
+		}
+		planItems = append(planItems, planner.Item{
+			LightID:  item.target.ID,
+			BridgeID: item.target.BridgeID,
+			Update:   item.update,
+		})
+		bridges[item.target.BridgeID] = item.target.Bridge
+	}
+
+	var groups []planner.Group
+	if mode != planner.ModeNone {
+		for _, br := range bridges {
+			bridgeGroups, err := planner.LoadGroups(ctx, br)
+			if err != nil {
+				return planner.Plan{}, fmt.Errorf("loading groups for bridge %s: %w", br.ID, err)
+			}
+			groups = append(groups, bridgeGroups...)
+		}
+	}
+
+	return planner.Build(planItems, groups, mode), nil
+}
+
+// executePlan sends a plan through each bridge's scheduler and records the
+// outcome on every item. Bridges run concurrently; within a bridge, group
+// and scene commands complete before the light commands that refine them.
+func executePlan(ctx context.Context, plan planner.Plan, items []bulkItem) *planReport {
+	report := &planReport{
+		Mode:          plan.Mode,
+		Commands:      plan.Commands(),
+		NaiveCommands: plan.NaiveCommands,
+		Steps:         make([]stepReport, len(plan.Steps)),
+	}
 
-				// Apply update
-				if err := br.CachedClient.Lights().Update(ctx, lightID, update); err != nil {
-					failures = append(failures, fmt.Sprintf("Light %s: %v", lightID, err))
-				} else {
-					results = append(results, lightID)
+	bridges := make(map[string]*bridge.Bridge)
+	byLight := make(map[string][]int)
+	for i, item := range items {
+		if item.err == nil {
+			bridges[item.target.BridgeID] = item.target.Bridge
+			byLight[item.target.ID] = append(byLight[item.target.ID], i)
+		}
+	}
+
+	stepsByBridge := make(map[string][]int)
+	for i, step := range plan.Steps {
+		report.Steps[i] = stepReport{Step: step}
+		stepsByBridge[step.BridgeID] = append(stepsByBridge[step.BridgeID], i)
+	}
+
+	var wg sync.WaitGroup
+	for bridgeID, indexes := range stepsByBridge {
+		br := bridges[bridgeID]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var lightSteps []int
+			for _, i := range indexes {
+				if plan.Steps[i].Kind == planner.StepLight {
+					lightSteps = append(lightSteps, i)
+					continue
 				}
+				runStep(ctx, br, &report.Steps[i])
 			}
 
-			// Build response
-			summary := fmt.Sprintf("✅ Successfully updated %d light(s)", len(results))
-			if len(failures) > 0 {
-				summary += fmt.Sprintf("\n❌ %d failure(s):", len(failures))
-				for _, failure := range failures {
-					summary += fmt.Sprintf("\n  - %s", failure)
+			var stepWG sync.WaitGroup
+			for _, i := range lightSteps {
+				stepWG.Add(1)
+				go func() {
+					defer stepWG.Done()
+					runStep(ctx, br, &report.Steps[i])
+				}()
+			}
+			stepWG.Wait()
+		}()
+	}
+	wg.Wait()
+
+	// A light succeeded only if every step that touched it succeeded
+	for _, step := range report.Steps {
+		for _, lightID := range step.Lights {
+			for _, i := range byLight[lightID] {
+				item := &items[i]
+				if step.Status != "cancelled" {
+					item.sent = true
+				}
+				if latency := time.Duration(step.LatencyMS) * time.Millisecond; latency > item.latency {
+					item.latency = latency
+				}
+				if step.Error != "" && item.err == nil {
+					item.err = fmt.Errorf("%s %s: %s", step.Kind, step.TargetID, step.Error)
 				}
 			}
+		}
+	}
 
-			return mcp.NewToolResultText(summary), nil
-		},
-	)
+	return report
+}
+
+// runStep sends one plan step and records its status
+func runStep(ctx context.Context, br *bridge.Bridge, step *stepReport) {
+	kind := scheduler.LightCommand
+	if step.Kind != planner.StepLight {
+		kind = scheduler.GroupCommand
+	}
+
+	var latency time.Duration
+	sent := false
+	err := br.Scheduler.Do(ctx, kind, func(ctx context.Context) error {
+		sent = true
+		start := time.Now()
+		defer func() { latency = time.Since(start) }()
+
+		switch step.Kind {
+		case planner.StepGroupedLight:
+			return br.CachedClient.GroupedLights().Update(ctx, step.TargetID, *step.GroupUpdate)
+		case planner.StepScene:
+			return recallTemporaryScene(ctx, br, step.Step)
+		default:
+			return br.CachedClient.Lights().Update(ctx, step.TargetID, *step.LightUpdate)
+		}
+	})
+
+	step.LatencyMS = latency.Milliseconds()
+	switch {
+	case err == nil:
+		step.Status = "ok"
+	case !sent:
+		step.Status = "cancelled"
+		step.Error = err.Error()
+	default:
+		step.Status = "error"
+		step.Error = err.Error()
+	}
+}
+
+// temporarySceneCleanupTimeout bounds deleting a temporary scene after its
+// recall
+const temporarySceneCleanupTimeout = 5 * time.Second
+
+// recallTemporaryScene creates a scene from a plan step, recalls it so all
+// lights change together, and deletes it again
+func recallTemporaryScene(ctx context.Context, br *bridge.Bridge, step planner.Step) error {
+	created, err := br.CachedClient.Scenes().Create(ctx, resources.SceneCreate{
+		Metadata: resources.Metadata{Name: "hue-mcp " + time.Now().Format("150405.000")},
+		Group:    resources.ResourceIdentifier{RID: step.TargetID, RType: step.TargetType},
+		Actions:  step.SceneActions,
+	})
+	if err != nil {
+		return fmt.Errorf("creating temporary scene: %w", err)
+	}
+
+	recallErr := br.CachedClient.Scenes().Update(ctx, created.RID, resources.SceneUpdate{
+		Recall: &resources.SceneRecall{Action: "active"},
+	})
+
+	// Delete the scene even if the request was cancelled meanwhile, so it is
+	// not left behind on the bridge
+	deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), temporarySceneCleanupTimeout)
+	defer cancel()
+	if err := br.CachedClient.Scenes().Delete(deleteCtx, created.RID); err != nil {
+		err = fmt.Errorf("deleting temporary scene %s: %w", created.RID, err)
+		log.Printf("Warning: %v", err)
+		if recallErr == nil {
+			return err
+		}
+	}
+
+	return recallErr
+}
+
+// newBulkReport builds a per-light report in request order. Lights that
+// accepted the update but are unreachable are reported as such, not as ok.
+func newBulkReport(ctx context.Context, items []bulkItem, duration time.Duration) bulkReport {
+	report := bulkReport{
+		DurationMS: duration.Milliseconds(),
+		Results:    make([]bulkResult, len(items)),
+	}
+	bridges := make(map[string]bool)
+	unreachable := make(map[string]map[string]bool)
+
+	for i, item := range items {
+		result := bulkResult{
+			Light:     item.query,
+			LatencyMS: item.latency.Milliseconds(),
+		}
+		if item.target != nil {
+			result.LightID = item.target.ID
+			result.Name = item.target.Name
+			result.BridgeID = item.target.BridgeID
+			bridges[item.target.BridgeID] = true
+		}
+
+		offline := false
+		if item.target != nil && item.err == nil {
+			if _, ok := unreachable[item.target.BridgeID]; !ok {
+				unreachable[item.target.BridgeID] = item.target.Bridge.UnreachableLights(ctx)
+			}
+			offline = unreachable[item.target.BridgeID][item.target.ID]
+		}
+
+		switch {
+		case offline:
+			result.Status = "unreachable"
+			result.Error = "the light accepted the update but is unreachable, so it will not change until it reconnects"
+			report.Unreachable++
+		case item.err == nil:
+			result.Status = "ok"
+			report.Succeeded++
+		case item.target == nil:
+			result.Status = "invalid"
+		case !item.sent:
+			result.Status = "cancelled"
+		default:
+			result.Status = "error"
+		}
+		if item.err != nil {
+			result.Error = item.err.Error()
+			report.Failed++
+		}
+
+		report.Results[i] = result
+	}
+	report.Bridges = len(bridges)
+
+	return report
+}
+
+// planDryRun describes the commands of a plan and the predicted change of
+// every item without sending anything
+func planDryRun(ctx context.Context, plan planner.Plan, items []bulkItem) *dryRunReport {
+	report := newDryRun()
+	report.Plan = plan
+
+	for _, step := range plan.Steps {
+		command := dryRunCommand{BridgeID: step.BridgeID, TargetID: step.TargetID}
+		switch step.Kind {
+		case planner.StepGroupedLight:
+			command.Method = "grouped_lights.update"
+			command.Payload = step.GroupUpdate
+		case planner.StepScene:
+			command.Method = "scenes.create+recall+delete"
+			command.Payload = step.SceneActions
+		default:
+			command.Method = "lights.update"
+			command.Payload = step.LightUpdate
+		}
+		report.Commands = append(report.Commands, command)
+	}
+
+	for _, item := range items {
+		if item.err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", item.query, item.err))
+			continue
+		}
+		report.predictLight(ctx, item.target.Bridge, item.target.ID, item.update)
+	}
+
+	return report
 }
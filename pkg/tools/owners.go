@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+)
+
+// lightOwners records which show, animation or streaming effect drives each
+// light. Each engine keeps its own runs apart; this keeps them apart from
+// each other, so two of them never share a light's rate limit or restore
+// state the other captured mid-effect.
+type lightOwners struct {
+	mu     sync.Mutex
+	claims map[string]*lightClaim
+}
+
+// lightClaim is the set of lights one run drives
+type lightClaim struct {
+	owners *lightOwners
+	owner  string
+	keys   []string
+}
+
+// newLightOwners creates a registry with no lights claimed
+func newLightOwners() *lightOwners {
+	return &lightOwners{claims: make(map[string]*lightClaim)}
+}
+
+// claim reserves lights for owner, such as "an animation". It fails without
+// claiming anything if another run drives any of them.
+func (o *lightOwners) claim(owner string, lights []snapshot.LightState) (*lightClaim, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	c := &lightClaim{owners: o, owner: owner}
+	for _, light := range lights {
+		key := light.BridgeID + "/" + light.LightID
+		if other, ok := o.claims[key]; ok {
+			name := light.LightID
+			if light.Name != "" {
+				name = fmt.Sprintf("%q", light.Name)
+			}
+			return nil, fmt.Errorf("light %s is already driven by %s; stop it first", name, other.owner)
+		}
+		c.keys = append(c.keys, key)
+	}
+
+	for _, key := range c.keys {
+		o.claims[key] = c
+	}
+	return c, nil
+}
+
+// rename names the run once it has an ID
+func (c *lightClaim) rename(owner string) {
+	c.owners.mu.Lock()
+	defer c.owners.mu.Unlock()
+	c.owner = owner
+}
+
+// release frees the lights. Releasing twice is harmless.
+func (c *lightClaim) release() {
+	c.owners.mu.Lock()
+	defer c.owners.mu.Unlock()
+
+	for _, key := range c.keys {
+		if c.owners.claims[key] == c {
+			delete(c.owners.claims, key)
+		}
+	}
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
 )
 
 // RegisterRoomTools registers all room-related tools
-func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager) {
+func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
 	// list_rooms tool
 	s.AddTool(
 		mcp.Tool{
@@ -42,11 +47,14 @@ func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager) {
 			}
 
 			type roomInfo struct {
-				BridgeID   string `json:"bridge_id"`
-				BridgeName string `json:"bridge_name"`
-				ID         string `json:"id"`
-				Name       string `json:"name"`
-				Type       string `json:"type"`
+				BridgeID          string `json:"bridge_id"`
+				BridgeName        string `json:"bridge_name"`
+				ID                string `json:"id"`
+				Name              string `json:"name"`
+				Type              string `json:"type"`
+				Archetype         string `json:"archetype,omitempty"`
+				Devices           int    `json:"devices"`
+				UnreachableLights int    `json:"unreachable_lights,omitempty"`
 			}
 
 			var allRooms []roomInfo
@@ -60,15 +68,23 @@ func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager) {
 				if err != nil {
 					continue
 				}
+				unreachable := br.UnreachableLights(ctx)
 
 				for _, room := range rooms {
-					allRooms = append(allRooms, roomInfo{
+					info := roomInfo{
 						BridgeID:   br.ID,
 						BridgeName: br.Name,
 						ID:         room.ID,
 						Name:       room.Metadata.Name,
 						Type:       room.Type,
-					})
+						Archetype:  room.Metadata.Archetype,
+						Devices:    len(room.Children),
+					}
+					if len(unreachable) > 0 {
+						lightIDs, _ := roomLightIDs(ctx, br, &room)
+						info.UnreachableLights = countIn(lightIDs, unreachable)
+					}
+					allRooms = append(allRooms, info)
 				}
 			}
 
@@ -85,31 +101,118 @@ func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager) {
 	s.AddTool(
 		mcp.Tool{
 			Name:        "get_room",
-			Description: "Get detailed information about a specific room",
+			Description: "Get a room with its devices, its lights and their current state, and the room's grouped light ID",
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"room_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The room ID",
+						"description": "The room ID, name, or alias",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 				},
 				Required: []string{"room_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			roomID, err := request.RequireString("room_id")
+			target, err := resolveArg(ctx, res, request, "room_id", resolver.KindRoom)
 			if err != nil {
-				return mcp.NewToolResultError("room_id is required"), nil
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			room, err := target.Bridge.CachedClient.Rooms().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get room: %v", err)), nil
+			}
+
+			lightIDs, err := roomLightIDs(ctx, target.Bridge, room)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			type roomDetails struct {
+				BridgeID       string                         `json:"bridge_id"`
+				ID             string                         `json:"id"`
+				Name           string                         `json:"name"`
+				Archetype      string                         `json:"archetype,omitempty"`
+				GroupedLightID string                         `json:"grouped_light_id,omitempty"`
+				Devices        []resources.ResourceIdentifier `json:"devices"`
+				Lights         []memberLight                  `json:"lights"`
+			}
+
+			details := roomDetails{
+				BridgeID:       target.BridgeID,
+				ID:             room.ID,
+				Name:           room.Metadata.Name,
+				Archetype:      room.Metadata.Archetype,
+				GroupedLightID: groupedLightService(room.Services),
+				Devices:        room.Children,
+				Lights:         memberLights(ctx, target.Bridge, lightIDs),
+			}
+
+			data, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal room: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// create_room tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "create_room",
+			Description: "Create a room, optionally with devices. Devices can be given by device or light ID, name or alias, must all be on the same bridge, and must not already belong to a room (use move_devices for that).",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the new room",
+					},
+					"archetype": map[string]interface{}{
+						"type":        "string",
+						"description": "Room archetype (icon). Defaults to 'other'",
+						"enum":        groupArchetypes,
+					},
+					"devices": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional devices to put in the room",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Defaults to the bridge the devices are on, or the default bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
 			}
 
 			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
 
+			var devices []*resolver.Entry
+			if queries := request.GetStringSlice("devices", nil); len(queries) > 0 {
+				devices, bridgeID, err = resolveDeviceList(ctx, res, queries, bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			var br *bridge.Bridge
 			if bridgeID != "" {
 				br, err = bm.GetBridge(bridgeID)
 			} else {
@@ -119,17 +222,447 @@ func RegisterRoomTools(s *server.MCPServer, bm *bridge.Manager) {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			room, err := br.CachedClient.Rooms().Get(ctx, roomID)
+			rooms, err := br.CachedClient.Rooms().List(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list rooms: %v", err)), nil
+			}
+			if err := checkUnassigned(devices, deviceRooms(rooms), ""); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			create := resources.RoomCreate{
+				Metadata: resources.Metadata{
+					Name:      name,
+					Archetype: request.GetString("archetype", "other"),
+				},
+				Children: deviceIdentifiers(devices),
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: br.ID, Method: "rooms.create", Payload: create}).result()
+			}
+
+			var createdID string
+			err = br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				created, err := br.CachedClient.Rooms().Create(ctx, create)
+				if err != nil {
+					return err
+				}
+				createdID = created.RID
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create room: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, br.ID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Room %q created (id %s) with %d device(s), but refreshing the index failed: %v", name, createdID, len(devices), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Room %q created (id %s) with %d device(s) on bridge %s", name, createdID, len(devices), br.ID)), nil
+		},
+	)
+
+	// update_room tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "update_room",
+			Description: "Rename a room, change its archetype, or add and remove devices. Devices already in another room are rejected; use move_devices to move them.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"room_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The room ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for the room",
+					},
+					"archetype": map[string]interface{}{
+						"type":        "string",
+						"description": "New room archetype (icon)",
+						"enum":        groupArchetypes,
+					},
+					"add_devices": map[string]interface{}{
+						"type":        "array",
+						"description": "Devices to add to the room",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"remove_devices": map[string]interface{}{
+						"type":        "array",
+						"description": "Devices to remove from the room",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"room_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "room_id", resolver.KindRoom)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			room, err := target.Bridge.CachedClient.Rooms().Get(ctx, target.ID)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to get room: %v", err)), nil
 			}
 
-			data, err := json.MarshalIndent(room, "", "  ")
+			update := resources.RoomUpdate{}
+
+			name := request.GetString("name", "")
+			archetype := request.GetString("archetype", "")
+			if name != "" || archetype != "" {
+				metadata := room.Metadata
+				if name != "" {
+					metadata.Name = name
+				}
+				if archetype != "" {
+					metadata.Archetype = archetype
+				}
+				update.Metadata = &metadata
+			}
+
+			addDevices := request.GetStringSlice("add_devices", nil)
+			removeDevices := request.GetStringSlice("remove_devices", nil)
+			if len(addDevices) > 0 || len(removeDevices) > 0 {
+				members := make([]string, 0, len(room.Children))
+				for _, child := range room.Children {
+					members = append(members, child.RID)
+				}
+
+				if len(addDevices) > 0 {
+					added, _, err := resolveDeviceList(ctx, res, addDevices, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+
+					rooms, err := target.Bridge.CachedClient.Rooms().List(ctx)
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to list rooms: %v", err)), nil
+					}
+					if err := checkUnassigned(added, deviceRooms(rooms), room.ID); err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+
+					for _, device := range added {
+						if !containsString(members, device.ID) {
+							members = append(members, device.ID)
+						}
+					}
+				}
+
+				if len(removeDevices) > 0 {
+					removed, _, err := resolveDeviceList(ctx, res, removeDevices, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					for _, device := range removed {
+						members = removeString(members, device.ID)
+					}
+				}
+
+				update.Children = deviceChildren(members)
+			}
+
+			if update.Metadata == nil && update.Children == nil {
+				return mcp.NewToolResultError("nothing to update: provide name, archetype, add_devices or remove_devices"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "rooms.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Rooms().Update(ctx, target.ID, update)
+			})
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal room: %v", err)), nil
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update room: %v", err)), nil
 			}
 
-			return mcp.NewToolResultText(string(data)), nil
+			// Device membership feeds the "Room/Light" paths, so refresh on any change
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Room %s updated, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Room %s updated successfully", target.Label())), nil
 		},
 	)
+
+	// move_devices tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "move_devices",
+			Description: "Move devices into a room, removing them from the room they are currently in. A device belongs to one room only.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"devices": map[string]interface{}{
+						"type":        "array",
+						"description": "Devices to move, by device or light ID, name or alias",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"room_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The destination room ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"devices", "room_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "room_id", resolver.KindRoom)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			devices, _, err := resolveDeviceList(ctx, res, request.GetStringSlice("devices", nil), target.BridgeID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			rooms, err := target.Bridge.CachedClient.Rooms().List(ctx)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list rooms: %v", err)), nil
+			}
+
+			byID := make(map[string]resources.Room, len(rooms))
+			for _, room := range rooms {
+				byID[room.ID] = room
+			}
+			destination, ok := byID[target.ID]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("room %s not found on bridge %s", target.Label(), target.BridgeID)), nil
+			}
+
+			// Group the devices by the room they leave
+			current := deviceRooms(rooms)
+			leaving := make(map[string][]string)
+			var moved []string
+			for _, device := range devices {
+				from, inRoom := current[device.ID]
+				if inRoom && from.ID == destination.ID {
+					continue
+				}
+				if inRoom {
+					leaving[from.ID] = append(leaving[from.ID], device.ID)
+				}
+				moved = append(moved, device.ID)
+			}
+
+			if len(moved) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("All devices are already in room %s", target.Label())), nil
+			}
+
+			if isDryRun(ctx) {
+				report := newDryRun()
+				for roomID, ids := range leaving {
+					members := childIDs(byID[roomID].Children)
+					for _, id := range ids {
+						members = removeString(members, id)
+					}
+					report.Commands = append(report.Commands, dryRunCommand{BridgeID: target.BridgeID, Method: "rooms.update", TargetID: roomID, Payload: resources.RoomUpdate{Children: deviceChildren(members)}})
+				}
+				members := append(childIDs(destination.Children), moved...)
+				report.Commands = append(report.Commands, dryRunCommand{BridgeID: target.BridgeID, Method: "rooms.update", TargetID: destination.ID, Payload: resources.RoomUpdate{Children: deviceChildren(members)}})
+				return report.result()
+			}
+
+			// Devices must leave their old room before the bridge accepts
+			// them elsewhere. If any step fails, the rooms already changed
+			// get their devices back.
+			var changed []string
+			rollback := func() string {
+				var failed []string
+				for _, roomID := range changed {
+					if err := updateRoomChildren(context.WithoutCancel(ctx), target.Bridge, roomID, byID[roomID].Children); err != nil {
+						log.Printf("Warning: failed to restore the devices of room %s: %v", roomID, err)
+						failed = append(failed, byID[roomID].Metadata.Name)
+					}
+				}
+				if len(failed) > 0 {
+					return fmt.Sprintf("; restoring the devices of %s failed too", strings.Join(failed, ", "))
+				}
+				return ""
+			}
+
+			for roomID, ids := range leaving {
+				members := childIDs(byID[roomID].Children)
+				for _, id := range ids {
+					members = removeString(members, id)
+				}
+
+				if err := updateRoomChildren(ctx, target.Bridge, roomID, deviceChildren(members)); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to remove devices from room %q: %v%s", byID[roomID].Metadata.Name, err, rollback())), nil
+				}
+				changed = append(changed, roomID)
+			}
+
+			members := append(childIDs(destination.Children), moved...)
+			if err := updateRoomChildren(ctx, target.Bridge, destination.ID, deviceChildren(members)); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to add devices to room %s: %v%s", target.Label(), err, rollback())), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Moved %d device(s) to room %s, but refreshing the index failed: %v", len(moved), target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Moved %d device(s) to room %s", len(moved), target.Label())), nil
+		},
+	)
+
+	// delete_room tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_room",
+			Description: "Delete a room. Its devices become unassigned; the lights themselves are not affected.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"room_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The room ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"room_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "room_id", resolver.KindRoom)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "rooms.delete", TargetID: target.ID}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Rooms().Delete(ctx, target.ID)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete room: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Room %s deleted, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Room %s deleted successfully", target.Label())), nil
+		},
+	)
+}
+
+// countIn counts the IDs that are in a set
+func countIn(ids []string, set map[string]bool) int {
+	n := 0
+	for _, id := range ids {
+		if set[id] {
+			n++
+		}
+	}
+	return n
+}
+
+// updateRoomChildren replaces the devices of a room
+func updateRoomChildren(ctx context.Context, br *bridge.Bridge, roomID string, children []resources.ResourceIdentifier) error {
+	return br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+		return br.CachedClient.Rooms().Update(ctx, roomID, resources.RoomUpdate{Children: children})
+	})
+}
+
+// roomLightIDs returns the IDs of the lights owned by a room's devices
+func roomLightIDs(ctx context.Context, br *bridge.Bridge, room *resources.Room) ([]string, error) {
+	lights, err := br.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lights: %w", err)
+	}
+
+	devices := make(map[string]bool, len(room.Children))
+	for _, child := range room.Children {
+		devices[child.RID] = true
+	}
+
+	var ids []string
+	for _, light := range lights {
+		if devices[light.Owner.RID] {
+			ids = append(ids, light.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// deviceRooms maps each device ID to the room it belongs to
+func deviceRooms(rooms []resources.Room) map[string]resources.Room {
+	byDevice := make(map[string]resources.Room)
+	for _, room := range rooms {
+		for _, child := range room.Children {
+			byDevice[child.RID] = room
+		}
+	}
+	return byDevice
+}
+
+// checkUnassigned returns an error if any device already belongs to a room
+// other than exceptRoomID
+func checkUnassigned(devices []*resolver.Entry, byDevice map[string]resources.Room, exceptRoomID string) error {
+	for _, device := range devices {
+		if room, ok := byDevice[device.ID]; ok && room.ID != exceptRoomID {
+			return fmt.Errorf("device %s already belongs to room %q; a device can be in one room only, use move_devices to move it", device.Label(), room.Metadata.Name)
+		}
+	}
+	return nil
+}
+
+// deviceIdentifiers converts resolved devices to resource identifiers
+func deviceIdentifiers(devices []*resolver.Entry) []resources.ResourceIdentifier {
+	ids := make([]string, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+	return deviceChildren(ids)
+}
+
+// deviceChildren builds a room's children from device IDs
+func deviceChildren(ids []string) []resources.ResourceIdentifier {
+	children := make([]resources.ResourceIdentifier, len(ids))
+	for i, id := range ids {
+		children[i] = resources.ResourceIdentifier{RID: id, RType: "device"}
+	}
+	return children
+}
+
+// childIDs returns the resource IDs of a group's children
+func childIDs(children []resources.ResourceIdentifier) []string {
+	ids := make([]string, len(children))
+	for i, child := range children {
+		ids[i] = child.RID
+	}
+	return ids
 }
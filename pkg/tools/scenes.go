@@ -4,20 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
 	"github.com/rmrfslashbin/hue-sdk/resources"
 )
 
 // RegisterSceneTools registers all scene-related tools
-func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
+func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
 	// list_scenes tool
 	s.AddTool(
 		mcp.Tool{
 			Name:        "list_scenes",
-			Description: "List all scenes across all bridges or from a specific bridge",
+			Description: "List all scenes across all bridges or from a specific bridge, with each scene's room or zone and whether it is currently active (static or dynamic)",
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -25,6 +30,10 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 						"type":        "string",
 						"description": "Optional bridge ID. If not provided, lists scenes from all bridges",
 					},
+					"active_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only list scenes that are currently active",
+					},
 				},
 			},
 		},
@@ -42,12 +51,20 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 				bridges = bm.ListBridges()
 			}
 
+			activeOnly := request.GetBool("active_only", false)
+
 			type sceneInfo struct {
-				BridgeID   string `json:"bridge_id"`
-				BridgeName string `json:"bridge_name"`
-				ID         string `json:"id"`
-				Name       string `json:"name"`
-				Type       string `json:"type"`
+				BridgeID    string     `json:"bridge_id"`
+				BridgeName  string     `json:"bridge_name"`
+				ID          string     `json:"id"`
+				Name        string     `json:"name"`
+				Type        string     `json:"type"`
+				Group       string     `json:"group,omitempty"`
+				GroupType   string     `json:"group_type,omitempty"`
+				Status      string     `json:"status"`
+				LastRecall  *time.Time `json:"last_recall,omitempty"`
+				Speed       float64    `json:"speed"`
+				AutoDynamic bool       `json:"auto_dynamic"`
 			}
 
 			var allScenes []sceneInfo
@@ -62,14 +79,31 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 					continue
 				}
 
+				groupNames := sceneGroupNames(ctx, br)
+
 				for _, scene := range scenes {
-					allScenes = append(allScenes, sceneInfo{
-						BridgeID:   br.ID,
-						BridgeName: br.Name,
-						ID:         scene.ID,
-						Name:       scene.Metadata.Name,
-						Type:       scene.Type,
-					})
+					status := sceneStatus(scene)
+					if activeOnly && status == "inactive" {
+						continue
+					}
+
+					info := sceneInfo{
+						BridgeID:    br.ID,
+						BridgeName:  br.Name,
+						ID:          scene.ID,
+						Name:        scene.Metadata.Name,
+						Type:        scene.Type,
+						Group:       groupNames[scene.Group.RID],
+						GroupType:   scene.Group.RType,
+						Status:      status,
+						Speed:       scene.Speed,
+						AutoDynamic: scene.AutoDynamic,
+					}
+					if scene.Status != nil {
+						info.LastRecall = scene.Status.LastRecall
+					}
+
+					allScenes = append(allScenes, info)
 				}
 			}
 
@@ -92,35 +126,23 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 				Properties: map[string]interface{}{
 					"scene_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The scene ID",
+						"description": "The scene ID, name, \"Room/Scene\" path, or alias",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 				},
 				Required: []string{"scene_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			sceneID, err := request.RequireString("scene_id")
-			if err != nil {
-				return mcp.NewToolResultError("scene_id is required"), nil
-			}
-
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
+			target, err := resolveArg(ctx, res, request, "scene_id", resolver.KindScene)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			scene, err := br.CachedClient.Scenes().Get(ctx, sceneID)
+			scene, err := target.Bridge.CachedClient.Scenes().Get(ctx, target.ID)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to get scene: %v", err)), nil
 			}
@@ -138,17 +160,17 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 	s.AddTool(
 		mcp.Tool{
 			Name:        "activate_scene",
-			Description: "Activate (recall) a scene to apply its lighting configuration. Optionally override brightness or transition duration.",
+			Description: "Activate (recall) a scene to apply its lighting configuration. Recall it statically, as the scene's active state, or in dynamic mode cycling through its palette. Optionally override brightness, transition duration or dynamic speed.",
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
 					"scene_id": map[string]interface{}{
 						"type":        "string",
-						"description": "The scene ID to activate",
+						"description": "The scene to activate: ID, name, \"Room/Scene\" path, or alias",
 					},
 					"bridge_id": map[string]interface{}{
 						"type":        "string",
-						"description": "Optional bridge ID. Uses default bridge if not provided",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
 					},
 					"duration": map[string]interface{}{
 						"type":        "number",
@@ -162,31 +184,43 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 						"minimum":     0,
 						"maximum":     100,
 					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "Recall mode: 'active' (default, starts dynamics if the scene auto-starts them), 'static' (never dynamic), or 'dynamic_palette' (cycle through the scene's palette)",
+						"enum":        []string{"active", "static", "dynamic_palette"},
+					},
+					"speed": map[string]interface{}{
+						"type":        "number",
+						"description": "Optional speed for dynamic mode (0-1). Saved on the scene",
+						"minimum":     0,
+						"maximum":     1,
+					},
+					"auto_dynamic": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Optionally set whether the scene starts in dynamic mode whenever it is recalled as 'active'. Saved on the scene",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
 				},
 				Required: []string{"scene_id"},
 			},
 		},
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			sceneID, err := request.RequireString("scene_id")
+			target, err := resolveArg(ctx, res, request, "scene_id", resolver.KindScene)
 			if err != nil {
-				return mcp.NewToolResultError("scene_id is required"), nil
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			bridgeID := request.GetString("bridge_id", "")
-			var br *bridge.Bridge
-
-			if bridgeID != "" {
-				br, err = bm.GetBridge(bridgeID)
-			} else {
-				br, err = bm.GetDefaultBridge()
-			}
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
+			mode := request.GetString("mode", "active")
+			switch mode {
+			case "active", "static", "dynamic_palette":
+			default:
+				return mcp.NewToolResultError(fmt.Sprintf("invalid mode %q: use active, static or dynamic_palette", mode)), nil
 			}
 
 			// Build scene recall request
 			recall := resources.SceneRecall{
-				Action: "active",
+				Action: mode,
 			}
 
 			args := request.GetArguments()
@@ -211,11 +245,298 @@ func RegisterSceneTools(s *server.MCPServer, bm *bridge.Manager) {
 				Recall: &recall,
 			}
 
-			if err := br.CachedClient.Scenes().Update(ctx, sceneID, update); err != nil {
+			// Optional dynamics settings, stored on the scene
+			if speed, ok := args["speed"].(float64); ok {
+				update.Speed = &speed
+			}
+			if autoDynamic, ok := args["auto_dynamic"].(bool); ok {
+				update.AutoDynamic = &autoDynamic
+			}
+
+			report := newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "scenes.update", TargetID: target.ID, Payload: update})
+			scene, err := target.Bridge.CachedClient.Scenes().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get scene: %v", err)), nil
+			}
+			if report.Changes, err = sceneLightDiffs(ctx, target.Bridge, scene); err != nil {
+				report.Warnings = append(report.Warnings, err.Error())
+			}
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Scenes().Update(ctx, target.ID, update)
+			})
+			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to activate scene: %v", err)), nil
 			}
 
-			return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s activated successfully", sceneID)), nil
+			if mode != "active" {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s activated successfully (%s)", target.Label(), mode)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s activated successfully", target.Label())), nil
+		},
+	)
+
+	// preview_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "preview_scene",
+			Description: "Preview what activating a scene would change, without changing anything. Compares each light's scene action with its current state (on/off, brightness, color, effect) and flags lights that are unreachable or no longer part of the scene's room or zone.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"scene_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The scene to preview: ID, name, \"Room/Scene\" path, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"scene_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "scene_id", resolver.KindScene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			scene, err := target.Bridge.CachedClient.Scenes().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get scene: %v", err)), nil
+			}
+
+			diffs, err := sceneLightDiffs(ctx, target.Bridge, scene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			type scenePreview struct {
+				BridgeID     string      `json:"bridge_id"`
+				SceneID      string      `json:"scene_id"`
+				Scene        string      `json:"scene"`
+				Group        string      `json:"group,omitempty"`
+				Changing     int         `json:"changing"`
+				Unchanged    int         `json:"unchanged"`
+				Unreachable  int         `json:"unreachable"`
+				OutsideGroup int         `json:"outside_group"`
+				Lights       []lightDiff `json:"lights"`
+			}
+
+			preview := scenePreview{
+				BridgeID: target.BridgeID,
+				SceneID:  scene.ID,
+				Scene:    scene.Metadata.Name,
+				Group:    target.Group,
+				Lights:   diffs,
+			}
+
+			for _, diff := range diffs {
+				if !diff.Reachable {
+					preview.Unreachable++
+				}
+				if !diff.InGroup {
+					preview.OutsideGroup++
+				}
+				if len(diff.Changes) > 0 {
+					preview.Changing++
+				} else {
+					preview.Unchanged++
+				}
+			}
+
+			data, err := json.MarshalIndent(preview, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal preview: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
 		},
 	)
 }
+
+// sceneLightDiffs compares each action of a scene with the current state of
+// its light, flagging lights that are unreachable or outside the scene's group
+func sceneLightDiffs(ctx context.Context, br *bridge.Bridge, scene *resources.Scene) ([]lightDiff, error) {
+	groupLights, err := groupLightIDs(ctx, br, scene.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	unreachable := br.UnreachableDevices(ctx)
+
+	diffs := make([]lightDiff, 0, len(scene.Actions))
+	for _, action := range scene.Actions {
+		diff := lightDiff{
+			BridgeID: br.ID,
+			LightID:  action.Target.RID,
+			InGroup:  containsString(groupLights, action.Target.RID),
+		}
+
+		light, err := br.CachedClient.Lights().Get(ctx, action.Target.RID)
+		if err != nil {
+			diff.Warnings = append(diff.Warnings, fmt.Sprintf("light not found: %v", err))
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		diff.Name = light.Metadata.Name
+		diff.Reachable = !unreachable[light.Owner.RID]
+		diff.Changes = diffSceneAction(light, action.Action)
+
+		if !diff.Reachable {
+			diff.Warnings = append(diff.Warnings, "light is unreachable and will not change")
+		}
+		if !diff.InGroup {
+			diff.Warnings = append(diff.Warnings, fmt.Sprintf("light is not part of the scene's %s", scene.Group.RType))
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// lightDiff is the change a scene would make to one light
+type lightDiff struct {
+	BridgeID  string        `json:"bridge_id,omitempty"`
+	LightID   string        `json:"light_id"`
+	Name      string        `json:"name,omitempty"`
+	Reachable bool          `json:"reachable"`
+	InGroup   bool          `json:"in_group"`
+	Changes   []fieldChange `json:"changes,omitempty"`
+	Warnings  []string      `json:"warnings,omitempty"`
+}
+
+// fieldChange is one property that changes from its current value
+type fieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// diffSceneAction compares a scene action with a light's current state
+func diffSceneAction(light *resources.Light, action resources.SceneActionDetails) []fieldChange {
+	var changes []fieldChange
+
+	if action.On != nil && action.On.On != light.On.On {
+		changes = append(changes, fieldChange{Field: "on", From: light.On.On, To: action.On.On})
+	}
+
+	// Off lights keep their other settings for the next time they turn on
+	if action.On != nil && !action.On.On {
+		return changes
+	}
+
+	if action.Dimming != nil && light.Dimming != nil && math.Abs(action.Dimming.Brightness-light.Dimming.Brightness) >= 0.5 {
+		changes = append(changes, fieldChange{Field: "brightness", From: light.Dimming.Brightness, To: action.Dimming.Brightness})
+	}
+
+	current := describeLightColor(light)
+	var wanted string
+	switch {
+	case action.Gradient != nil && len(action.Gradient.Points) > 0:
+		wanted = describeGradient(action.Gradient)
+	case action.ColorTemperature != nil:
+		wanted = fmt.Sprintf("%d mirek", action.ColorTemperature.Mirek)
+	case action.Color != nil:
+		wanted = fmt.Sprintf("xy(%.4f, %.4f)", action.Color.XY.X, action.Color.XY.Y)
+	}
+	if wanted != "" && !sameColor(light, action) {
+		changes = append(changes, fieldChange{Field: "color", From: current, To: wanted})
+	}
+
+	if action.Effects != nil {
+		effect := "no_effect"
+		if light.Effects != nil && light.Effects.Status != "" {
+			effect = light.Effects.Status
+		}
+		if effect != action.Effects.Effect {
+			changes = append(changes, fieldChange{Field: "effect", From: effect, To: action.Effects.Effect})
+		}
+	}
+
+	return changes
+}
+
+// sameColor reports whether a light already shows the color of a scene action
+func sameColor(light *resources.Light, action resources.SceneActionDetails) bool {
+	const xyTolerance = 0.005
+
+	switch {
+	case action.Gradient != nil && len(action.Gradient.Points) > 0:
+		if light.Gradient == nil || len(light.Gradient.Points) != len(action.Gradient.Points) {
+			return false
+		}
+		for i, point := range action.Gradient.Points {
+			current := light.Gradient.Points[i].Color.XY
+			if math.Abs(current.X-point.Color.XY.X) > xyTolerance || math.Abs(current.Y-point.Color.XY.Y) > xyTolerance {
+				return false
+			}
+		}
+		return true
+
+	case action.ColorTemperature != nil:
+		return light.ColorTemperature != nil && light.ColorTemperature.MirekValid && light.ColorTemperature.Mirek == action.ColorTemperature.Mirek
+
+	case action.Color != nil:
+		if light.Color == nil || (light.ColorTemperature != nil && light.ColorTemperature.MirekValid) {
+			return false
+		}
+		return math.Abs(light.Color.XY.X-action.Color.XY.X) <= xyTolerance && math.Abs(light.Color.XY.Y-action.Color.XY.Y) <= xyTolerance
+	}
+
+	return true
+}
+
+// describeLightColor returns a light's current color in readable form
+func describeLightColor(light *resources.Light) string {
+	switch {
+	case light.Gradient != nil && len(light.Gradient.Points) > 0:
+		return describeGradient(light.Gradient)
+	case light.ColorTemperature != nil && light.ColorTemperature.MirekValid:
+		return fmt.Sprintf("%d mirek", light.ColorTemperature.Mirek)
+	case light.Color != nil:
+		return fmt.Sprintf("xy(%.4f, %.4f)", light.Color.XY.X, light.Color.XY.Y)
+	}
+	return "unknown"
+}
+
+// describeGradient returns gradient points in readable form
+func describeGradient(gradient *resources.Gradient) string {
+	points := make([]string, len(gradient.Points))
+	for i, point := range gradient.Points {
+		points[i] = fmt.Sprintf("xy(%.4f, %.4f)", point.Color.XY.X, point.Color.XY.Y)
+	}
+	return "gradient[" + strings.Join(points, ", ") + "]"
+}
+
+// sceneStatus returns whether a scene is inactive, static or dynamic_palette
+func sceneStatus(scene resources.Scene) string {
+	if scene.Status == nil || scene.Status.Active == "" {
+		return "inactive"
+	}
+	return scene.Status.Active
+}
+
+// sceneGroupNames maps room and zone IDs on a bridge to their names
+func sceneGroupNames(ctx context.Context, br *bridge.Bridge) map[string]string {
+	names := make(map[string]string)
+
+	if rooms, err := br.CachedClient.Rooms().List(ctx); err == nil {
+		for _, room := range rooms {
+			names[room.ID] = room.Metadata.Name
+		}
+	}
+	if zones, err := br.CachedClient.Zones().List(ctx); err == nil {
+		for _, zone := range zones {
+			names[zone.ID] = zone.Metadata.Name
+		}
+	}
+
+	return names
+}
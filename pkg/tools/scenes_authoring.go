@@ -0,0 +1,727 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// sceneActionSchema describes one light's state in a scene
+var sceneActionSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"light_id": map[string]interface{}{
+			"type":        "string",
+			"description": "The light ID, name, \"Room/Light\" path, or alias",
+		},
+		"on": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Whether the light is on in the scene",
+		},
+		"brightness": map[string]interface{}{
+			"type":        "number",
+			"description": "Brightness (0-100)",
+			"minimum":     0,
+			"maximum":     100,
+		},
+		"color_xy": map[string]interface{}{
+			"type":        "object",
+			"description": "CIE XY color coordinates",
+			"properties": map[string]interface{}{
+				"x": map[string]interface{}{
+					"type":    "number",
+					"minimum": 0,
+					"maximum": 1,
+				},
+				"y": map[string]interface{}{
+					"type":    "number",
+					"minimum": 0,
+					"maximum": 1,
+				},
+			},
+			"required": []string{"x", "y"},
+		},
+		"color_temp": map[string]interface{}{
+			"type":        "number",
+			"description": "Color temperature in mirek (153-500)",
+			"minimum":     153,
+			"maximum":     500,
+		},
+		"effect": map[string]interface{}{
+			"type":        "string",
+			"description": "Light effect",
+			"enum":        []string{"no_effect", "candle", "fire", "prism", "sparkle", "opal", "glisten", "underwater", "cosmos", "sunbeam", "enchant"},
+		},
+		"gradient": map[string]interface{}{
+			"type":        "array",
+			"description": "Gradient color points (for lightstrips)",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"x": map[string]interface{}{
+						"type":    "number",
+						"minimum": 0,
+						"maximum": 1,
+					},
+					"y": map[string]interface{}{
+						"type":    "number",
+						"minimum": 0,
+						"maximum": 1,
+					},
+				},
+				"required": []string{"x", "y"},
+			},
+		},
+	},
+	"required": []string{"light_id"},
+}
+
+// scenePaletteSchema describes the palette used by dynamic scenes
+var scenePaletteSchema = map[string]interface{}{
+	"type":        "object",
+	"description": "Palette for dynamic scenes",
+	"properties": map[string]interface{}{
+		"colors": map[string]interface{}{
+			"type":        "array",
+			"description": "Palette colors",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"x":          map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+					"y":          map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+					"brightness": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 100},
+				},
+				"required": []string{"x", "y"},
+			},
+		},
+		"color_temperatures": map[string]interface{}{
+			"type":        "array",
+			"description": "Palette color temperatures",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"mirek":      map[string]interface{}{"type": "number", "minimum": 153, "maximum": 500},
+					"brightness": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 100},
+				},
+				"required": []string{"mirek", "brightness"},
+			},
+		},
+	},
+}
+
+// RegisterSceneAuthoringTools registers tools that create, edit and delete scenes
+func RegisterSceneAuthoringTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// create_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "create_scene",
+			Description: "Create a scene for a room or zone, either from explicit per-light actions or by capturing the current state of the room or zone's lights. Actions are checked against each light's capabilities.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the new scene",
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Room the scene belongs to (ID, name, or alias)",
+					},
+					"zone": map[string]interface{}{
+						"type":        "string",
+						"description": "Zone the scene belongs to (ID, name, or alias). Use instead of room",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"actions": map[string]interface{}{
+						"type":        "array",
+						"description": "Per-light states. If omitted, the current state of every light in the room or zone is captured",
+						"items":       sceneActionSchema,
+					},
+					"speed": map[string]interface{}{
+						"type":        "number",
+						"description": "Speed of the dynamic palette (0-1)",
+						"minimum":     0,
+						"maximum":     1,
+					},
+					"auto_dynamic": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Start the scene in dynamic mode when it is recalled",
+					},
+					"palette":       scenePaletteSchema,
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			owner, err := resolveGroupOwner(ctx, res, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			group := resources.ResourceIdentifier{RID: owner.ID, RType: string(owner.Kind)}
+			groupLights, err := groupLightIDs(ctx, owner.Bridge, group)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(groupLights) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("%s %s has no lights", owner.Kind, owner.Label())), nil
+			}
+
+			args := request.GetArguments()
+
+			var actions []resources.SceneAction
+			if rawActions, ok := args["actions"]; ok {
+				actions, err = sceneActionsFromArgs(ctx, res, owner.Bridge, rawActions, groupLights)
+			} else {
+				actions, err = captureSceneActions(ctx, owner.Bridge, groupLights)
+			}
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			create := resources.SceneCreate{
+				Metadata: resources.Metadata{Name: name},
+				Group:    group,
+				Actions:  actions,
+			}
+
+			if speed, ok := args["speed"].(float64); ok {
+				create.Speed = &speed
+			}
+			if autoDynamic, ok := args["auto_dynamic"].(bool); ok {
+				create.AutoDynamic = &autoDynamic
+			}
+			if rawPalette, ok := args["palette"]; ok {
+				if create.Palette, err = paletteFromArgs(rawPalette); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: owner.BridgeID, Method: "scenes.create", Payload: create}).result()
+			}
+
+			var createdID string
+			err = owner.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				created, err := owner.Bridge.CachedClient.Scenes().Create(ctx, create)
+				if err != nil {
+					return err
+				}
+				createdID = created.RID
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create scene: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, owner.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %q created (id %s) for %d light(s), but refreshing the index failed: %v", name, createdID, len(actions), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %q created (id %s) in %s %s for %d light(s)", name, createdID, owner.Kind, owner.Label(), len(actions))), nil
+		},
+	)
+
+	// update_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "update_scene",
+			Description: "Edit a scene: rename it, set or remove per-light actions, re-capture the current light state, or change its dynamic speed, palette or auto-dynamic setting",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"scene_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The scene ID, name, \"Room/Scene\" path, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for the scene",
+					},
+					"actions": map[string]interface{}{
+						"type":        "array",
+						"description": "Per-light states to set. Lights not listed keep their current action",
+						"items":       sceneActionSchema,
+					},
+					"remove_lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to remove from the scene",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"capture": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace all actions with the current state of the scene's lights",
+					},
+					"speed": map[string]interface{}{
+						"type":        "number",
+						"description": "Speed of the dynamic palette (0-1)",
+						"minimum":     0,
+						"maximum":     1,
+					},
+					"auto_dynamic": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Start the scene in dynamic mode when it is recalled",
+					},
+					"palette":       scenePaletteSchema,
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"scene_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "scene_id", resolver.KindScene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			scene, err := target.Bridge.CachedClient.Scenes().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get scene: %v", err)), nil
+			}
+
+			args := request.GetArguments()
+			update := resources.SceneUpdate{}
+
+			if name := request.GetString("name", ""); name != "" {
+				metadata := scene.Metadata
+				metadata.Name = name
+				update.Metadata = &metadata
+			}
+
+			_, hasActions := args["actions"]
+			removeLights := request.GetStringSlice("remove_lights", nil)
+			capture := request.GetBool("capture", false)
+
+			if hasActions || len(removeLights) > 0 || capture {
+				groupLights, err := groupLightIDs(ctx, target.Bridge, scene.Group)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				actions := scene.Actions
+				if capture {
+					sceneLights := make([]string, 0, len(scene.Actions))
+					for _, action := range scene.Actions {
+						sceneLights = append(sceneLights, action.Target.RID)
+					}
+					if actions, err = captureSceneActions(ctx, target.Bridge, sceneLights); err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+				}
+
+				if hasActions {
+					changed, err := sceneActionsFromArgs(ctx, res, target.Bridge, args["actions"], groupLights)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					actions = mergeSceneActions(actions, changed)
+				}
+
+				if len(removeLights) > 0 {
+					removed, _, err := resolveLightList(ctx, res, removeLights, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					for _, light := range removed {
+						actions = removeSceneAction(actions, light.ID)
+					}
+				}
+
+				if len(actions) == 0 {
+					return mcp.NewToolResultError("a scene must keep at least one light; use delete_scene to remove it"), nil
+				}
+				update.Actions = actions
+			}
+
+			if speed, ok := args["speed"].(float64); ok {
+				update.Speed = &speed
+			}
+			if autoDynamic, ok := args["auto_dynamic"].(bool); ok {
+				update.AutoDynamic = &autoDynamic
+			}
+			if rawPalette, ok := args["palette"]; ok {
+				if update.Palette, err = paletteFromArgs(rawPalette); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			if update.Metadata == nil && update.Actions == nil && update.Speed == nil && update.AutoDynamic == nil && update.Palette == nil {
+				return mcp.NewToolResultError("nothing to update: provide name, actions, remove_lights, capture, speed, auto_dynamic or palette"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "scenes.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Scenes().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update scene: %v", err)), nil
+			}
+
+			if update.Metadata != nil {
+				if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s updated, but refreshing the index failed: %v", target.Label(), err)), nil
+				}
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s updated successfully", target.Label())), nil
+		},
+	)
+
+	// delete_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_scene",
+			Description: "Delete a scene",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"scene_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The scene ID, name, \"Room/Scene\" path, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"scene_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "scene_id", resolver.KindScene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "scenes.delete", TargetID: target.ID}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Scenes().Delete(ctx, target.ID)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete scene: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s deleted, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Scene %s deleted successfully", target.Label())), nil
+		},
+	)
+}
+
+// groupLightIDs returns the lights of a room, zone or the whole bridge
+func groupLightIDs(ctx context.Context, br *bridge.Bridge, group resources.ResourceIdentifier) ([]string, error) {
+	switch group.RType {
+	case "bridge_home":
+		lights, err := br.CachedClient.Lights().List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list lights: %w", err)
+		}
+		ids := make([]string, len(lights))
+		for i, light := range lights {
+			ids[i] = light.ID
+		}
+		return ids, nil
+
+	case "room":
+		room, err := br.CachedClient.Rooms().Get(ctx, group.RID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get room: %w", err)
+		}
+		return roomLightIDs(ctx, br, room)
+
+	case "zone":
+		zone, err := br.CachedClient.Zones().Get(ctx, group.RID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get zone: %w", err)
+		}
+		return zoneLightIDs(*zone), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported group type %q", group.RType)
+	}
+}
+
+// groupedLightMembers returns the lights controlled by a grouped light
+func groupedLightMembers(ctx context.Context, br *bridge.Bridge, groupedLightID string) ([]string, error) {
+	gl, err := br.CachedClient.GroupedLights().Get(ctx, groupedLightID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grouped light: %w", err)
+	}
+
+	return groupLightIDs(ctx, br, gl.Owner)
+}
+
+// sceneActionsFromArgs parses and validates per-light scene actions.
+// Every light must belong to the scene's room or zone.
+func sceneActionsFromArgs(ctx context.Context, res *resolver.Resolver, br *bridge.Bridge, raw interface{}, groupLights []string) ([]resources.SceneAction, error) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("actions must be a non-empty array")
+	}
+
+	actions := make([]resources.SceneAction, 0, len(list))
+	for i, item := range list {
+		args, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("action %d: invalid action", i+1)
+		}
+
+		query, _ := args["light_id"].(string)
+		if query == "" {
+			return nil, fmt.Errorf("action %d: missing light_id", i+1)
+		}
+
+		target, err := res.Resolve(ctx, resolver.KindLight, query, br.ID)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		if !containsString(groupLights, target.ID) {
+			return nil, fmt.Errorf("action %d: light %s is not part of the scene's room or zone", i+1, target.Label())
+		}
+
+		light, err := br.CachedClient.Lights().Get(ctx, target.ID)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: failed to get light %s: %w", i+1, target.Label(), err)
+		}
+
+		details, err := sceneActionFromUpdate(lightUpdateFromArgs(args))
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		if err := validateSceneAction(light, details); err != nil {
+			return nil, fmt.Errorf("action %d: light %s: %w", i+1, target.Label(), err)
+		}
+
+		actions = append(actions, resources.SceneAction{
+			Target: resources.ResourceIdentifier{RID: target.ID, RType: "light"},
+			Action: details,
+		})
+	}
+
+	return actions, nil
+}
+
+// sceneActionFromUpdate converts a light update to a scene action
+func sceneActionFromUpdate(update resources.LightUpdate) (resources.SceneActionDetails, error) {
+	if update.TimedEffects != nil || update.Alert != nil {
+		return resources.SceneActionDetails{}, fmt.Errorf("timed effects and alerts cannot be stored in a scene")
+	}
+
+	return stateAction(update), nil
+}
+
+// stateAction returns the part of a light update that describes a lasting state
+func stateAction(update resources.LightUpdate) resources.SceneActionDetails {
+	return resources.SceneActionDetails{
+		On:               update.On,
+		Dimming:          update.Dimming,
+		Color:            update.Color,
+		ColorTemperature: update.ColorTemperature,
+		Gradient:         update.Gradient,
+		Effects:          update.Effects,
+	}
+}
+
+// validateSceneAction checks a scene action against a light's capabilities
+func validateSceneAction(light *resources.Light, action resources.SceneActionDetails) error {
+	if action.Dimming != nil && light.Dimming == nil {
+		return fmt.Errorf("does not support dimming")
+	}
+
+	if action.Color != nil && light.Color == nil {
+		return fmt.Errorf("does not support color")
+	}
+
+	if action.ColorTemperature != nil {
+		if light.ColorTemperature == nil {
+			return fmt.Errorf("does not support color temperature")
+		}
+		if schema := light.ColorTemperature.MirekSchema; schema != nil {
+			mirek := action.ColorTemperature.Mirek
+			if mirek < schema.MirekMinimum || mirek > schema.MirekMaximum {
+				return fmt.Errorf("color temperature %d mirek is outside the supported range %d-%d", mirek, schema.MirekMinimum, schema.MirekMaximum)
+			}
+		}
+	}
+
+	if action.Color != nil && action.ColorTemperature != nil {
+		return fmt.Errorf("set either a color or a color temperature, not both")
+	}
+
+	if action.Gradient != nil {
+		if light.Gradient == nil {
+			return fmt.Errorf("does not support gradients")
+		}
+		if capable := light.Gradient.PointsCapable; capable > 0 && len(action.Gradient.Points) > capable {
+			return fmt.Errorf("supports at most %d gradient points, got %d", capable, len(action.Gradient.Points))
+		}
+	}
+
+	if action.Effects != nil {
+		if light.Effects == nil {
+			return fmt.Errorf("does not support effects")
+		}
+		if action.Effects.Effect != "no_effect" && len(light.Effects.EffectValues) > 0 && !containsString(light.Effects.EffectValues, action.Effects.Effect) {
+			return fmt.Errorf("does not support effect %q (supported: %v)", action.Effects.Effect, light.Effects.EffectValues)
+		}
+	}
+
+	return nil
+}
+
+// captureSceneActions records the current state of lights as scene actions
+func captureSceneActions(ctx context.Context, br *bridge.Bridge, lightIDs []string) ([]resources.SceneAction, error) {
+	actions := make([]resources.SceneAction, 0, len(lightIDs))
+	for _, id := range lightIDs {
+		light, err := br.CachedClient.Lights().Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get light %s: %w", id, err)
+		}
+
+		actions = append(actions, resources.SceneAction{
+			Target: resources.ResourceIdentifier{RID: light.ID, RType: "light"},
+			Action: captureAction(light),
+		})
+	}
+
+	return actions, nil
+}
+
+// captureAction returns a scene action reproducing a light's current state
+func captureAction(light *resources.Light) resources.SceneActionDetails {
+	// Scenes only switch off lights, so their brightness and color are left out
+	state := snapshot.StateOf(*light)
+	if !light.On.On {
+		state = resources.LightUpdate{On: state.On}
+	}
+
+	// A captured state never holds timed effects or alerts
+	action, _ := sceneActionFromUpdate(state)
+	return action
+}
+
+// mergeSceneActions replaces the actions of lights in changed and appends new ones
+func mergeSceneActions(actions, changed []resources.SceneAction) []resources.SceneAction {
+	merged := make([]resources.SceneAction, 0, len(actions)+len(changed))
+	merged = append(merged, actions...)
+
+	for _, action := range changed {
+		replaced := false
+		for i := range merged {
+			if merged[i].Target.RID == action.Target.RID {
+				merged[i] = action
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, action)
+		}
+	}
+
+	return merged
+}
+
+// removeSceneAction returns the actions without the given light
+func removeSceneAction(actions []resources.SceneAction, lightID string) []resources.SceneAction {
+	result := make([]resources.SceneAction, 0, len(actions))
+	for _, action := range actions {
+		if action.Target.RID != lightID {
+			result = append(result, action)
+		}
+	}
+	return result
+}
+
+// paletteFromArgs parses a dynamic scene palette
+func paletteFromArgs(raw interface{}) (*resources.ScenePalette, error) {
+	args, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("palette must be an object")
+	}
+
+	palette := &resources.ScenePalette{
+		Color:            []resources.ScenePaletteColor{},
+		Dimming:          []resources.Dimming{},
+		ColorTemperature: []resources.ScenePaletteColorTemperature{},
+	}
+
+	if colors, ok := args["colors"].([]interface{}); ok {
+		for i, item := range colors {
+			color, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("palette color %d: invalid color", i+1)
+			}
+			x, xOk := color["x"].(float64)
+			y, yOk := color["y"].(float64)
+			if !xOk || !yOk {
+				return nil, fmt.Errorf("palette color %d: x and y are required", i+1)
+			}
+
+			entry := resources.ScenePaletteColor{Color: resources.Color{XY: resources.ColorXY{X: x, Y: y}}}
+			if brightness, ok := color["brightness"].(float64); ok {
+				entry.Dimming = &resources.Dimming{Brightness: brightness}
+			}
+			palette.Color = append(palette.Color, entry)
+		}
+	}
+
+	if temps, ok := args["color_temperatures"].([]interface{}); ok {
+		for i, item := range temps {
+			temp, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("palette color temperature %d: invalid color temperature", i+1)
+			}
+			mirek, mirekOk := temp["mirek"].(float64)
+			brightness, brightnessOk := temp["brightness"].(float64)
+			if !mirekOk || !brightnessOk {
+				return nil, fmt.Errorf("palette color temperature %d: mirek and brightness are required", i+1)
+			}
+
+			palette.ColorTemperature = append(palette.ColorTemperature, resources.ScenePaletteColorTemperature{
+				ColorTemperature: resources.ColorTemperature{Mirek: int(mirek)},
+				Dimming:          resources.Dimming{Brightness: brightness},
+			})
+		}
+	}
+
+	if len(palette.Color) == 0 && len(palette.ColorTemperature) == 0 {
+		return nil, fmt.Errorf("palette needs at least one color or color temperature")
+	}
+
+	return palette, nil
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// sensorServices are the sensor services that can be enabled and disabled
+var sensorServices = []string{"motion", "temperature", "light_level"}
+
+// RegisterSensorTools registers all sensor tools
+func RegisterSensorTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// list_sensors tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_sensors",
+			Description: "List motion, temperature and light level sensors with their current readings (°C and °F, lux), enabled state and when each reading last changed",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists sensors from all bridges",
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional room name to only list the sensors in that room",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+			room := request.GetString("room", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			allSensors := []bridge.Sensor{}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				sensors, err := br.Sensors(ctx)
+				if err != nil {
+					continue
+				}
+
+				for _, sensor := range sensors {
+					if room != "" && !strings.EqualFold(sensor.Room, room) {
+						continue
+					}
+					allSensors = append(allSensors, sensor)
+				}
+			}
+
+			data, err := json.MarshalIndent(allSensors, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal sensors: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_sensor tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_sensor",
+			Description: "Get the current readings of a sensor device: motion, temperature in °C and °F, and light level in lux, each with when it last changed",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"sensor_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The sensor device ID, name or alias, or the ID of one of its motion, temperature or light level services",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"sensor_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("sensor_id")
+			if err != nil {
+				return mcp.NewToolResultError("sensor_id is required"), nil
+			}
+
+			_, sensor, err := findSensor(ctx, bm, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			data, err := json.MarshalIndent(sensor, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal sensor: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// enable_sensor and disable_sensor tools
+	for _, action := range []string{"enable", "disable"} {
+		action := action
+		enabled := action == "enable"
+
+		description := "Enable the sensor services of a sensor device so it reports readings and can trigger automations."
+		if !enabled {
+			description = "Disable the sensor services of a sensor device. A disabled motion sensor no longer triggers automations."
+		}
+
+		s.AddTool(
+			mcp.Tool{
+				Name:        action + "_sensor",
+				Description: description,
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"sensor_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The sensor device ID, name or alias, or the ID of one of its services",
+						},
+						"services": map[string]interface{}{
+							"type":        "array",
+							"description": "Optional services to " + action + ". Defaults to every sensor service on the device",
+							"items": map[string]interface{}{
+								"type": "string",
+								"enum": sensorServices,
+							},
+						},
+						"bridge_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+						},
+						"dry_run":       dryRunArgument,
+						"confirm_token": confirmTokenArgument,
+					},
+					Required: []string{"sensor_id"},
+				},
+			},
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				query, err := request.RequireString("sensor_id")
+				if err != nil {
+					return mcp.NewToolResultError("sensor_id is required"), nil
+				}
+
+				br, sensor, err := findSensor(ctx, bm, res, query, request.GetString("bridge_id", ""))
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				available := sensor.ServiceIDs()
+				services := request.GetStringSlice("services", nil)
+				if len(services) == 0 {
+					services = sensorServices
+				}
+
+				var commands []dryRunCommand
+				for _, service := range services {
+					id, ok := available[service]
+					if !ok {
+						if len(request.GetStringSlice("services", nil)) > 0 {
+							return mcp.NewToolResultError(fmt.Sprintf("%s has no %s service", sensor.Name, service)), nil
+						}
+						continue
+					}
+					commands = append(commands, dryRunCommand{
+						BridgeID: br.ID,
+						Method:   service + ".update",
+						TargetID: id,
+						Payload:  map[string]bool{"enabled": enabled},
+					})
+				}
+
+				if isDryRun(ctx) {
+					return newDryRun(commands...).result()
+				}
+
+				for _, command := range commands {
+					service, id := strings.TrimSuffix(command.Method, ".update"), command.TargetID
+					err := br.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+						return setSensorEnabled(ctx, br, service, id, enabled)
+					})
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to %s %s service: %v", action, service, err)), nil
+					}
+				}
+
+				return mcp.NewToolResultText(fmt.Sprintf("✅ %s: %d sensor service(s) %sd", sensor.Name, len(commands), action)), nil
+			},
+		)
+	}
+}
+
+// findSensor finds a sensor by device or service ID, or by device name or
+// alias
+func findSensor(ctx context.Context, bm *bridge.Manager, res *resolver.Resolver, query, bridgeID string) (*bridge.Bridge, bridge.Sensor, error) {
+	var bridges []*bridge.Bridge
+	if bridgeID != "" {
+		br, err := bm.GetBridge(bridgeID)
+		if err != nil {
+			return nil, bridge.Sensor{}, err
+		}
+		bridges = []*bridge.Bridge{br}
+	} else {
+		bridges = bm.ListBridges()
+	}
+
+	for _, br := range bridges {
+		if !br.Connected {
+			continue
+		}
+		sensors, err := br.Sensors(ctx)
+		if err != nil {
+			continue
+		}
+		for _, sensor := range sensors {
+			if sensor.DeviceID == query {
+				return br, sensor, nil
+			}
+			for _, id := range sensor.ServiceIDs() {
+				if id == query {
+					return br, sensor, nil
+				}
+			}
+		}
+	}
+
+	device, err := resolveDevice(ctx, res, query, bridgeID)
+	if err != nil {
+		return nil, bridge.Sensor{}, err
+	}
+
+	sensors, err := device.Bridge.Sensors(ctx)
+	if err != nil {
+		return nil, bridge.Sensor{}, err
+	}
+	for _, sensor := range sensors {
+		if sensor.DeviceID == device.ID {
+			return device.Bridge, sensor, nil
+		}
+	}
+
+	return nil, bridge.Sensor{}, fmt.Errorf("%s is not a sensor; use list_sensors to see the available sensors", device.Label())
+}
+
+// setSensorEnabled enables or disables one sensor service
+func setSensorEnabled(ctx context.Context, br *bridge.Bridge, service, id string, enabled bool) error {
+	switch service {
+	case "motion":
+		return br.CachedClient.Motion().Update(ctx, id, resources.MotionUpdate{Enabled: &enabled})
+	case "temperature":
+		return br.CachedClient.Temperature().Update(ctx, id, resources.TemperatureUpdate{Enabled: &enabled})
+	case "light_level":
+		return br.CachedClient.LightLevel().Update(ctx, id, resources.LightLevelUpdate{Enabled: &enabled})
+	default:
+		return fmt.Errorf("unknown sensor service %q", service)
+	}
+}
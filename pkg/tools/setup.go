@@ -178,12 +178,13 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 			}
 
 			result := map[string]string{
-				"bridge_ip": bridgeIP,
-				"app_key":   authResp.Success.Username,
-				"app_name":  appName,
-				"device":    deviceName,
-				"status":    "✅ Authentication successful!",
-				"next_step": "Use add_bridge to save this configuration",
+				"bridge_ip":  bridgeIP,
+				"app_key":    authResp.Success.Username,
+				"client_key": authResp.Success.ClientKey,
+				"app_name":   appName,
+				"device":     deviceName,
+				"status":     "✅ Authentication successful!",
+				"next_step":  "Use add_bridge to save this configuration",
 			}
 
 			data, _ := json.MarshalIndent(result, "", "  ")
@@ -215,6 +216,10 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 						"type":        "string",
 						"description": "App key from authenticate_bridge",
 					},
+					"client_key": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional client key from authenticate_bridge, needed for entertainment streaming",
+					},
 				},
 				Required: []string{"bridge_id", "bridge_name", "bridge_ip", "app_key"},
 			},
@@ -242,11 +247,12 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 
 			// Add bridge to configuration
 			bridgeCfg := config.BridgeConfig{
-				ID:      bridgeID,
-				Name:    bridgeName,
-				IP:      bridgeIP,
-				AppKey:  appKey,
-				Enabled: true,
+				ID:        bridgeID,
+				Name:      bridgeName,
+				IP:        bridgeIP,
+				AppKey:    appKey,
+				ClientKey: request.GetString("client_key", ""),
+				Enabled:   true,
 			}
 
 			if err := cfg.AddBridge(bridgeCfg); err != nil {
@@ -289,6 +295,8 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 						"type":        "string",
 						"description": "ID of the bridge to remove",
 					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
 				},
 				Required: []string{"bridge_id"},
 			},
@@ -299,6 +307,13 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 				return mcp.NewToolResultError("bridge_id is required"), nil
 			}
 
+			if isDryRun(ctx) {
+				if _, err := cfg.GetBridge(bridgeID); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to remove bridge: %v", err)), nil
+				}
+				return newDryRun(dryRunCommand{BridgeID: bridgeID, Method: "config.remove_bridge", TargetID: bridgeID}).result()
+			}
+
 			if err := cfg.RemoveBridge(bridgeID); err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("Failed to remove bridge: %v", err)), nil
 			}
@@ -315,6 +330,48 @@ func RegisterSetupTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Con
 		},
 	)
 
+	// set_default_bridge tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "set_default_bridge",
+			Description: "Set the bridge used when creating new resources. Existing lights, rooms and scenes are always routed to the bridge they live on.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the bridge to use by default. Empty to use the first configured bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"bridge_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+
+			if isDryRun(ctx) {
+				if bridgeID != "" {
+					if _, err := cfg.GetBridge(bridgeID); err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to set default bridge: %v", err)), nil
+					}
+				}
+				return newDryRun(dryRunCommand{BridgeID: bridgeID, Method: "config.set_default_bridge", Payload: bridgeID}).result()
+			}
+
+			if err := cfg.SetDefaultBridge(bridgeID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to set default bridge: %v", err)), nil
+			}
+
+			if bridgeID == "" {
+				return mcp.NewToolResultText("✅ Default bridge cleared; the first configured bridge will be used"), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Bridge '%s' is now the default bridge", bridgeID)), nil
+		},
+	)
+
 	// get_config_path tool
 	s.AddTool(
 		mcp.Tool{
@@ -0,0 +1,608 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-mcp/pkg/show"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// showFormatHelp describes the show format in tool descriptions
+const showFormatHelp = `A show is JSON or YAML: {"name", "description"?, "duration"? (seconds, default the last keyframe), "loops"? (times to play, default 1), "loop"? (repeat until stopped), "tracks": [{"lights": [...] | "room" | "zone", "bridge_id"?, "loop"? (repeat this track's keyframes), "keyframes": [{"time" (seconds), "on"?, "brightness"? (0-100), "color"? (name or hex code) | "color_xy"? {x,y} | "color_temp"? (153-500 mirek), "easing"? (linear, ease_in, ease_out, ease_in_out, step)}]}]}. Fields a keyframe leaves out keep moving between the keyframes that set them; easing shapes the move into the keyframe; on switches at the keyframe. Rooms and zones are played as one group and update at most about once a second.`
+
+// showSourceProperties are the arguments that name a show to read
+var showSourceProperties = map[string]interface{}{
+	"content": map[string]interface{}{
+		"type":        "string",
+		"description": "The show as JSON or YAML text",
+	},
+	"name": map[string]interface{}{
+		"type":        "string",
+		"description": "Name of a saved show, instead of content",
+	},
+}
+
+// RegisterShowTools registers the tools that validate, play, control and
+// export keyframe light shows
+func RegisterShowTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver, shows *show.Engine, owners *lightOwners) {
+	// validate_light_show tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "validate_light_show",
+			Description: "Check a light show without playing it: its structure, values, colors and that every track's lights, room or zone exists. Returns the problems found, or a summary with the step interval the bridges' rate limits allow. " + showFormatHelp,
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: showSourceProperties,
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sh, err := showFromArgs(request, shows)
+			if err != nil {
+				return showError(err), nil
+			}
+
+			cfg, _, err := showConfig(ctx, bm, res, sh)
+			if err != nil {
+				return showError(err), nil
+			}
+			interval, _, err := show.Plan(cfg)
+			if err != nil {
+				return showError(err), nil
+			}
+
+			type trackSummary struct {
+				Name      string   `json:"name,omitempty"`
+				Targets   []string `json:"targets"`
+				Keyframes int      `json:"keyframes"`
+				Loop      bool     `json:"loop,omitempty"`
+			}
+			summary := struct {
+				Valid      bool           `json:"valid"`
+				Name       string         `json:"name"`
+				Length     float64        `json:"length_seconds"`
+				Loops      int            `json:"loops,omitempty"`
+				Loop       bool           `json:"loop,omitempty"`
+				IntervalMS int64          `json:"step_interval_ms"`
+				Tracks     []trackSummary `json:"tracks"`
+			}{
+				Valid:      true,
+				Name:       sh.Name,
+				Length:     sh.Length(),
+				Loops:      sh.Loops,
+				Loop:       sh.Loop,
+				IntervalMS: interval.Milliseconds(),
+			}
+			for i, track := range sh.Tracks {
+				ts := trackSummary{Name: track.Name, Keyframes: len(track.Keyframes), Loop: track.Loop}
+				for _, target := range cfg.Targets[i] {
+					label := target.Name
+					if target.Group {
+						label += " (group)"
+					}
+					ts.Targets = append(ts.Targets, label)
+				}
+				summary.Tracks = append(summary.Tracks, ts)
+			}
+
+			data, err := json.MarshalIndent(summary, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal summary: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// play_light_show tool
+	playProperties := map[string]interface{}{
+		"restore": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Restore the lights' state from before the show when it ends or is stopped (default true)",
+		},
+		"dry_run":       dryRunArgument,
+		"confirm_token": confirmTokenArgument,
+	}
+	for k, v := range showSourceProperties {
+		playProperties[k] = v
+	}
+	s.AddTool(
+		mcp.Tool{
+			Name:        "play_light_show",
+			Description: "Play a light show through the bridges' rate-limited command path. Every track is sampled once per step and only changed lights are sent, each fading to where the show will be at the next step. Returns a show ID for pause_light_show, resume_light_show, seek_light_show and stop_light_show. " + showFormatHelp,
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: playProperties,
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sh, err := showFromArgs(request, shows)
+			if err != nil {
+				return showError(err), nil
+			}
+
+			cfg, previous, err := showConfig(ctx, bm, res, sh)
+			if err != nil {
+				return showError(err), nil
+			}
+			interval, first, err := show.Plan(cfg)
+			if err != nil {
+				return showError(err), nil
+			}
+
+			restore := request.GetBool("restore", true)
+
+			report := newDryRun()
+			for i, targets := range cfg.Targets {
+				for j, target := range targets {
+					method, payload := "lights.update", interface{}(first[i][j])
+					if target.Group {
+						method, payload = "grouped_lights.update", groupedUpdate(first[i][j])
+					}
+					report.Commands = append(report.Commands, dryRunCommand{BridgeID: target.BridgeID, Method: method, TargetID: target.ID, Payload: payload})
+				}
+			}
+			for _, state := range previous {
+				if br, err := bm.GetBridge(state.BridgeID); err == nil {
+					report.affectLights(ctx, br, []string{state.LightID}, fieldChange{Field: "light_show", From: nil, To: sh.Name})
+				}
+			}
+			report.Plan = map[string]interface{}{
+				"show":             sh.Name,
+				"length_seconds":   sh.Length(),
+				"loops":            sh.Loops,
+				"loop":             sh.Loop,
+				"step_interval_ms": interval.Milliseconds(),
+				"restore":          restore,
+			}
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Commands are the first step; a step is sent every %s", interval))
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			claim, err := owners.claim("a light show", previous)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			status, err := shows.Play(cfg, func(ctx context.Context) error {
+				defer claim.release()
+				if restore {
+					restoreLights(ctx, bm, previous)
+				}
+				return nil
+			})
+			if err != nil {
+				claim.release()
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to play show: %v", err)), nil
+			}
+			claim.rename("show " + status.ID)
+
+			length := fmt.Sprintf("%.1fs", status.Length)
+			switch {
+			case sh.Loop:
+				length += ", looping until stopped"
+			case sh.Loops > 1:
+				length += fmt.Sprintf(" × %d", sh.Loops)
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Playing show %q (id %s, %s) on %d target(s), one step every %s", sh.Name, status.ID, length, len(status.Targets), interval)), nil
+		},
+	)
+
+	// pause_light_show tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "pause_light_show",
+			Description: "Pause a playing light show. The lights hold their current state until it is resumed, sought or stopped.",
+			InputSchema: showIDSchema(),
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("show_id")
+			if err != nil {
+				return mcp.NewToolResultError("show_id is required"), nil
+			}
+
+			if isDryRun(ctx) {
+				return showDryRun(shows, id, "would pause")
+			}
+
+			status, err := shows.Pause(id)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("⏸️ Show %q paused at %.1fs of %.1fs", status.Name, status.Position, status.Length)), nil
+		},
+	)
+
+	// resume_light_show tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "resume_light_show",
+			Description: "Resume a paused light show from where it was paused",
+			InputSchema: showIDSchema(),
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("show_id")
+			if err != nil {
+				return mcp.NewToolResultError("show_id is required"), nil
+			}
+
+			if isDryRun(ctx) {
+				return showDryRun(shows, id, "would resume")
+			}
+
+			status, err := shows.Resume(id)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("▶️ Show %q resumed at %.1fs of %.1fs", status.Name, status.Position, status.Length)), nil
+		},
+	)
+
+	// seek_light_show tool
+	seekSchema := showIDSchema()
+	seekSchema.Properties["position_seconds"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Position to jump to, in seconds from the start. For shows that play more than once, positions count across plays.",
+		"minimum":     0,
+	}
+	seekSchema.Required = append(seekSchema.Required, "position_seconds")
+	s.AddTool(
+		mcp.Tool{
+			Name:        "seek_light_show",
+			Description: "Jump a light show to a position and send every target its state there. A paused show stays paused at the new position.",
+			InputSchema: seekSchema,
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("show_id")
+			if err != nil {
+				return mcp.NewToolResultError("show_id is required"), nil
+			}
+			position, err := request.RequireFloat("position_seconds")
+			if err != nil {
+				return mcp.NewToolResultError("position_seconds is required"), nil
+			}
+
+			if isDryRun(ctx) {
+				return showDryRun(shows, id, fmt.Sprintf("would jump to %.1fs", position))
+			}
+
+			status, err := shows.Seek(id, position)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("⏩ Show %q moved to %.1fs of %.1fs", status.Name, status.Position, status.Length)), nil
+		},
+	)
+
+	// stop_light_show tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "stop_light_show",
+			Description: "Stop a light show, or all of them, restoring the lights if the show was played with restore",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"show_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The show ID from play_light_show or list_light_shows, or \"all\"",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"show_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("show_id")
+			if err != nil {
+				return mcp.NewToolResultError("show_id is required"), nil
+			}
+
+			if id == "all" {
+				playing := shows.List()
+				if len(playing) == 0 {
+					return mcp.NewToolResultText("No shows are playing"), nil
+				}
+				if isDryRun(ctx) {
+					report := newDryRun()
+					report.Plan = playing
+					report.Warnings = append(report.Warnings, fmt.Sprintf("%d show(s) would stop", len(playing)))
+					return report.result()
+				}
+
+				stopped, err := shows.StopAll(ctx)
+				if err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("⚠️ Stopped %d show(s), but restoring some lights failed: %v", len(stopped), err)), nil
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Stopped %d show(s)", len(stopped))), nil
+			}
+
+			if isDryRun(ctx) {
+				return showDryRun(shows, id, "would stop")
+			}
+
+			status, err := shows.Stop(ctx, id)
+			if err != nil {
+				if status.ID == "" {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("⚠️ Show %q stopped at %.1fs, but restoring the lights failed: %v", status.Name, status.Position, err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Show %q stopped at %.1fs after %d update(s)", status.Name, status.Position, status.Updates)), nil
+		},
+	)
+
+	// list_light_shows tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_light_shows",
+			Description: "List playing light shows with their position and progress, and the shows saved with export_light_show",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			saved, err := show.List(config.ShowsDir())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			data, err := json.MarshalIndent(map[string]interface{}{
+				"playing": shows.List(),
+				"saved":   saved,
+			}, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal shows: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// export_light_show tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "export_light_show",
+			Description: "Validate a light show and write it out as JSON or YAML, saving it under its name in the shows directory so it can be played again by name. Exports the show text given, a saved show, or a playing show by ID.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"content": showSourceProperties["content"],
+					"name":    showSourceProperties["name"],
+					"show_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a playing show, instead of content",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Output format (default yaml)",
+						"enum":        show.Formats,
+					},
+					"save": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Save the show to the shows directory (default true)",
+					},
+					"overwrite": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace a saved show with the same name",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sh, err := showFromArgs(request, shows)
+			if err != nil {
+				return showError(err), nil
+			}
+			if err := sh.Validate(); err != nil {
+				return showError(err), nil
+			}
+
+			format := request.GetString("format", "yaml")
+			data, err := show.Marshal(sh, format)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if !request.GetBool("save", true) {
+				return mcp.NewToolResultText(string(data)), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{Method: "shows.save", TargetID: sh.Name, Payload: map[string]interface{}{
+					"directory": config.ShowsDir(),
+					"format":    format,
+					"overwrite": request.GetBool("overwrite", false),
+				}}).result()
+			}
+
+			path, err := show.Save(config.ShowsDir(), sh, format, request.GetBool("overwrite", false))
+			if err != nil {
+				return showError(err), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Show %q saved to %s\n\n%s", sh.Name, path, data)), nil
+		},
+	)
+}
+
+// showIDSchema is the input schema of the tools that control a playing show
+func showIDSchema() mcp.ToolInputSchema {
+	return mcp.ToolInputSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"show_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The show ID from play_light_show or list_light_shows",
+			},
+			"dry_run":       dryRunArgument,
+			"confirm_token": confirmTokenArgument,
+		},
+		Required: []string{"show_id"},
+	}
+}
+
+// showFromArgs reads the show named by the content, name or show_id
+// argument
+func showFromArgs(request mcp.CallToolRequest, shows *show.Engine) (*show.Show, error) {
+	if content := request.GetString("content", ""); content != "" {
+		return show.Parse([]byte(content))
+	}
+	if name := request.GetString("name", ""); name != "" {
+		return show.Load(config.ShowsDir(), name)
+	}
+	if id := request.GetString("show_id", ""); id != "" {
+		return shows.Show(id)
+	}
+	return nil, errors.New("one of content, name or show_id is required")
+}
+
+// showError reports a show error, listing validation problems one per line
+func showError(err error) *mcp.CallToolResult {
+	var invalid *show.ValidationError
+	if errors.As(err, &invalid) {
+		return mcp.NewToolResultError(fmt.Sprintf("The show has %d problem(s):\n- %s", len(invalid.Problems), strings.Join(invalid.Problems, "\n- ")))
+	}
+	return mcp.NewToolResultError(err.Error())
+}
+
+// showDryRun reports what a show control tool would do
+func showDryRun(shows *show.Engine, id, action string) (*mcp.CallToolResult, error) {
+	for _, status := range shows.List() {
+		if status.ID == id {
+			report := newDryRun()
+			report.Plan = status
+			report.Warnings = append(report.Warnings, fmt.Sprintf("Show %q %s", status.Name, action))
+			return report.result()
+		}
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("no show %q is playing", id)), nil
+}
+
+// showConfig resolves a show's tracks to targets and captures the state of
+// every light it touches so it can be restored
+func showConfig(ctx context.Context, bm *bridge.Manager, res *resolver.Resolver, sh *show.Show) (show.Config, []snapshot.LightState, error) {
+	if err := sh.Validate(); err != nil {
+		return show.Config{}, nil, err
+	}
+
+	cfg := show.Config{
+		Show:    sh,
+		Targets: make([][]show.Target, len(sh.Tracks)),
+		Rates:   make(map[string]show.Rates),
+	}
+	touched := make(map[string][]string)
+	bridges := make(map[string]*bridge.Bridge)
+	capability := make(map[string]*resources.Light)
+
+	for i, track := range sh.Tracks {
+		for _, query := range track.Lights {
+			light, err := res.Resolve(ctx, resolver.KindLight, query, track.BridgeID)
+			if err != nil {
+				return show.Config{}, nil, fmt.Errorf("tracks[%d]: %w", i, err)
+			}
+			cfg.Targets[i] = append(cfg.Targets[i], show.Target{BridgeID: light.BridgeID, ID: light.ID, Name: light.Name})
+			touched[light.BridgeID] = append(touched[light.BridgeID], light.ID)
+			bridges[light.BridgeID] = light.Bridge
+
+			if l, err := light.Bridge.CachedClient.Lights().Get(ctx, light.ID); err == nil {
+				capability[animationKey(light.BridgeID, light.ID)] = l
+			}
+		}
+
+		if track.Room != "" || track.Zone != "" {
+			kind, query := resolver.KindRoom, track.Room
+			if track.Zone != "" {
+				kind, query = resolver.KindZone, track.Zone
+			}
+			owner, err := res.Resolve(ctx, kind, query, track.BridgeID)
+			if err != nil {
+				return show.Config{}, nil, fmt.Errorf("tracks[%d]: %w", i, err)
+			}
+			group, err := res.GroupedLightFor(ctx, owner)
+			if err != nil {
+				return show.Config{}, nil, fmt.Errorf("tracks[%d]: %w", i, err)
+			}
+			lightIDs, err := groupLightIDs(ctx, owner.Bridge, resources.ResourceIdentifier{RID: owner.ID, RType: string(owner.Kind)})
+			if err != nil {
+				return show.Config{}, nil, fmt.Errorf("tracks[%d]: %w", i, err)
+			}
+
+			cfg.Targets[i] = append(cfg.Targets[i], show.Target{BridgeID: group.BridgeID, ID: group.ID, Name: owner.Name, Group: true})
+			touched[owner.BridgeID] = append(touched[owner.BridgeID], lightIDs...)
+			bridges[owner.BridgeID] = owner.Bridge
+		}
+	}
+
+	for id, br := range bridges {
+		cfg.Rates[id] = show.Rates{Light: br.Scheduler.LightRate(), Group: br.Scheduler.GroupRate()}
+	}
+
+	cfg.Send = func(ctx context.Context, target show.Target, update resources.LightUpdate) error {
+		br, err := bm.GetBridge(target.BridgeID)
+		if err != nil {
+			return err
+		}
+
+		if target.Group {
+			return br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return br.CachedClient.GroupedLights().Update(ctx, target.ID, groupedUpdate(update))
+			})
+		}
+
+		// Leave out what the light cannot show rather than have the
+		// bridge reject the whole update
+		if light := capability[animationKey(target.BridgeID, target.ID)]; light != nil {
+			if light.Color == nil {
+				update.Color = nil
+			}
+			if light.ColorTemperature == nil {
+				update.ColorTemperature = nil
+			}
+		}
+		return br.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+			return br.CachedClient.Lights().Update(ctx, target.ID, update)
+		})
+	}
+
+	var previous []snapshot.LightState
+	for id, lightIDs := range touched {
+		states, err := snapshot.Capture(ctx, bridges[id], lightIDs)
+		if err != nil {
+			return show.Config{}, nil, err
+		}
+		previous = append(previous, states...)
+	}
+
+	return cfg, previous, nil
+}
+
+// groupedUpdate expresses a light update as a grouped light update
+func groupedUpdate(update resources.LightUpdate) resources.GroupedLightUpdate {
+	return resources.GroupedLightUpdate{
+		On:               update.On,
+		Dimming:          update.Dimming,
+		Color:            update.Color,
+		ColorTemperature: update.ColorTemperature,
+		Dynamics:         update.Dynamics,
+	}
+}
@@ -0,0 +1,590 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// weekdays are the recurrence values accepted by smart scene timeslots
+var weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// timeslotInfo is a smart scene timeslot in readable form
+type timeslotInfo struct {
+	Start   string `json:"start"`
+	SceneID string `json:"scene_id"`
+	Scene   string `json:"scene,omitempty"`
+}
+
+// weekTimeslotInfo is a set of timeslots and the weekdays they apply to
+type weekTimeslotInfo struct {
+	Weekdays  []string       `json:"weekdays"`
+	Timeslots []timeslotInfo `json:"timeslots"`
+}
+
+// activeTimeslotInfo is the timeslot a smart scene is currently in
+type activeTimeslotInfo struct {
+	Weekday string `json:"weekday"`
+	timeslotInfo
+}
+
+// RegisterSmartSceneTools registers all smart scene tools
+func RegisterSmartSceneTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// list_smart_scenes tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_smart_scenes",
+			Description: "List smart scenes (scenes that change automatically at set times of day) with their state and current timeslot",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists smart scenes from all bridges",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			type smartSceneInfo struct {
+				BridgeID       string              `json:"bridge_id"`
+				BridgeName     string              `json:"bridge_name"`
+				ID             string              `json:"id"`
+				Name           string              `json:"name"`
+				Group          string              `json:"group,omitempty"`
+				State          string              `json:"state"`
+				ActiveTimeslot *activeTimeslotInfo `json:"active_timeslot,omitempty"`
+			}
+
+			var allSmartScenes []smartSceneInfo
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				smartScenes, err := br.CachedClient.SmartScenes().List(ctx)
+				if err != nil {
+					continue
+				}
+
+				groupNames := sceneGroupNames(ctx, br)
+				sceneNames := sceneNamesByID(ctx, br)
+
+				for _, smartScene := range smartScenes {
+					allSmartScenes = append(allSmartScenes, smartSceneInfo{
+						BridgeID:       br.ID,
+						BridgeName:     br.Name,
+						ID:             smartScene.ID,
+						Name:           smartScene.Metadata.Name,
+						Group:          groupNames[smartScene.Group.RID],
+						State:          smartScene.State,
+						ActiveTimeslot: activeTimeslot(smartScene, sceneNames),
+					})
+				}
+			}
+
+			data, err := json.MarshalIndent(allSmartScenes, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal smart scenes: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_smart_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_smart_scene",
+			Description: "Get a smart scene with its weekday timeslots, the scene each timeslot recalls, and the timeslot that is currently active",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"smart_scene_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The smart scene ID, name, \"Room/Smart scene\" path, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"smart_scene_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "smart_scene_id", resolver.KindSmartScene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			smartScene, err := target.Bridge.CachedClient.SmartScenes().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get smart scene: %v", err)), nil
+			}
+
+			sceneNames := sceneNamesByID(ctx, target.Bridge)
+
+			type smartSceneDetails struct {
+				BridgeID           string              `json:"bridge_id"`
+				ID                 string              `json:"id"`
+				Name               string              `json:"name"`
+				Group              string              `json:"group,omitempty"`
+				GroupID            string              `json:"group_id"`
+				State              string              `json:"state"`
+				TransitionDuration int                 `json:"transition_duration_ms"`
+				ActiveTimeslot     *activeTimeslotInfo `json:"active_timeslot,omitempty"`
+				WeekTimeslots      []weekTimeslotInfo  `json:"week_timeslots"`
+			}
+
+			details := smartSceneDetails{
+				BridgeID:           target.BridgeID,
+				ID:                 smartScene.ID,
+				Name:               smartScene.Metadata.Name,
+				Group:              target.Group,
+				GroupID:            smartScene.Group.RID,
+				State:              smartScene.State,
+				TransitionDuration: smartScene.TransitionDuration,
+				ActiveTimeslot:     activeTimeslot(*smartScene, sceneNames),
+			}
+
+			for _, week := range smartScene.WeekTimeslots {
+				info := weekTimeslotInfo{Weekdays: week.Recurrence}
+				for _, slot := range week.Timeslots {
+					info.Timeslots = append(info.Timeslots, describeTimeslot(slot, sceneNames))
+				}
+				details.WeekTimeslots = append(details.WeekTimeslots, info)
+			}
+
+			data, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal smart scene: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// activate_smart_scene and deactivate_smart_scene tools
+	for _, action := range []string{"activate", "deactivate"} {
+		action := action
+
+		description := "Activate a smart scene. The bridge recalls the scene of the current timeslot and switches scenes as the day goes on."
+		if action == "deactivate" {
+			description = "Deactivate a smart scene so it stops switching scenes. The lights keep their current state."
+		}
+
+		s.AddTool(
+			mcp.Tool{
+				Name:        action + "_smart_scene",
+				Description: description,
+				InputSchema: mcp.ToolInputSchema{
+					Type: "object",
+					Properties: map[string]interface{}{
+						"smart_scene_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The smart scene ID, name, \"Room/Smart scene\" path, or alias",
+						},
+						"bridge_id": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+						},
+						"dry_run":       dryRunArgument,
+						"confirm_token": confirmTokenArgument,
+					},
+					Required: []string{"smart_scene_id"},
+				},
+			},
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				target, err := resolveArg(ctx, res, request, "smart_scene_id", resolver.KindSmartScene)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+
+				update := resources.SmartSceneUpdate{
+					Recall: &resources.SmartSceneRecall{Action: action},
+				}
+
+				report := newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "smart_scenes.update", TargetID: target.ID, Payload: update})
+				if action == "activate" {
+					smartScene, err := target.Bridge.CachedClient.SmartScenes().Get(ctx, target.ID)
+					if err != nil {
+						return mcp.NewToolResultError(fmt.Sprintf("Failed to get smart scene: %v", err)), nil
+					}
+					lightIDs, err := groupLightIDs(ctx, target.Bridge, smartScene.Group)
+					if err != nil {
+						report.Warnings = append(report.Warnings, err.Error())
+					}
+					report.affectLights(ctx, target.Bridge, lightIDs, fieldChange{Field: "smart_scene", From: nil, To: target.Name})
+				}
+				if result, done := report.preflight(ctx); done {
+					return result, nil
+				}
+
+				err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+					return target.Bridge.CachedClient.SmartScenes().Update(ctx, target.ID, update)
+				})
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to %s smart scene: %v", action, err)), nil
+				}
+
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Smart scene %s %sd successfully", target.Label(), action)), nil
+			},
+		)
+	}
+
+	// create_smart_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "create_smart_scene",
+			Description: "Create a smart scene for a room or zone that recalls different scenes at set times of day. Timeslots start at a clock time (\"07:30\") or at sunrise or sunset, and can differ per weekday.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the new smart scene",
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Room the smart scene belongs to (ID, name, or alias)",
+					},
+					"zone": map[string]interface{}{
+						"type":        "string",
+						"description": "Zone the smart scene belongs to (ID, name, or alias). Use instead of room",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"week_timeslots": map[string]interface{}{
+						"type":        "array",
+						"description": "Schedules, each applying a list of timeslots to a set of weekdays",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"weekdays": map[string]interface{}{
+									"type":        "array",
+									"description": "Weekdays this schedule applies to. Defaults to every day",
+									"items": map[string]interface{}{
+										"type": "string",
+										"enum": weekdays,
+									},
+								},
+								"timeslots": map[string]interface{}{
+									"type":        "array",
+									"description": "Timeslots in order of their start",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"start": map[string]interface{}{
+												"type":        "string",
+												"description": "Start of the timeslot: a time such as \"07:30\", or \"sunrise\" or \"sunset\"",
+											},
+											"scene": map[string]interface{}{
+												"type":        "string",
+												"description": "Scene to recall (ID, name, or alias). It must belong to the same room or zone",
+											},
+										},
+										"required": []string{"start", "scene"},
+									},
+								},
+							},
+							"required": []string{"timeslots"},
+						},
+					},
+					"transition_duration": map[string]interface{}{
+						"type":        "number",
+						"description": "Optional transition between timeslots in milliseconds",
+						"minimum":     0,
+					},
+					"activate": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Activate the smart scene right away",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name", "week_timeslots"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			owner, err := resolveGroupOwner(ctx, res, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			args := request.GetArguments()
+
+			weeks, err := weekTimeslotsFromArgs(ctx, res, owner, args["week_timeslots"])
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			create := resources.SmartSceneCreate{
+				Metadata:      resources.Metadata{Name: name},
+				Group:         resources.ResourceIdentifier{RID: owner.ID, RType: string(owner.Kind)},
+				WeekTimeslots: weeks,
+			}
+
+			if duration, ok := args["transition_duration"].(float64); ok {
+				durationMs := int(duration)
+				create.TransitionDuration = &durationMs
+			}
+
+			activate := request.GetBool("activate", false)
+			if activate {
+				create.Recall = &resources.SmartSceneRecall{Action: "activate"}
+			}
+
+			// Activating right away changes the group's lights, so they are
+			// reported for confirmation and the automatic snapshot
+			report := newDryRun(dryRunCommand{BridgeID: owner.BridgeID, Method: "smart_scenes.create", Payload: create})
+			if activate {
+				lightIDs, err := groupLightIDs(ctx, owner.Bridge, create.Group)
+				if err != nil {
+					report.Warnings = append(report.Warnings, err.Error())
+				}
+				report.affectLights(ctx, owner.Bridge, lightIDs, fieldChange{Field: "smart_scene", From: nil, To: name})
+			}
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			var createdID string
+			err = owner.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				created, err := owner.Bridge.CachedClient.SmartScenes().Create(ctx, create)
+				if err != nil {
+					return err
+				}
+				createdID = created.RID
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create smart scene: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, owner.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Smart scene %q created (id %s), but refreshing the index failed: %v", name, createdID, err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Smart scene %q created (id %s) in %s %s", name, createdID, owner.Kind, owner.Label())), nil
+		},
+	)
+
+	// delete_smart_scene tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_smart_scene",
+			Description: "Delete a smart scene. The scenes it recalls are not affected.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"smart_scene_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The smart scene ID, name, \"Room/Smart scene\" path, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"smart_scene_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "smart_scene_id", resolver.KindSmartScene)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "smart_scenes.delete", TargetID: target.ID}).result()
+			}
+
+			if err := target.Bridge.CachedClient.SmartScenes().Delete(ctx, target.ID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete smart scene: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Smart scene %s deleted, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Smart scene %s deleted successfully", target.Label())), nil
+		},
+	)
+}
+
+// sceneNamesByID maps the scene IDs on a bridge to their names
+func sceneNamesByID(ctx context.Context, br *bridge.Bridge) map[string]string {
+	names := make(map[string]string)
+	if scenes, err := br.CachedClient.Scenes().List(ctx); err == nil {
+		for _, scene := range scenes {
+			names[scene.ID] = scene.Metadata.Name
+		}
+	}
+	return names
+}
+
+// activeTimeslot returns the timeslot a smart scene is currently in, or nil
+// if it is inactive
+func activeTimeslot(smartScene resources.SmartScene, sceneNames map[string]string) *activeTimeslotInfo {
+	active := smartScene.ActiveTimeslot
+	if active == nil || smartScene.State != "active" {
+		return nil
+	}
+
+	for _, week := range smartScene.WeekTimeslots {
+		if !containsString(week.Recurrence, active.Weekday) {
+			continue
+		}
+		if active.TimeslotID < 0 || active.TimeslotID >= len(week.Timeslots) {
+			break
+		}
+
+		return &activeTimeslotInfo{
+			Weekday:      active.Weekday,
+			timeslotInfo: describeTimeslot(week.Timeslots[active.TimeslotID], sceneNames),
+		}
+	}
+
+	return &activeTimeslotInfo{Weekday: active.Weekday}
+}
+
+// describeTimeslot converts a timeslot to its readable form
+func describeTimeslot(slot resources.SmartSceneTimeslot, sceneNames map[string]string) timeslotInfo {
+	start := slot.StartTime.Kind
+	if slot.StartTime.Kind == "time" && slot.StartTime.Time != nil {
+		start = fmt.Sprintf("%02d:%02d", slot.StartTime.Time.Hour, slot.StartTime.Time.Minute)
+	}
+
+	return timeslotInfo{
+		Start:   start,
+		SceneID: slot.Target.RID,
+		Scene:   sceneNames[slot.Target.RID],
+	}
+}
+
+// weekTimeslotsFromArgs parses and validates the schedules of a smart scene
+func weekTimeslotsFromArgs(ctx context.Context, res *resolver.Resolver, owner *resolver.Entry, raw interface{}) ([]resources.SmartSceneWeekTimeslot, error) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("week_timeslots must be a non-empty array")
+	}
+
+	seenDays := make(map[string]bool)
+	var weeks []resources.SmartSceneWeekTimeslot
+
+	for i, item := range list {
+		args, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schedule %d: invalid schedule", i+1)
+		}
+
+		week := resources.SmartSceneWeekTimeslot{Recurrence: weekdays}
+		if days, ok := args["weekdays"].([]interface{}); ok && len(days) > 0 {
+			week.Recurrence = nil
+			for _, day := range days {
+				name, _ := day.(string)
+				name = strings.ToLower(name)
+				if !containsString(weekdays, name) {
+					return nil, fmt.Errorf("schedule %d: invalid weekday %q", i+1, day)
+				}
+				week.Recurrence = append(week.Recurrence, name)
+			}
+		}
+
+		// The bridge picks the schedule for a day by its recurrence, so days must not overlap
+		for _, day := range week.Recurrence {
+			if seenDays[day] {
+				return nil, fmt.Errorf("schedule %d: %s is already covered by another schedule", i+1, day)
+			}
+			seenDays[day] = true
+		}
+
+		slots, ok := args["timeslots"].([]interface{})
+		if !ok || len(slots) == 0 {
+			return nil, fmt.Errorf("schedule %d: timeslots must be a non-empty array", i+1)
+		}
+
+		for j, rawSlot := range slots {
+			slot, ok := rawSlot.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schedule %d, timeslot %d: invalid timeslot", i+1, j+1)
+			}
+
+			startArg, _ := slot["start"].(string)
+			start, err := parseTimeslotStart(startArg)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %d, timeslot %d: %w", i+1, j+1, err)
+			}
+
+			sceneArg, _ := slot["scene"].(string)
+			scene, err := res.Resolve(ctx, resolver.KindScene, sceneArg, owner.BridgeID)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %d, timeslot %d: %w", i+1, j+1, err)
+			}
+			if scene.GroupID != owner.ID {
+				return nil, fmt.Errorf("schedule %d, timeslot %d: scene %s does not belong to %s %s", i+1, j+1, scene.Label(), owner.Kind, owner.Label())
+			}
+
+			week.Timeslots = append(week.Timeslots, resources.SmartSceneTimeslot{
+				StartTime: start,
+				Target:    resources.ResourceIdentifier{RID: scene.ID, RType: "scene"},
+			})
+		}
+
+		weeks = append(weeks, week)
+	}
+
+	return weeks, nil
+}
+
+// parseTimeslotStart parses "HH:MM", "HH:MM:SS", "sunrise" or "sunset"
+func parseTimeslotStart(value string) (resources.TimeslotStartTime, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch value {
+	case "sunrise", "sunset":
+		return resources.TimeslotStartTime{Kind: value}, nil
+	case "":
+		return resources.TimeslotStartTime{}, fmt.Errorf("start is required")
+	}
+
+	var t resources.TimeOfDay
+	n, _ := fmt.Sscanf(value, "%d:%d:%d", &t.Hour, &t.Minute, &t.Second)
+	if n < 2 || t.Hour < 0 || t.Hour > 23 || t.Minute < 0 || t.Minute > 59 || t.Second < 0 || t.Second > 59 {
+		return resources.TimeslotStartTime{}, fmt.Errorf("invalid start %q: use HH:MM, sunrise or sunset", value)
+	}
+
+	return resources.TimeslotStartTime{Kind: "time", Time: &t}, nil
+}
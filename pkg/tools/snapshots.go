@@ -0,0 +1,509 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/planner"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// lightStateTools are the tools that change light state. An automatic
+// snapshot of the lights each call changes is taken before it so
+// undo_last_change can revert it.
+var lightStateTools = map[string]bool{
+	"control_light":        true,
+	"control_lights":       true,
+	"control_room_lights":  true,
+	"set_gradient":         true,
+	"activate_scene":       true,
+	"activate_smart_scene": true,
+	"create_smart_scene":   true,
+	"restore_snapshot":     true,
+	"start_stream_effect":  true,
+	"start_animation":      true,
+	"play_light_show":      true,
+}
+
+// SnapshotMiddleware takes an automatic snapshot before every tool that
+// changes light state. Only the lights the tool reports it is about to
+// change are captured, so undoing the call leaves every other light alone.
+// The snapshot is dropped again if the tool fails outright, since nothing
+// was changed.
+func SnapshotMiddleware(store *snapshot.Store, bm *bridge.Manager) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name := request.Params.Name
+			if !lightStateTools[name] || isDryRun(ctx) {
+				return next(ctx, request)
+			}
+
+			var snap *snapshot.Snapshot
+			ctx = withPreflight(ctx, func(ctx context.Context, report *dryRunReport) *mcp.CallToolResult {
+				lights, err := captureChanged(ctx, bm, report)
+				if err != nil {
+					log.Printf("Warning: automatic snapshot before %s failed: %v", name, err)
+					return nil
+				}
+				if len(lights) == 0 {
+					return nil
+				}
+
+				added, err := store.Add(snapshot.Snapshot{
+					Scope:     fmt.Sprintf("%d light(s)", len(lights)),
+					Automatic: true,
+					Tool:      name,
+					Lights:    lights,
+				})
+				if err != nil {
+					log.Printf("Warning: saving automatic snapshot before %s failed: %v", name, err)
+					return nil
+				}
+				snap = &added
+				return nil
+			})
+
+			result, callErr := next(ctx, request)
+			if snap != nil && (callErr != nil || (result != nil && result.IsError)) {
+				_ = store.Delete(snap.ID)
+			}
+
+			return result, callErr
+		}
+	}
+}
+
+// captureChanged captures the lights a report predicts a change for
+func captureChanged(ctx context.Context, bm *bridge.Manager, report *dryRunReport) ([]snapshot.LightState, error) {
+	byBridge := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, diff := range report.Changes {
+		key := diff.BridgeID + "/" + diff.LightID
+		if diff.BridgeID == "" || len(diff.Changes) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		byBridge[diff.BridgeID] = append(byBridge[diff.BridgeID], diff.LightID)
+	}
+
+	var lights []snapshot.LightState
+	for id, lightIDs := range byBridge {
+		br, err := bm.GetBridge(id)
+		if err != nil {
+			return nil, err
+		}
+
+		states, err := snapshot.Capture(ctx, br, lightIDs)
+		if err != nil {
+			return nil, err
+		}
+		lights = append(lights, states...)
+	}
+
+	return lights, nil
+}
+
+// RegisterSnapshotTools registers the snapshot, restore and undo tools
+func RegisterSnapshotTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver, store *snapshot.Store) {
+	// take_snapshot tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "take_snapshot",
+			Description: "Save the current state of lights so it can be restored later. Captures specific lights, a room, a zone, a bridge, or (by default) the whole home.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the snapshot, used to restore it later",
+					},
+					"lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to capture (IDs, names, \"Room/Light\" paths, or aliases)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"room": map[string]interface{}{
+						"type":        "string",
+						"description": "Capture all lights in a room (ID, name, or alias)",
+					},
+					"zone": map[string]interface{}{
+						"type":        "string",
+						"description": "Capture all lights in a zone (ID, name, or alias)",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Capture all lights on a bridge, or disambiguate names",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			scope, lights, err := captureScope(ctx, bm, res, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(lights) == 0 {
+				return mcp.NewToolResultError("no lights to capture"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{Method: "snapshots.add", Payload: snapshot.Snapshot{Name: name, Scope: scope, Lights: lights}}).result()
+			}
+
+			snap, err := store.Add(snapshot.Snapshot{Name: name, Scope: scope, Lights: lights})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to save snapshot: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Snapshot %q saved (id %s) with %d light(s) from %s", name, snap.ID, len(lights), scope)), nil
+		},
+	)
+
+	// list_snapshots tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_snapshots",
+			Description: "List saved snapshots, newest first, including the automatic snapshots taken before each light change",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			type snapshotInfo struct {
+				ID        string    `json:"id"`
+				Name      string    `json:"name,omitempty"`
+				Scope     string    `json:"scope"`
+				Automatic bool      `json:"automatic"`
+				Tool      string    `json:"before_tool,omitempty"`
+				CreatedAt time.Time `json:"created_at"`
+				Lights    int       `json:"lights"`
+			}
+
+			snapshots := store.List()
+			infos := make([]snapshotInfo, len(snapshots))
+			for i, snap := range snapshots {
+				infos[i] = snapshotInfo{
+					ID:        snap.ID,
+					Name:      snap.Name,
+					Scope:     snap.Scope,
+					Automatic: snap.Automatic,
+					Tool:      snap.Tool,
+					CreatedAt: snap.CreatedAt,
+					Lights:    len(snap.Lights),
+				}
+			}
+
+			data, err := json.MarshalIndent(infos, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal snapshots: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// restore_snapshot tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "restore_snapshot",
+			Description: "Restore lights to a saved snapshot. Only lights whose state differs are changed, using grouped commands or temporary scenes where possible.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"snapshot": map[string]interface{}{
+						"type":        "string",
+						"description": "The snapshot ID or name",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"snapshot"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ref, err := request.RequireString("snapshot")
+			if err != nil {
+				return mcp.NewToolResultError("snapshot is required"), nil
+			}
+
+			snap, ok := store.Find(ref)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("snapshot %q not found; use list_snapshots to see saved snapshots", ref)), nil
+			}
+
+			report, preflight, err := restoreSnapshot(ctx, bm, snap)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to restore snapshot: %v", err)), nil
+			}
+			if preflight != nil {
+				return preflight, nil
+			}
+
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal results: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// delete_snapshot tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_snapshot",
+			Description: "Delete a saved snapshot",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"snapshot": map[string]interface{}{
+						"type":        "string",
+						"description": "The snapshot ID or name",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"snapshot"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ref, err := request.RequireString("snapshot")
+			if err != nil {
+				return mcp.NewToolResultError("snapshot is required"), nil
+			}
+
+			snap, ok := store.Find(ref)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("snapshot %q not found", ref)), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{Method: "snapshots.delete", TargetID: snap.ID}).result()
+			}
+
+			if err := store.Delete(snap.ID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete snapshot: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Snapshot %s deleted successfully", snap.ID)), nil
+		},
+	)
+
+	// undo_last_change tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "undo_last_change",
+			Description: "Revert the most recent light change made through this server by putting the lights it changed back into their state from before it. Other lights are left alone. Call again to step further back.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			snap, ok := store.LatestAutomatic()
+			if !ok {
+				return mcp.NewToolResultError("nothing to undo: no automatic snapshots are saved"), nil
+			}
+
+			report, preflight, err := restoreSnapshot(ctx, bm, snap)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to undo: %v", err)), nil
+			}
+			if preflight != nil {
+				return preflight, nil
+			}
+
+			if report.Failed == 0 {
+				if err := store.Delete(snap.ID); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Undo applied, but removing its snapshot failed: %v", err)), nil
+				}
+			}
+
+			report.Undone = snap.Tool
+
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal results: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// restoreReport is the outcome of restoring a snapshot
+type restoreReport struct {
+	Snapshot  string    `json:"snapshot"`
+	TakenAt   time.Time `json:"taken_at"`
+	Undone    string    `json:"undone_tool,omitempty"`
+	Unchanged int       `json:"unchanged"`
+	bulkReport
+}
+
+// restoreSnapshot puts every light in a snapshot that has changed since back
+// into its captured state through an optimized bulk update. If the call is a
+// dry run or is stopped before anything is sent, the result to return
+// instead is given and the report is nil.
+func restoreSnapshot(ctx context.Context, bm *bridge.Manager, snap snapshot.Snapshot) (*restoreReport, *mcp.CallToolResult, error) {
+	report := &restoreReport{Snapshot: snap.ID, TakenAt: snap.CreatedAt}
+
+	start := time.Now()
+	items, unchanged := restoreItems(ctx, bm, snap)
+	report.Unchanged = unchanged
+
+	plan, err := planBulkUpdates(ctx, items, planner.ModeScenes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if result, done := planDryRun(ctx, plan, items).preflight(ctx); done {
+		return nil, result, nil
+	}
+
+	planResult := executePlan(ctx, plan, items)
+	report.bulkReport = newBulkReport(ctx, items, time.Since(start))
+	report.Plan = planResult
+
+	return report, nil, nil
+}
+
+// restoreItems builds the bulk updates for the lights in a snapshot whose
+// state has changed since, and counts the lights that have not
+func restoreItems(ctx context.Context, bm *bridge.Manager, snap snapshot.Snapshot) ([]bulkItem, int) {
+	unchanged := 0
+
+	var items []bulkItem
+	for _, state := range snap.Lights {
+		item := bulkItem{query: state.LightID, update: state.State}
+
+		br, err := bm.GetBridge(state.BridgeID)
+		if err != nil {
+			item.err = err
+			items = append(items, item)
+			continue
+		}
+
+		current, err := br.CachedClient.Lights().Get(ctx, state.LightID)
+		if err != nil {
+			item.err = fmt.Errorf("getting light: %w", err)
+			items = append(items, item)
+			continue
+		}
+		if snapshot.Equal(snapshot.StateOf(*current), state.State) {
+			unchanged++
+			continue
+		}
+
+		item.target = &resolver.Entry{
+			Kind:       resolver.KindLight,
+			ID:         state.LightID,
+			Name:       current.Metadata.Name,
+			BridgeID:   br.ID,
+			BridgeName: br.Name,
+			Bridge:     br,
+		}
+		items = append(items, item)
+	}
+
+	return items, unchanged
+}
+
+// captureScope captures the lights addressed by a take_snapshot request and
+// describes the scope
+func captureScope(ctx context.Context, bm *bridge.Manager, res *resolver.Resolver, request mcp.CallToolRequest) (string, []snapshot.LightState, error) {
+	bridgeID := request.GetString("bridge_id", "")
+
+	if queries := request.GetStringSlice("lights", nil); len(queries) > 0 {
+		byBridge := make(map[string][]string)
+		bridges := make(map[string]*bridge.Bridge)
+		for _, query := range queries {
+			light, err := res.Resolve(ctx, resolver.KindLight, query, bridgeID)
+			if err != nil {
+				return "", nil, err
+			}
+			byBridge[light.BridgeID] = append(byBridge[light.BridgeID], light.ID)
+			bridges[light.BridgeID] = light.Bridge
+		}
+
+		var lights []snapshot.LightState
+		for id, lightIDs := range byBridge {
+			states, err := snapshot.Capture(ctx, bridges[id], lightIDs)
+			if err != nil {
+				return "", nil, err
+			}
+			lights = append(lights, states...)
+		}
+		return fmt.Sprintf("%d light(s)", len(queries)), lights, nil
+	}
+
+	if request.GetString("room", "") != "" || request.GetString("zone", "") != "" {
+		owner, err := resolveGroupOwner(ctx, res, request)
+		if err != nil {
+			return "", nil, err
+		}
+
+		lightIDs, err := groupLightIDs(ctx, owner.Bridge, resources.ResourceIdentifier{RID: owner.ID, RType: string(owner.Kind)})
+		if err != nil {
+			return "", nil, err
+		}
+		if len(lightIDs) == 0 {
+			return "", nil, fmt.Errorf("%s %s has no lights", owner.Kind, owner.Label())
+		}
+
+		lights, err := snapshot.Capture(ctx, owner.Bridge, lightIDs)
+		return fmt.Sprintf("%s %s", owner.Kind, owner.Label()), lights, err
+	}
+
+	if bridgeID != "" {
+		br, err := bm.GetBridge(bridgeID)
+		if err != nil {
+			return "", nil, err
+		}
+
+		lights, err := snapshot.Capture(ctx, br, nil)
+		return "bridge " + br.ID, lights, err
+	}
+
+	lights, err := captureHome(ctx, bm)
+	return "home", lights, err
+}
+
+// captureHome captures every light on every connected bridge
+func captureHome(ctx context.Context, bm *bridge.Manager) ([]snapshot.LightState, error) {
+	var lights []snapshot.LightState
+	for _, br := range bm.ListBridges() {
+		if !br.Connected {
+			continue
+		}
+
+		states, err := snapshot.Capture(ctx, br, nil)
+		if err != nil {
+			return nil, err
+		}
+		lights = append(lights, states...)
+	}
+
+	return lights, nil
+}
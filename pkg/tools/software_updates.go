@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/firmware"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// RegisterSoftwareUpdateTools registers the firmware update tools
+func RegisterSoftwareUpdateTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// get_software_updates tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_software_updates",
+			Description: "Get the firmware update state of the bridge and every device: no_update, update_pending (downloading), ready_to_install or installing, with the installed version",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, reports on all bridges",
+					},
+					"pending_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only list devices with an update waiting or installing (default false)",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+			pendingOnly := request.GetBool("pending_only", false)
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			result := struct {
+				Devices        int                     `json:"devices"`
+				Pending        int                     `json:"pending"`
+				ReadyToInstall int                     `json:"ready_to_install"`
+				Installing     int                     `json:"installing"`
+				Updates        []bridge.SoftwareUpdate `json:"updates"`
+			}{
+				Updates: []bridge.SoftwareUpdate{},
+			}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				updates, err := br.SoftwareUpdates(ctx)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to get software updates on bridge %s: %v", br.ID, err)), nil
+				}
+
+				for _, update := range updates {
+					result.Devices++
+					switch update.State {
+					case firmware.StateReadyToInstall:
+						result.ReadyToInstall++
+					case firmware.StateInstalling:
+						result.Installing++
+					}
+					if update.Pending() {
+						result.Pending++
+					} else if pendingOnly {
+						continue
+					}
+					result.Updates = append(result.Updates, update)
+				}
+			}
+
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal software updates: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// install_software_update tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "install_software_update",
+			Description: "Start installing a downloaded firmware update on a device or the bridge. Only works when get_software_updates reports ready_to_install. The device is unavailable while it installs, and lights may flash or turn on.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"device_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The device ID, name or alias, or one of its lights",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"device_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			query, err := request.RequireString("device_id")
+			if err != nil {
+				return mcp.NewToolResultError("device_id is required"), nil
+			}
+
+			target, err := resolveDevice(ctx, res, query, request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			device, err := target.Bridge.CachedClient.Devices().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get device: %v", err)), nil
+			}
+
+			var swuID string
+			for _, service := range device.Services {
+				if service.RType == "device_software_update" {
+					swuID = service.RID
+				}
+			}
+			if swuID == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("Device %s does not report software updates", target.Label())), nil
+			}
+
+			swu, err := target.Bridge.CachedClient.DeviceSoftwareUpdates().Get(ctx, swuID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get software update state: %v", err)), nil
+			}
+			if swu.State != firmware.StateReadyToInstall {
+				return mcp.NewToolResultError(fmt.Sprintf("Device %s has no update ready to install (state %s)", target.Label(), swu.State)), nil
+			}
+
+			update := resources.DeviceSoftwareUpdateUpdate{
+				Install: &resources.SoftwareUpdateInstall{Action: "start"},
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "device_software_updates.update", TargetID: swuID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.LightCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.DeviceSoftwareUpdates().Update(ctx, swuID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to start software update: %v", err)), nil
+			}
+
+			bm.RecordSoftwareUpdate(firmware.Entry{
+				Time:     time.Now(),
+				BridgeID: target.BridgeID,
+				DeviceID: target.ID,
+				Device:   device.Metadata.Name,
+				Event:    "install_requested",
+				State:    swu.State,
+				Version:  device.ProductData.SoftwareVersion,
+			})
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Software update started on %s (version %s). Control tools treat it as busy until the install finishes.", target.Label(), device.ProductData.SoftwareVersion)), nil
+		},
+	)
+
+	// get_software_update_history tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_software_update_history",
+			Description: "Get the history of firmware updates, newest first: installs requested, started, finished and failed, with the device's version at the time",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of entries to return (default 50)",
+						"minimum":     1,
+					},
+					"device": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional device ID, name or alias to only show its updates",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID to only show updates on that bridge",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			limit := request.GetInt("limit", 50)
+			bridgeID := request.GetString("bridge_id", "")
+
+			history := bm.UpdateHistory()
+			if history == nil {
+				return mcp.NewToolResultError("Software update history is not available"), nil
+			}
+
+			deviceID := ""
+			if query := request.GetString("device", ""); query != "" {
+				device, err := resolveDevice(ctx, res, query, bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				deviceID = device.ID
+			}
+
+			entries := []firmware.Entry{}
+			for _, entry := range history.List(0) {
+				if bridgeID != "" && entry.BridgeID != bridgeID {
+					continue
+				}
+				if deviceID != "" && entry.DeviceID != deviceID {
+					continue
+				}
+				entries = append(entries, entry)
+				if len(entries) == limit {
+					break
+				}
+			}
+
+			data, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal update history: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// busyDevices returns the devices on a bridge that are installing a
+// software update
+func busyDevices(ctx context.Context, br *bridge.Bridge) map[string]bool {
+	busy := make(map[string]bool)
+
+	swus, err := br.CachedClient.DeviceSoftwareUpdates().List(ctx)
+	if err != nil {
+		return busy
+	}
+
+	for _, swu := range swus {
+		if swu.State == firmware.StateInstalling {
+			busy[swu.Owner.RID] = true
+		}
+	}
+
+	return busy
+}
+
+// busyLights returns the lights on a bridge whose device is installing a
+// software update
+func busyLights(ctx context.Context, br *bridge.Bridge) map[string]bool {
+	busy := make(map[string]bool)
+
+	devices := busyDevices(ctx, br)
+	if len(devices) == 0 {
+		return busy
+	}
+
+	lights, err := br.CachedClient.Lights().List(ctx)
+	if err != nil {
+		return busy
+	}
+
+	for _, light := range lights {
+		if devices[light.Owner.RID] {
+			busy[light.ID] = true
+		}
+	}
+
+	return busy
+}
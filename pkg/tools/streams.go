@@ -0,0 +1,351 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/planner"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-mcp/pkg/stream"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// maxStreamDuration is the longest a stream effect may run
+const maxStreamDuration = time.Hour
+
+// RegisterStreamTools registers the tools that play effects over an
+// entertainment stream
+func RegisterStreamTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver, streams *stream.Engine, owners *lightOwners) {
+	// start_stream_effect tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "start_stream_effect",
+			Description: "Play a smooth animated effect on an entertainment area by streaming 25-50 frames per second over DTLS, far faster than normal light commands allow. The lights' previous state is restored when the effect ends or is stopped.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"configuration_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The entertainment configuration ID, name or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"effect": map[string]interface{}{
+						"type":        "string",
+						"description": "Effect to play: solid color, rainbow across the channels, pulse of the color, or chase of the color from channel to channel",
+						"enum":        stream.Effects,
+					},
+					"color": map[string]interface{}{
+						"type":        "object",
+						"description": "Base color for solid, pulse and chase (default white)",
+						"properties": map[string]interface{}{
+							"r": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 255},
+							"g": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 255},
+							"b": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 255},
+						},
+						"required": []string{"r", "g", "b"},
+					},
+					"period_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Length of one effect cycle in seconds (default 4)",
+						"minimum":     0.2,
+					},
+					"rate": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Frames per second (%d-%d, default %d)", stream.MinRate, stream.MaxRate, stream.DefaultRate),
+						"minimum":     stream.MinRate,
+						"maximum":     stream.MaxRate,
+					},
+					"duration_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("How long to play the effect (default 60, at most %d)", int(maxStreamDuration.Seconds())),
+						"minimum":     1,
+						"maximum":     maxStreamDuration.Seconds(),
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"configuration_id", "effect"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			br := target.Bridge
+
+			if streams.Running(br.ID, target.ID) {
+				return mcp.NewToolResultError(fmt.Sprintf("An effect is already streaming on %s; stop it first with stop_stream_effect", target.Label())), nil
+			}
+			if br.ClientKey == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("Bridge %s has no client key configured. Streaming needs the client_key returned by authenticate_bridge; authenticate again and add the bridge with it.", br.ID)), nil
+			}
+
+			effect := request.GetString("effect", "")
+			options := stream.EffectOptions{
+				R: 1, G: 1, B: 1,
+				Period: time.Duration(request.GetFloat("period_seconds", 4) * float64(time.Second)),
+			}
+			if color, ok := request.GetArguments()["color"].(map[string]interface{}); ok {
+				r, _ := color["r"].(float64)
+				g, _ := color["g"].(float64)
+				b, _ := color["b"].(float64)
+				options.R, options.G, options.B = r/255, g/255, b/255
+			}
+			source, err := stream.Effect(effect, options)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			rate := request.GetInt("rate", stream.DefaultRate)
+			if rate < stream.MinRate || rate > stream.MaxRate {
+				return mcp.NewToolResultError(fmt.Sprintf("rate must be between %d and %d", stream.MinRate, stream.MaxRate)), nil
+			}
+			duration := time.Duration(request.GetFloat("duration_seconds", 60) * float64(time.Second))
+			if duration < time.Second || duration > maxStreamDuration {
+				return mcp.NewToolResultError(fmt.Sprintf("duration_seconds must be between 1 and %d", int(maxStreamDuration.Seconds()))), nil
+			}
+
+			ec, err := br.CachedClient.EntertainmentConfigurations().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get entertainment configuration: %v", err)), nil
+			}
+			if ec.Status == "active" {
+				return mcp.NewToolResultError(fmt.Sprintf("Entertainment area %s is already streaming from another client", target.Label())), nil
+			}
+
+			channels := make([]uint8, 0, len(ec.Channels))
+			for _, channel := range ec.Channels {
+				channels = append(channels, uint8(channel.ChannelID))
+			}
+			if len(channels) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("Entertainment area %s has no channels; add lights to it first", target.Label())), nil
+			}
+			var skipped string
+			if len(channels) > stream.MaxChannels {
+				skipped = fmt.Sprintf("%s has %d channels but a stream carries at most %d; channels %v get no color", target.Label(), len(channels), stream.MaxChannels, channels[stream.MaxChannels:])
+				channels = channels[:stream.MaxChannels]
+			}
+
+			// Capture the area's lights so they can be put back afterwards
+			var lightIDs []string
+			serviceLights := entertainmentServiceLights(ctx, br)
+			for _, location := range ec.Locations.ServiceLocations {
+				if light, ok := serviceLights[location.Service.RID]; ok {
+					lightIDs = append(lightIDs, light.ID)
+				}
+			}
+			var previous []snapshot.LightState
+			if len(lightIDs) > 0 {
+				if previous, err = snapshot.Capture(ctx, br, lightIDs); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to capture light state: %v", err)), nil
+				}
+			}
+
+			start := resources.EntertainmentConfigurationUpdate{Action: "start"}
+
+			report := newDryRun(
+				dryRunCommand{BridgeID: br.ID, Method: "entertainment_configurations.update", TargetID: target.ID, Payload: start},
+				dryRunCommand{BridgeID: br.ID, Method: "dtls.stream", TargetID: target.ID, Payload: map[string]interface{}{
+					"address":          net.JoinHostPort(br.IP, fmt.Sprint(stream.Port)),
+					"effect":           effect,
+					"channels":         channels,
+					"rate":             rate,
+					"duration_seconds": duration.Seconds(),
+				}},
+			)
+			report.affectLights(ctx, br, lightIDs, fieldChange{Field: "stream_effect", From: nil, To: effect})
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%d light(s) are restored to their current state when the effect ends", len(previous)))
+			if skipped != "" {
+				report.Warnings = append(report.Warnings, skipped)
+			}
+			if result, done := report.preflight(ctx); done {
+				return result, nil
+			}
+
+			claim, err := owners.claim("a streaming effect on "+target.Label(), previous)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			err = br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return br.CachedClient.EntertainmentConfigurations().Update(ctx, target.ID, start)
+			})
+			if err != nil {
+				claim.release()
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to start streaming: %v", err)), nil
+			}
+
+			// Newer bridges expect the application ID as PSK identity; older
+			// ones accept the app key
+			identity, err := stream.ApplicationID(ctx, br.HTTPClient, br.IP, br.AppKey)
+			if err != nil {
+				identity = br.AppKey
+			}
+
+			session, err := stream.Dial(ctx, stream.Config{
+				Addr:            br.IP,
+				Identity:        identity,
+				ClientKey:       br.ClientKey,
+				ConfigurationID: target.ID,
+				Channels:        channels,
+				Rate:            rate,
+				Duration:        duration,
+				Source:          source,
+			})
+			if err != nil {
+				if stopErr := stopStreaming(ctx, br, target.ID); stopErr != nil {
+					log.Printf("Warning: failed to stop streaming on %s: %v", target.ID, stopErr)
+				}
+				claim.release()
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to open the stream: %v", err)), nil
+			}
+
+			info := stream.Active{
+				BridgeID: br.ID,
+				Name:     ec.Metadata.Name,
+				Effect:   effect,
+				Status:   stream.Status{ConfigurationID: target.ID},
+			}
+			err = streams.Add(info, session, func(ctx context.Context) error {
+				defer claim.release()
+				err := stopStreaming(ctx, br, target.ID)
+				restoreLights(ctx, bm, previous)
+				return err
+			})
+			if err != nil {
+				// Another call started streaming this area meanwhile; leave
+				// its lights to it
+				session.Stop()
+				claim.release()
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to start the effect: %v", err)), nil
+			}
+
+			text := fmt.Sprintf("✅ Streaming %s on %s at %d Hz across %d channel(s) for %s. The lights are restored when it ends; stop it early with stop_stream_effect.", effect, target.Label(), rate, len(channels), duration)
+			if skipped != "" {
+				text += "\n⚠️ " + skipped
+			}
+			return mcp.NewToolResultText(text), nil
+		},
+	)
+
+	// stop_stream_effect tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "stop_stream_effect",
+			Description: "Stop a streaming effect, hand the entertainment area's lights back and restore their previous state",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"configuration_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The entertainment configuration ID, name or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"configuration_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "configuration_id", resolver.KindEntertainment)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if !streams.Running(target.BridgeID, target.ID) {
+				return mcp.NewToolResultError(fmt.Sprintf("No effect is streaming on %s", target.Label())), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "entertainment_configurations.update", TargetID: target.ID, Payload: resources.EntertainmentConfigurationUpdate{Action: "stop"}}).result()
+			}
+
+			stopped, err := streams.Stop(ctx, target.BridgeID, target.ID)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("⚠️ Effect stopped on %s after %d frames, but handing the lights back failed: %v", target.Label(), stopped.Frames, err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Effect stopped on %s after %d frames and the lights were restored", target.Label(), stopped.Frames)), nil
+		},
+	)
+
+	// list_stream_effects tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_stream_effects",
+			Description: "List effects currently streaming to entertainment areas, with their frame rate and frames sent",
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			data, err := json.MarshalIndent(streams.List(), "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal streams: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// stopStreaming hands an entertainment configuration's lights back to
+// normal control
+func stopStreaming(ctx context.Context, br *bridge.Bridge, configurationID string) error {
+	return br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+		return br.CachedClient.EntertainmentConfigurations().Update(ctx, configurationID, resources.EntertainmentConfigurationUpdate{Action: "stop"})
+	})
+}
+
+// restoreLights sends captured light states back unconditionally. Streamed
+// colors never reach the cache, so comparing against it as restoreSnapshot
+// does would find nothing to restore.
+func restoreLights(ctx context.Context, bm *bridge.Manager, states []snapshot.LightState) {
+	items := make([]bulkItem, 0, len(states))
+	for _, state := range states {
+		br, err := bm.GetBridge(state.BridgeID)
+		if err != nil {
+			continue
+		}
+		items = append(items, bulkItem{
+			query:  state.LightID,
+			update: state.State,
+			target: &resolver.Entry{
+				Kind:       resolver.KindLight,
+				ID:         state.LightID,
+				Name:       state.Name,
+				BridgeID:   br.ID,
+				BridgeName: br.Name,
+				Bridge:     br,
+			},
+		})
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	plan, err := planBulkUpdates(ctx, items, planner.ModeScenes)
+	if err != nil {
+		log.Printf("Warning: failed to plan restoring lights after streaming: %v", err)
+		return
+	}
+	executePlan(ctx, plan, items)
+}
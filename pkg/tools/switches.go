@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/events"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+)
+
+// buttonInfo is one button of a switch
+type buttonInfo struct {
+	ID          string     `json:"id"`
+	Button      int        `json:"button"`
+	LastEvent   string     `json:"last_event,omitempty"`
+	Updated     *time.Time `json:"last_pressed,omitempty"`
+	EventValues []string   `json:"event_values,omitempty"`
+}
+
+// rotaryInfo is a rotary dial of a switch
+type rotaryInfo struct {
+	ID         string     `json:"id"`
+	LastAction string     `json:"last_action,omitempty"`
+	Direction  string     `json:"direction,omitempty"`
+	Steps      int        `json:"steps,omitempty"`
+	Updated    *time.Time `json:"last_turned,omitempty"`
+}
+
+// switchInfo is a switch device with its buttons and dials
+type switchInfo struct {
+	BridgeID    string       `json:"bridge_id"`
+	BridgeName  string       `json:"bridge_name"`
+	DeviceID    string       `json:"device_id"`
+	Name        string       `json:"name"`
+	Product     string       `json:"product,omitempty"`
+	Room        string       `json:"room,omitempty"`
+	Unreachable bool         `json:"unreachable,omitempty"`
+	Battery     *int         `json:"battery,omitempty"`
+	Buttons     []buttonInfo `json:"buttons,omitempty"`
+	Rotaries    []rotaryInfo `json:"rotaries,omitempty"`
+}
+
+// RegisterSwitchTools registers the switch and button event tools
+func RegisterSwitchTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// list_switches tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_switches",
+			Description: "List switch devices (dimmer switches, tap dials, smart buttons) with their buttons, rotary dials and the last event of each",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists switches from all bridges",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			allSwitches := []switchInfo{}
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				switches, err := bridgeSwitches(ctx, br)
+				if err != nil {
+					continue
+				}
+
+				allSwitches = append(allSwitches, switches...)
+			}
+
+			data, err := json.MarshalIndent(allSwitches, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal switches: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_button_events tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_button_events",
+			Description: "Get recent button presses and dial turns, newest first, e.g. what was pressed in the last 10 minutes. Events are kept in memory from the moment the server started.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"minutes": map[string]interface{}{
+						"type":        "number",
+						"description": "How many minutes to look back (default 10)",
+						"minimum":     0,
+					},
+					"device": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional switch device ID, name or alias to only show its events",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID to only show events from that bridge",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			minutes := request.GetFloat("minutes", 10)
+			if minutes < 0 {
+				return mcp.NewToolResultError("minutes must not be negative"), nil
+			}
+			bridgeID := request.GetString("bridge_id", "")
+
+			deviceID := ""
+			if query := request.GetString("device", ""); query != "" {
+				device, err := resolveDevice(ctx, res, query, bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				deviceID = device.ID
+			}
+
+			since := time.Now().Add(-time.Duration(minutes * float64(time.Minute)))
+
+			matched := []events.ButtonEvent{}
+			for _, event := range bm.Events().Since(since) {
+				if bridgeID != "" && event.BridgeID != bridgeID {
+					continue
+				}
+				if deviceID != "" && event.DeviceID != deviceID {
+					continue
+				}
+				matched = append(matched, event)
+			}
+
+			result := struct {
+				Since  time.Time            `json:"since"`
+				Count  int                  `json:"count"`
+				Events []events.ButtonEvent `json:"events"`
+			}{
+				Since:  since,
+				Count:  len(matched),
+				Events: matched,
+			}
+
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal events: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+}
+
+// bridgeSwitches returns the devices on a bridge that have buttons or
+// rotary dials
+func bridgeSwitches(ctx context.Context, br *bridge.Bridge) ([]switchInfo, error) {
+	buttons, err := br.CachedClient.Buttons().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buttons: %w", err)
+	}
+	rotaries, err := br.CachedClient.RelativeRotaries().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rotary dials: %w", err)
+	}
+
+	byDevice := make(map[string]*switchInfo)
+	device := func(id string) *switchInfo {
+		if info, ok := byDevice[id]; ok {
+			return info
+		}
+		info := &switchInfo{BridgeID: br.ID, BridgeName: br.Name, DeviceID: id}
+		byDevice[id] = info
+		return info
+	}
+
+	for _, button := range buttons {
+		info := buttonInfo{
+			ID:          button.ID,
+			Button:      button.Metadata.ControlID,
+			LastEvent:   button.Button.LastEvent,
+			EventValues: button.Button.EventValues,
+		}
+		if report := button.Button.ButtonReport; report != nil {
+			info.LastEvent = report.Event
+			info.Updated = &report.Updated
+		}
+		sw := device(button.Owner.RID)
+		sw.Buttons = append(sw.Buttons, info)
+	}
+
+	for _, rotary := range rotaries {
+		info := rotaryInfo{ID: rotary.ID}
+		if report := rotary.RelativeRotary.RotaryReport; report != nil {
+			info.LastAction = report.Action
+			info.Direction = report.Rotation.Direction
+			info.Steps = report.Rotation.Steps
+			info.Updated = &report.Updated
+		} else if last := rotary.RelativeRotary.LastEvent; last != nil {
+			info.LastAction = last.Action
+			info.Direction = last.Rotation.Direction
+			info.Steps = last.Rotation.Steps
+		}
+		sw := device(rotary.Owner.RID)
+		sw.Rotaries = append(sw.Rotaries, info)
+	}
+
+	if devices, err := br.CachedClient.Devices().List(ctx); err == nil {
+		for _, d := range devices {
+			if sw, ok := byDevice[d.ID]; ok {
+				sw.Name = d.Metadata.Name
+				sw.Product = d.ProductData.ProductName
+			}
+		}
+	}
+	if rooms, err := br.CachedClient.Rooms().List(ctx); err == nil {
+		for id, room := range deviceRooms(rooms) {
+			if sw, ok := byDevice[id]; ok {
+				sw.Room = room.Metadata.Name
+			}
+		}
+	}
+
+	unreachable := br.UnreachableDevices(ctx)
+	batteries := batteriesOf(ctx, br)
+
+	switches := make([]switchInfo, 0, len(byDevice))
+	for id, sw := range byDevice {
+		sw.Unreachable = unreachable[id]
+		if power, ok := batteries[id]; ok {
+			level := power.BatteryLevel
+			sw.Battery = &level
+		}
+		sort.Slice(sw.Buttons, func(i, j int) bool {
+			return sw.Buttons[i].Button < sw.Buttons[j].Button
+		})
+		switches = append(switches, *sw)
+	}
+	sort.Slice(switches, func(i, j int) bool {
+		return switches[i].Name < switches[j].Name
+	})
+
+	return switches, nil
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+)
+
+// resolveArg resolves a required tool argument that references a resource
+// by ID, name, "Room/Name" path or alias
+func resolveArg(ctx context.Context, res *resolver.Resolver, request mcp.CallToolRequest, arg string, kind resolver.Kind) (*resolver.Entry, error) {
+	query, err := request.RequireString(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required", arg)
+	}
+
+	return res.Resolve(ctx, kind, query, request.GetString("bridge_id", ""))
+}
+
+// resolveGroupTarget resolves the grouped light addressed by a tool request.
+// It accepts grouped_light_id, room, zone or room_id, each of which may be
+// an ID, a name or an alias.
+func resolveGroupTarget(ctx context.Context, res *resolver.Resolver, request mcp.CallToolRequest) (*resolver.Entry, error) {
+	bridgeID := request.GetString("bridge_id", "")
+
+	if groupedLightID := request.GetString("grouped_light_id", ""); groupedLightID != "" {
+		return res.Resolve(ctx, resolver.KindGroupedLight, groupedLightID, bridgeID)
+	}
+
+	if roomID := request.GetString("room_id", ""); roomID != "" {
+		owner, err := res.Resolve(ctx, resolver.KindRoom, roomID, bridgeID)
+		var notFound *resolver.NotFoundError
+		if errors.As(err, &notFound) {
+			owner, err = res.Resolve(ctx, resolver.KindZone, roomID, bridgeID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return res.GroupedLightFor(ctx, owner)
+	}
+
+	if request.GetString("room", "") == "" && request.GetString("zone", "") == "" {
+		return nil, fmt.Errorf("one of grouped_light_id, room, zone or room_id is required")
+	}
+
+	owner, err := resolveGroupOwner(ctx, res, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GroupedLightFor(ctx, owner)
+}
+
+// resolveGroupOwner resolves the room or zone named by the room or zone
+// argument of a tool request
+func resolveGroupOwner(ctx context.Context, res *resolver.Resolver, request mcp.CallToolRequest) (*resolver.Entry, error) {
+	bridgeID := request.GetString("bridge_id", "")
+
+	if room := request.GetString("room", ""); room != "" {
+		return res.Resolve(ctx, resolver.KindRoom, room, bridgeID)
+	}
+	if zone := request.GetString("zone", ""); zone != "" {
+		return res.Resolve(ctx, resolver.KindZone, zone, bridgeID)
+	}
+
+	return nil, fmt.Errorf("one of room or zone is required")
+}
+
+// resolveLightList resolves a list of light references that must all live on
+// the same bridge, returning the lights and that bridge's ID
+func resolveLightList(ctx context.Context, res *resolver.Resolver, queries []string, bridgeID string) ([]*resolver.Entry, string, error) {
+	if len(queries) == 0 {
+		return nil, "", fmt.Errorf("at least one light is required")
+	}
+
+	lights := make([]*resolver.Entry, 0, len(queries))
+	for _, query := range queries {
+		light, err := res.Resolve(ctx, resolver.KindLight, query, bridgeID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if bridgeID == "" {
+			bridgeID = light.BridgeID
+		} else if light.BridgeID != bridgeID {
+			return nil, "", fmt.Errorf("light %s is on bridge %s, but all lights must be on bridge %s", light.Label(), light.BridgeID, bridgeID)
+		}
+
+		lights = append(lights, light)
+	}
+
+	return lights, bridgeID, nil
+}
+
+// resolveDeviceList resolves a list of device references that must all live
+// on the same bridge. A light reference resolves to the device that owns it.
+func resolveDeviceList(ctx context.Context, res *resolver.Resolver, queries []string, bridgeID string) ([]*resolver.Entry, string, error) {
+	if len(queries) == 0 {
+		return nil, "", fmt.Errorf("at least one device is required")
+	}
+
+	devices := make([]*resolver.Entry, 0, len(queries))
+	for _, query := range queries {
+		device, err := resolveDevice(ctx, res, query, bridgeID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if bridgeID == "" {
+			bridgeID = device.BridgeID
+		} else if device.BridgeID != bridgeID {
+			return nil, "", fmt.Errorf("device %s is on bridge %s, but all devices must be on bridge %s", device.Label(), device.BridgeID, bridgeID)
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, bridgeID, nil
+}
+
+// resolveDevice resolves a device by ID, name or alias, falling back to the
+// owner of a matching light
+func resolveDevice(ctx context.Context, res *resolver.Resolver, query, bridgeID string) (*resolver.Entry, error) {
+	device, err := res.Resolve(ctx, resolver.KindDevice, query, bridgeID)
+
+	var notFound *resolver.NotFoundError
+	if !errors.As(err, &notFound) {
+		return device, err
+	}
+
+	light, lightErr := res.Resolve(ctx, resolver.KindLight, query, bridgeID)
+	if lightErr != nil {
+		return nil, err
+	}
+
+	details, lightErr := light.Bridge.CachedClient.Lights().Get(ctx, light.ID)
+	if lightErr != nil {
+		return nil, fmt.Errorf("getting light %s: %w", light.Label(), lightErr)
+	}
+
+	return res.Resolve(ctx, resolver.KindDevice, details.Owner.RID, light.BridgeID)
+}
@@ -2,23 +2,49 @@ package tools
 
 import (
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/animation"
 	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
 	"github.com/rmrfslashbin/hue-mcp/pkg/config"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/show"
+	"github.com/rmrfslashbin/hue-mcp/pkg/snapshot"
+	"github.com/rmrfslashbin/hue-mcp/pkg/stream"
 )
 
 // RegisterAllTools registers all MCP tools with the server
-func RegisterAllTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Config) {
+func RegisterAllTools(s *server.MCPServer, bm *bridge.Manager, cfg *config.Config, res *resolver.Resolver, snapshots *snapshot.Store, streams *stream.Engine, animations *animation.Engine, shows *show.Engine) {
 	// Setup tools - for discovering and configuring bridges
 	RegisterSetupTools(s, bm, cfg)
 
 	// Cache management tools
 	RegisterCacheTools(s, bm)
 
+	// Alias tools
+	RegisterAliasTools(s, cfg, res)
+
 	// Bridge control tools
-	RegisterLightTools(s, bm)
-	RegisterBulkLightTools(s, bm)
-	RegisterGroupedLightTools(s, bm)
-	RegisterRoomTools(s, bm)
-	RegisterSceneTools(s, bm)
+	RegisterLightTools(s, bm, res)
+	RegisterGradientTools(s, bm, res)
+	RegisterBulkLightTools(s, bm, res)
+	RegisterGroupedLightTools(s, bm, res)
+	RegisterRoomTools(s, bm, res)
+	RegisterZoneTools(s, bm, res)
+	RegisterSceneTools(s, bm, res)
+	RegisterSceneAuthoringTools(s, bm, res)
+	RegisterSmartSceneTools(s, bm, res)
+	RegisterDeviceTools(s, bm, res)
+	RegisterSensorTools(s, bm, res)
+	RegisterSwitchTools(s, bm, res)
+	RegisterHealthTools(s, bm)
+	RegisterSoftwareUpdateTools(s, bm, res)
+	RegisterEntertainmentTools(s, bm, res)
+	// Shows, animations and streaming effects never drive the same light
+	owners := newLightOwners()
+	RegisterStreamTools(s, bm, res, streams, owners)
+	RegisterAnimationTools(s, bm, res, animations, owners)
+	RegisterShowTools(s, bm, res, shows, owners)
 	RegisterBridgeTools(s, bm)
+
+	// Snapshot and undo tools
+	RegisterSnapshotTools(s, bm, res, snapshots)
 }
@@ -0,0 +1,506 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rmrfslashbin/hue-mcp/pkg/bridge"
+	"github.com/rmrfslashbin/hue-mcp/pkg/resolver"
+	"github.com/rmrfslashbin/hue-mcp/pkg/scheduler"
+	"github.com/rmrfslashbin/hue-sdk/resources"
+)
+
+// groupArchetypes are the archetypes the bridge accepts for rooms and zones
+var groupArchetypes = []string{
+	"living_room", "kitchen", "dining", "bedroom", "kids_bedroom", "bathroom", "nursery",
+	"recreation", "office", "gym", "hallway", "toilet", "front_door", "garage", "terrace",
+	"garden", "driveway", "carport", "home", "downstairs", "upstairs", "top_floor", "attic",
+	"guest_room", "staircase", "lounge", "man_cave", "computer", "studio", "music", "tv",
+	"reading", "closet", "storage", "laundry_room", "balcony", "porch", "barbecue", "pool", "other",
+}
+
+// memberLight is a light in a room or zone with its current state
+type memberLight struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	On          bool    `json:"on"`
+	Brightness  float64 `json:"brightness,omitempty"`
+	Unreachable bool    `json:"unreachable,omitempty"`
+}
+
+// memberLights returns the current state of the given lights
+func memberLights(ctx context.Context, br *bridge.Bridge, lightIDs []string) []memberLight {
+	unreachable := br.UnreachableDevices(ctx)
+
+	members := make([]memberLight, 0, len(lightIDs))
+	for _, id := range lightIDs {
+		light, err := br.CachedClient.Lights().Get(ctx, id)
+		if err != nil {
+			members = append(members, memberLight{ID: id})
+			continue
+		}
+
+		member := memberLight{
+			ID:          light.ID,
+			Name:        light.Metadata.Name,
+			On:          light.On.On,
+			Unreachable: unreachable[light.Owner.RID],
+		}
+		if light.Dimming != nil {
+			member.Brightness = light.Dimming.Brightness
+		}
+		members = append(members, member)
+	}
+
+	return members
+}
+
+// RegisterZoneTools registers all zone-related tools
+func RegisterZoneTools(s *server.MCPServer, bm *bridge.Manager, res *resolver.Resolver) {
+	// list_zones tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "list_zones",
+			Description: "List all zones across all bridges or from a specific bridge. Zones group lights across rooms (e.g., 'Downstairs', 'TV area').",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. If not provided, lists zones from all bridges",
+					},
+				},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			bridgeID := request.GetString("bridge_id", "")
+
+			var bridges []*bridge.Bridge
+			if bridgeID != "" {
+				br, err := bm.GetBridge(bridgeID)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				bridges = []*bridge.Bridge{br}
+			} else {
+				bridges = bm.ListBridges()
+			}
+
+			type zoneInfo struct {
+				BridgeID   string `json:"bridge_id"`
+				BridgeName string `json:"bridge_name"`
+				ID         string `json:"id"`
+				Name       string `json:"name"`
+				Archetype  string `json:"archetype,omitempty"`
+				Lights     int    `json:"lights"`
+			}
+
+			var allZones []zoneInfo
+
+			for _, br := range bridges {
+				if !br.Connected {
+					continue
+				}
+
+				zones, err := br.CachedClient.Zones().List(ctx)
+				if err != nil {
+					continue
+				}
+
+				for _, zone := range zones {
+					allZones = append(allZones, zoneInfo{
+						BridgeID:   br.ID,
+						BridgeName: br.Name,
+						ID:         zone.ID,
+						Name:       zone.Metadata.Name,
+						Archetype:  zone.Metadata.Archetype,
+						Lights:     len(zoneLightIDs(zone)),
+					})
+				}
+			}
+
+			data, err := json.MarshalIndent(allZones, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal zones: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// get_zone tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "get_zone",
+			Description: "Get a zone with its member lights, their current state, and the zone's grouped light ID",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"zone_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The zone ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+				},
+				Required: []string{"zone_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "zone_id", resolver.KindZone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			zone, err := target.Bridge.CachedClient.Zones().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get zone: %v", err)), nil
+			}
+
+			type zoneDetails struct {
+				BridgeID       string        `json:"bridge_id"`
+				ID             string        `json:"id"`
+				Name           string        `json:"name"`
+				Archetype      string        `json:"archetype,omitempty"`
+				GroupedLightID string        `json:"grouped_light_id,omitempty"`
+				Lights         []memberLight `json:"lights"`
+			}
+
+			details := zoneDetails{
+				BridgeID:       target.BridgeID,
+				ID:             zone.ID,
+				Name:           zone.Metadata.Name,
+				Archetype:      zone.Metadata.Archetype,
+				GroupedLightID: groupedLightService(zone.Services),
+				Lights:         memberLights(ctx, target.Bridge, zoneLightIDs(*zone)),
+			}
+
+			data, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal zone: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(data)), nil
+		},
+	)
+
+	// create_zone tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "create_zone",
+			Description: "Create a zone from a list of lights. Lights can be given by ID, name or \"Room/Light\" path and must all be on the same bridge.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for the new zone",
+					},
+					"lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to include in the zone",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"archetype": map[string]interface{}{
+						"type":        "string",
+						"description": "Zone archetype (icon). Defaults to 'other'",
+						"enum":        groupArchetypes,
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Defaults to the bridge the lights are on",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"name", "lights"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultError("name is required"), nil
+			}
+
+			lights, bridgeID, err := resolveLightList(ctx, res, request.GetStringSlice("lights", nil), request.GetString("bridge_id", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			br, err := bm.GetBridge(bridgeID)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			create := resources.ZoneCreate{
+				Metadata: resources.Metadata{
+					Name:      name,
+					Archetype: request.GetString("archetype", "other"),
+				},
+				Children: lightIdentifiers(lights),
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: br.ID, Method: "zones.create", Payload: create}).result()
+			}
+
+			var createdID string
+			err = br.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				created, err := br.CachedClient.Zones().Create(ctx, create)
+				if err != nil {
+					return err
+				}
+				createdID = created.RID
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to create zone: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, br.ID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %q created (id %s) with %d light(s), but refreshing the index failed: %v", name, createdID, len(lights), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %q created (id %s) with %d light(s) on bridge %s", name, createdID, len(lights), br.ID)), nil
+		},
+	)
+
+	// update_zone tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "update_zone",
+			Description: "Rename a zone, change its archetype, or add and remove member lights",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"zone_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The zone ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for the zone",
+					},
+					"archetype": map[string]interface{}{
+						"type":        "string",
+						"description": "New zone archetype (icon)",
+						"enum":        groupArchetypes,
+					},
+					"add_lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to add to the zone",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"remove_lights": map[string]interface{}{
+						"type":        "array",
+						"description": "Lights to remove from the zone",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"zone_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "zone_id", resolver.KindZone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			zone, err := target.Bridge.CachedClient.Zones().Get(ctx, target.ID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get zone: %v", err)), nil
+			}
+
+			update := resources.ZoneUpdate{}
+
+			name := request.GetString("name", "")
+			archetype := request.GetString("archetype", "")
+			if name != "" || archetype != "" {
+				metadata := zone.Metadata
+				if name != "" {
+					metadata.Name = name
+				}
+				if archetype != "" {
+					metadata.Archetype = archetype
+				}
+				update.Metadata = &metadata
+			}
+
+			addLights := request.GetStringSlice("add_lights", nil)
+			removeLights := request.GetStringSlice("remove_lights", nil)
+			if len(addLights) > 0 || len(removeLights) > 0 {
+				members := zoneLightIDs(*zone)
+
+				if len(addLights) > 0 {
+					added, _, err := resolveLightList(ctx, res, addLights, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					for _, light := range added {
+						if !containsString(members, light.ID) {
+							members = append(members, light.ID)
+						}
+					}
+				}
+
+				if len(removeLights) > 0 {
+					removed, _, err := resolveLightList(ctx, res, removeLights, target.BridgeID)
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					for _, light := range removed {
+						members = removeString(members, light.ID)
+					}
+				}
+
+				if len(members) == 0 {
+					return mcp.NewToolResultError("a zone must keep at least one light; use delete_zone to remove it"), nil
+				}
+
+				children := make([]resources.ResourceIdentifier, len(members))
+				for i, id := range members {
+					children[i] = resources.ResourceIdentifier{RID: id, RType: "light"}
+				}
+				update.Children = children
+			}
+
+			if update.Metadata == nil && update.Children == nil {
+				return mcp.NewToolResultError("nothing to update: provide name, archetype, add_lights or remove_lights"), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "zones.update", TargetID: target.ID, Payload: update}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Zones().Update(ctx, target.ID, update)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to update zone: %v", err)), nil
+			}
+
+			if update.Metadata != nil {
+				if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+					return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %s updated, but refreshing the index failed: %v", target.Label(), err)), nil
+				}
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %s updated successfully", target.Label())), nil
+		},
+	)
+
+	// delete_zone tool
+	s.AddTool(
+		mcp.Tool{
+			Name:        "delete_zone",
+			Description: "Delete a zone. The lights themselves are not affected.",
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"zone_id": map[string]interface{}{
+						"type":        "string",
+						"description": "The zone ID, name, or alias",
+					},
+					"bridge_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional bridge ID. Only needed when a name matches resources on more than one bridge",
+					},
+					"dry_run":       dryRunArgument,
+					"confirm_token": confirmTokenArgument,
+				},
+				Required: []string{"zone_id"},
+			},
+		},
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			target, err := resolveArg(ctx, res, request, "zone_id", resolver.KindZone)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if isDryRun(ctx) {
+				return newDryRun(dryRunCommand{BridgeID: target.BridgeID, Method: "zones.delete", TargetID: target.ID}).result()
+			}
+
+			err = target.Bridge.Scheduler.Do(ctx, scheduler.GroupCommand, func(ctx context.Context) error {
+				return target.Bridge.CachedClient.Zones().Delete(ctx, target.ID)
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to delete zone: %v", err)), nil
+			}
+
+			if err := bm.RefreshIndex(ctx, target.BridgeID); err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %s deleted, but refreshing the index failed: %v", target.Label(), err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Zone %s deleted successfully", target.Label())), nil
+		},
+	)
+}
+
+// zoneLightIDs returns the IDs of the lights in a zone
+func zoneLightIDs(zone resources.Zone) []string {
+	var ids []string
+	for _, child := range zone.Children {
+		if child.RType == "light" {
+			ids = append(ids, child.RID)
+		}
+	}
+	return ids
+}
+
+// groupedLightService returns the grouped light among a group's services
+func groupedLightService(services []resources.ResourceIdentifier) string {
+	for _, service := range services {
+		if service.RType == "grouped_light" {
+			return service.RID
+		}
+	}
+	return ""
+}
+
+// lightIdentifiers converts resolved lights to resource identifiers
+func lightIdentifiers(lights []*resolver.Entry) []resources.ResourceIdentifier {
+	ids := make([]resources.ResourceIdentifier, len(lights))
+	for i, light := range lights {
+		ids[i] = resources.ResourceIdentifier{RID: light.ID, RType: "light"}
+	}
+	return ids
+}
+
+// containsString reports whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns the slice without any occurrence of value
+func removeString(values []string, value string) []string {
+	result := values[:0]
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}